@@ -0,0 +1,95 @@
+// Command infer proposes a DataGuard schema and starter rules from sample records.
+//
+//	infer -file orders.json          # JSON array or one JSON object per line ("-" reads stdin)
+//	infer -table orders -limit 5000  # rows of a table, connecting to DATABASE_URL
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/singh-anurag-7991/data-guard/internal/domain"
+	"github.com/singh-anurag-7991/data-guard/internal/ingest/postgres"
+	"github.com/singh-anurag-7991/data-guard/internal/schema"
+)
+
+func main() {
+	file := flag.String("file", "", "JSON file of sample records")
+	table := flag.String("table", "", "table to sample, from DATABASE_URL")
+	limit := flag.Int("limit", schema.DefaultSampleSize, "rows sampled from -table")
+	flag.Parse()
+
+	records, err := load(*file, *table, *limit)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "infer:", err)
+		os.Exit(1)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(schema.Infer(records)); err != nil {
+		fmt.Fprintln(os.Stderr, "infer:", err)
+		os.Exit(1)
+	}
+}
+
+func load(file, table string, limit int) ([]domain.Record, error) {
+	switch {
+	case file != "" && table != "":
+		return nil, fmt.Errorf("use either -file or -table")
+	case table != "":
+		ctx := context.Background()
+		client, err := postgres.NewClient(ctx, os.Getenv("DATABASE_URL"))
+		if err != nil {
+			return nil, fmt.Errorf("connect: %w", err)
+		}
+		defer client.Close()
+		return schema.SampleTable(ctx, client, table, limit)
+	case file == "-":
+		return decode(os.Stdin)
+	case file != "":
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return decode(f)
+	default:
+		return nil, fmt.Errorf("-file or -table is required")
+	}
+}
+
+// decode reads a JSON array of records, or one record per line
+func decode(r io.Reader) ([]domain.Record, error) {
+	br := bufio.NewReader(r)
+	head, err := br.Peek(1)
+	for err == nil && strings.TrimSpace(string(head)) == "" {
+		br.ReadByte()
+		head, err = br.Peek(1)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("no records: %w", err)
+	}
+
+	var records []domain.Record
+	dec := json.NewDecoder(br)
+	if head[0] == '[' {
+		err := dec.Decode(&records)
+		return records, err
+	}
+	for {
+		var record domain.Record
+		if err := dec.Decode(&record); err == io.EOF {
+			return records, nil
+		} else if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+}
@@ -2,13 +2,22 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
+	"strconv"
+	"time"
 
+	"github.com/singh-anurag-7991/data-guard/internal/alerting"
+	"github.com/singh-anurag-7991/data-guard/internal/alerting/slack"
 	"github.com/singh-anurag-7991/data-guard/internal/api"
 	"github.com/singh-anurag-7991/data-guard/internal/engine"
+	"github.com/singh-anurag-7991/data-guard/internal/freshness"
 	"github.com/singh-anurag-7991/data-guard/internal/ingest/postgres"
+	"github.com/singh-anurag-7991/data-guard/internal/plugin"
+	"github.com/singh-anurag-7991/data-guard/internal/reference"
+	"github.com/singh-anurag-7991/data-guard/internal/schema"
 	"github.com/singh-anurag-7991/data-guard/internal/storage"
 	"github.com/singh-anurag-7991/data-guard/pkg/logger"
 )
@@ -27,9 +36,11 @@ func main() {
 	// Create context for DB connection
 	ctx := context.Background() // basic root context
 	var repo storage.Provider
+	var pgClient *postgres.Client
 
 	if dbURL != "" {
-		pgClient, err := postgres.NewClient(ctx, dbURL)
+		var err error
+		pgClient, err = postgres.NewClient(ctx, dbURL)
 		if err != nil {
 			slog.Error("Failed to connect to DB", "error", err)
 			os.Exit(1)
@@ -44,16 +55,74 @@ func main() {
 	// Initialize Engine
 	exec := engine.NewExecutor()
 
+	// Custom operators from the WebAssembly modules of PLUGINS_DIR, reloaded as files change
+	if dir := os.Getenv("PLUGINS_DIR"); dir != "" {
+		limits, err := pluginLimits()
+		if err != nil {
+			slog.Error("Invalid plugin limits", "error", err)
+			os.Exit(1)
+		}
+		loader, err := plugin.NewLoader(ctx, dir, exec.Registry(), limits)
+		if err != nil {
+			slog.Error("Failed to start plugin loader", "error", err)
+			os.Exit(1)
+		}
+		if err := loader.Reload(ctx); err != nil {
+			slog.Error("Failed to load plugins", "error", err)
+		}
+		go loader.Run(ctx, 10*time.Second)
+	}
+
+	// exists_in checks resolve against tables (when connected), lookup lists and snapshots
+	var references *reference.Resolver
+	if pgClient != nil {
+		references = reference.NewResolver(repo, pgClient)
+	} else {
+		references = reference.NewResolver(repo, nil)
+	}
+
+	// Alerts go to Slack when SLACK_WEBHOOK_URL is set
+	alerts := alerting.NewManager(slack.NewClient(os.Getenv("SLACK_WEBHOOK_URL")), storage.AlertStates(repo))
+
+	// Sources expected to deliver data regularly, e.g. FRESHNESS="orders=1h,payments=15m"
+	expectations, err := freshness.ParseExpectations(os.Getenv("FRESHNESS"))
+	if err != nil {
+		slog.Error("Invalid FRESHNESS", "error", err)
+		os.Exit(1)
+	}
+	if len(expectations) > 0 {
+		go freshness.NewMonitor(repo, alerts, expectations).Run(ctx, time.Minute)
+	}
+
 	// Initialize API Handlers
-	ingestHandler := api.NewHandler(exec, repo)
+	ingestHandler := api.NewHandler(exec, repo, references, alerts)
 	dashboardHandler := api.NewDashboardHandler(repo)
+	lookupHandler := api.NewLookupHandler(repo)
+	var tables schema.RowFetcher // Left nil rather than holding a nil *postgres.Client
+	if pgClient != nil {
+		tables = pgClient
+	}
+	schemaHandler := api.NewSchemaHandler(tables)
+	operatorHandler := api.NewOperatorHandler(exec)
 
 	// Register Routes
 	mux := http.NewServeMux()
 	mux.HandleFunc("/ingest/api", ingestHandler.Ingest)
+	mux.HandleFunc("/api/schemas/import", schemaHandler.Import)
+	mux.HandleFunc("/api/schemas/infer", schemaHandler.Infer)
+	mux.HandleFunc("/api/operators", operatorHandler.List)
+
+	if pgClient != nil {
+		// Tables in the same database can be validated in place
+		tableHandler := api.NewTableHandler(exec, pgClient, repo, references, alerts)
+		mux.HandleFunc("/validate/table", tableHandler.Validate)
+	}
 
 	if repo != nil {
 		mux.HandleFunc("/api/runs", dashboardHandler.ListRuns)
+		mux.HandleFunc("/api/rules/stats", dashboardHandler.RuleStats)
+		mux.HandleFunc("/api/profiles", dashboardHandler.Profiles)
+		mux.HandleFunc("/api/lookups", lookupHandler.Lookups)
 	}
 
 	// Start Server
@@ -68,3 +137,23 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// pluginLimits reads PLUGIN_TIMEOUT (e.g. "250ms") and PLUGIN_MEMORY_MB over the defaults
+func pluginLimits() (plugin.Limits, error) {
+	limits := plugin.DefaultLimits
+	if v := os.Getenv("PLUGIN_TIMEOUT"); v != "" {
+		timeout, err := time.ParseDuration(v)
+		if err != nil {
+			return limits, fmt.Errorf("PLUGIN_TIMEOUT: %w", err)
+		}
+		limits.Timeout = timeout
+	}
+	if v := os.Getenv("PLUGIN_MEMORY_MB"); v != "" {
+		mb, err := strconv.Atoi(v)
+		if err != nil || mb <= 0 || mb > 4096 {
+			return limits, fmt.Errorf("PLUGIN_MEMORY_MB must be between 1 and 4096")
+		}
+		limits.MemoryPages = uint32(mb * 16) // 64 KiB pages
+	}
+	return limits, nil
+}
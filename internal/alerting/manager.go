@@ -2,6 +2,8 @@ package alerting
 
 import (
 	"fmt"
+	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/singh-anurag-7991/data-guard/internal/domain"
@@ -11,6 +13,7 @@ type State string
 
 const (
 	StateOK   State = "PASS"
+	StateWarn State = "WARN"
 	StateFail State = "FAIL"
 )
 
@@ -37,34 +40,105 @@ func NewManager(n Notifier, sm StateManager) *Manager {
 
 // ProcessResult decides whether to send an alert based on the result and previous state
 func (m *Manager) ProcessResult(res domain.ValidationResult) error {
+	// Drift is reported once by the detector, so it alerts whatever the status did. Failed drift
+	// and anomaly alerts must not hold back the status alert below.
+	if len(res.Drift) > 0 {
+		if err := m.notifier.Send("🧬 Schema Drift Detected", driftMessage(res), "#6A5ACD"); err != nil {
+			slog.Error("Failed to send drift alert", "source_id", res.SourceID, "error", err)
+		}
+	}
+
+	if len(res.Anomalies) > 0 {
+		if err := m.notifier.Send("📈 Metric Anomaly Detected", anomalyMessage(res), "#FFA500"); err != nil {
+			slog.Error("Failed to send anomaly alert", "source_id", res.SourceID, "error", err)
+		}
+	}
+
 	lastState, err := m.stateManager.GetLastState(res.SourceID)
-	if err != nil {
+	if err != nil || lastState == "" {
 		// If no state found, assume OK (first run)
 		lastState = StateOK
 	}
 
 	currentState := State(res.Status)
 
-	// State Machine Logic
-	if lastState == StateOK && currentState == StateFail {
-		// New Failure -> Alert
-		msg := fmt.Sprintf("Source '%s' has failed validation.\nRules Failed: %d\nTime: %s",
+	// State Machine Logic: only transitions are worth an alert
+	// PASS -> PASS, WARN -> WARN, FAIL -> FAIL: Do nothing (Suppress)
+	if lastState == currentState {
+		return nil
+	}
+
+	var title, msg, color string
+	switch {
+	case currentState == StateFail && res.Trigger == domain.TriggerFreshness && len(res.Errors) > 0:
+		// No data at all, the reason says for how long
+		title, color = "⏰ Data Source Overdue", "#FF0000"
+		msg = fmt.Sprintf("Source '%s' is overdue.\n%s\nTime: %s",
+			res.SourceID, res.Errors[0].Reason, res.Timestamp.Format(time.RFC3339))
+	case currentState == StateFail:
+		// New Failure (from PASS or WARN) -> Alert
+		title, color = "🚨 Data Validation Failed", "#FF0000"
+		msg = fmt.Sprintf("Source '%s' has failed validation.\nRules Failed: %d\nTime: %s",
 			res.SourceID, res.RulesFailed, res.Timestamp.Format(time.RFC3339))
-		if err := m.notifier.Send("🚨 Data Validation Failed", msg, "#FF0000"); err != nil {
-			return err
+	case currentState == StateWarn:
+		if lastState == StateFail {
+			// Partial recovery -> Alert
+			title, color = "⚠️ Data Validation Improved to Warning", "#FFA500"
+			msg = fmt.Sprintf("Source '%s' is no longer failing but still has warnings.\nRules Failed: %d", res.SourceID, res.RulesFailed)
+		} else {
+			// New Warning -> Alert
+			title, color = "⚠️ Data Validation Warning", "#FFA500"
+			msg = fmt.Sprintf("Source '%s' has warnings.\nRules Failed: %d\nTime: %s",
+				res.SourceID, res.RulesFailed, res.Timestamp.Format(time.RFC3339))
 		}
-		return m.stateManager.UpdateState(res.SourceID, StateFail)
+	case currentState == StateOK:
+		// Recovery -> Alert
+		title, color = "✅ Data Validation Recovered", "#36a64f"
+		msg = fmt.Sprintf("Source '%s' has recovered and is passing validation.", res.SourceID)
+	default:
+		return fmt.Errorf("unknown status %q for source %s", res.Status, res.SourceID)
 	}
 
-	if lastState == StateFail && currentState == StateOK {
-		// Recovery -> Alert
-		msg := fmt.Sprintf("Source '%s' has recovered and is passing validation.", res.SourceID)
-		if err := m.notifier.Send("✅ Data Validation Recovered", msg, "#36a64f"); err != nil {
-			return err
+	if err := m.notifier.Send(title, msg, color); err != nil {
+		return err
+	}
+	return m.stateManager.UpdateState(res.SourceID, currentState)
+}
+
+// maxAlertLines bounds how many changes a drift or anomaly alert lists
+const maxAlertLines = 10
+
+func driftMessage(res domain.ValidationResult) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Source '%s' changed shape.\n", res.SourceID)
+	for i, d := range res.Drift {
+		if i == maxAlertLines {
+			fmt.Fprintf(&b, "...and %d more\n", len(res.Drift)-i)
+			break
+		}
+		switch d.Kind {
+		case domain.DriftAdded:
+			fmt.Fprintf(&b, "+ %s (%s)\n", d.Field, d.After)
+		case domain.DriftRemoved:
+			fmt.Fprintf(&b, "- %s (was %s)\n", d.Field, d.Before)
+		default:
+			fmt.Fprintf(&b, "~ %s: %s -> %s\n", d.Field, d.Before, d.After)
 		}
-		return m.stateManager.UpdateState(res.SourceID, StateOK)
 	}
+	fmt.Fprintf(&b, "Time: %s", res.Timestamp.Format(time.RFC3339))
+	return b.String()
+}
 
-	// OK -> OK or FAIL -> FAIL: Do nothing (Suppress)
-	return nil
+func anomalyMessage(res domain.ValidationResult) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Source '%s' has unusual metrics.\n", res.SourceID)
+	for i, a := range res.Anomalies {
+		if i == maxAlertLines {
+			fmt.Fprintf(&b, "...and %d more\n", len(res.Anomalies)-i)
+			break
+		}
+		fmt.Fprintf(&b, "%s: %g, expected about %g (score %.1f)\n", a.Metric, a.Value, a.Expected, a.Score)
+	}
+	fmt.Fprintf(&b, "Time: %s", res.Timestamp.Format(time.RFC3339))
+	return b.String()
 }
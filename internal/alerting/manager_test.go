@@ -1,6 +1,7 @@
 package alerting
 
 import (
+	"errors"
 	"testing"
 	"time"
 
@@ -10,9 +11,13 @@ import (
 // Mock objects
 type mockNotifier struct {
 	sentCount int
+	failTitle string // Send fails for alerts with this title
 }
 
 func (m *mockNotifier) Send(title, message, color string) error {
+	if title == m.failTitle {
+		return errors.New("send failed")
+	}
 	m.sentCount++
 	return nil
 }
@@ -63,3 +68,96 @@ func TestManager_ProcessResult(t *testing.T) {
 		t.Errorf("state should update to OK")
 	}
 }
+
+func TestManager_ProcessResultWarn(t *testing.T) {
+	mockNotif := &mockNotifier{}
+	mockState := &mockStateManager{state: StateOK}
+	manager := NewManager(mockNotif, mockState)
+
+	warnRes := domain.ValidationResult{SourceID: "src", Status: "WARN", RulesFailed: 1, Timestamp: time.Now()}
+	failRes := domain.ValidationResult{SourceID: "src", Status: "FAIL", RulesFailed: 1, Timestamp: time.Now()}
+
+	steps := []struct {
+		res       domain.ValidationResult
+		wantSent  int
+		wantState State
+	}{
+		{warnRes, 1, StateWarn}, // PASS -> WARN alerts
+		{warnRes, 1, StateWarn}, // WARN -> WARN suppressed
+		{failRes, 2, StateFail}, // WARN -> FAIL escalates
+		{warnRes, 3, StateWarn}, // FAIL -> WARN partial recovery
+	}
+
+	for i, step := range steps {
+		_ = manager.ProcessResult(step.res)
+		if mockNotif.sentCount != step.wantSent {
+			t.Errorf("step %d: expected %d alerts, got %d", i, step.wantSent, mockNotif.sentCount)
+		}
+		if mockState.state != step.wantState {
+			t.Errorf("step %d: expected state %s, got %s", i, step.wantState, mockState.state)
+		}
+	}
+}
+
+func TestManager_ProcessResultDrift(t *testing.T) {
+	mockNotif := &mockNotifier{}
+	mockState := &mockStateManager{state: StateOK}
+	manager := NewManager(mockNotif, mockState)
+
+	drifted := domain.ValidationResult{
+		SourceID: "src",
+		Status:   "PASS",
+		Drift:    []domain.Drift{{Field: "amount", Kind: domain.DriftTypeChanged, Before: "number", After: "string"}},
+	}
+
+	// PASS -> PASS with drift alerts anyway
+	_ = manager.ProcessResult(drifted)
+	if mockNotif.sentCount != 1 {
+		t.Errorf("expected 1 drift alert, got %d", mockNotif.sentCount)
+	}
+
+	// Drift alongside a new failure sends both alerts
+	drifted.Status = "FAIL"
+	_ = manager.ProcessResult(drifted)
+	if mockNotif.sentCount != 3 {
+		t.Errorf("expected 3 alerts (2 drift + 1 fail), got %d", mockNotif.sentCount)
+	}
+	if mockState.state != StateFail {
+		t.Errorf("state should update to FAIL")
+	}
+
+	// A failed drift alert still lets the status alert through
+	mockNotif.failTitle = "🧬 Schema Drift Detected"
+	drifted.Status = "PASS"
+	_ = manager.ProcessResult(drifted)
+	if mockNotif.sentCount != 4 || mockState.state != StateOK {
+		t.Errorf("expected the recovery alert despite the failed drift alert, got %d alerts and state %s", mockNotif.sentCount, mockState.state)
+	}
+}
+
+func TestManager_ProcessResultAnomaly(t *testing.T) {
+	mockNotif := &mockNotifier{}
+	mockState := &mockStateManager{state: StateOK}
+	manager := NewManager(mockNotif, mockState)
+
+	res := domain.ValidationResult{
+		SourceID:  "src",
+		Status:    "PASS",
+		Anomalies: []domain.Anomaly{{Metric: "record_count", Value: 10, Expected: 1000, Score: -42, Baseline: "hour_of_week", Samples: 4}},
+	}
+	_ = manager.ProcessResult(res)
+	if mockNotif.sentCount != 1 {
+		t.Errorf("expected 1 anomaly alert, got %d", mockNotif.sentCount)
+	}
+	if mockState.state != StateOK {
+		t.Errorf("anomalies should not change the state")
+	}
+
+	// A failed anomaly alert still lets the status alert through
+	mockNotif.failTitle = "📈 Metric Anomaly Detected"
+	res.Status = "FAIL"
+	_ = manager.ProcessResult(res)
+	if mockNotif.sentCount != 2 || mockState.state != StateFail {
+		t.Errorf("expected the failure alert despite the failed anomaly alert, got %d alerts and state %s", mockNotif.sentCount, mockState.state)
+	}
+}
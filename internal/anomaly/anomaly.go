@@ -0,0 +1,175 @@
+// Package anomaly flags runs whose metrics stray from the history of their source. Every run
+// measures its record count, rule failure rates and per-field null ratios and means; each
+// metric is compared with earlier runs at the same hour of the week (falling back to all
+// earlier runs) using a robust z-score built on the median absolute deviation.
+package anomaly
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/singh-anurag-7991/data-guard/internal/domain"
+	"github.com/singh-anurag-7991/data-guard/internal/operators"
+)
+
+// Metric names, the per-rule and per-field ones followed by ":<rule id>" or ":<field>"
+const (
+	MetricRecordCount = "record_count"
+	MetricFailureRate = "failure_rate"
+	MetricNullRatio   = "null_ratio"
+	MetricMean        = "mean"
+)
+
+// Baselines a metric is compared with
+const (
+	BaselineHourOfWeek = "hour_of_week"
+	BaselineAll        = "all"
+)
+
+const (
+	// HistoryLimit is how many earlier runs make up the baselines, 12 weeks of hourly runs
+	HistoryLimit = 2016
+
+	threshold     = 3.5  // robust z-scores beyond this are anomalies
+	minSeasonal   = 4    // runs at the same hour of the week needed for a seasonal baseline
+	minHistory    = 10   // runs needed for any baseline
+	relativeFloor = 0.05 // spread assumed for constant series, as a fraction of their median
+)
+
+// Metrics measures a run: the records checked, the failure rate of each evaluated rule and,
+// over records, the null ratio of every top-level field and the mean of numeric ones.
+func Metrics(res domain.ValidationResult, records []domain.Record) map[string]float64 {
+	metrics := map[string]float64{MetricRecordCount: float64(res.RecordsChecked)}
+	for _, s := range res.RuleSummaries {
+		if s.Evaluated > 0 {
+			metrics[MetricFailureRate+":"+s.RuleID] = s.FailureRatio
+		}
+	}
+	if len(records) == 0 {
+		return metrics
+	}
+
+	type fieldStats struct {
+		set     int
+		numbers []float64
+	}
+	fields := map[string]*fieldStats{}
+	for _, record := range records {
+		for field, val := range record {
+			st, ok := fields[field]
+			if !ok {
+				st = &fieldStats{}
+				fields[field] = st
+			}
+			if val == nil {
+				continue
+			}
+			st.set++
+			if f, ok := operators.ToFloat(val); ok {
+				st.numbers = append(st.numbers, f)
+			}
+		}
+	}
+
+	for field, st := range fields {
+		// Missing fields count as null
+		metrics[MetricNullRatio+":"+field] = 1 - float64(st.set)/float64(len(records))
+		if st.set > 0 && len(st.numbers) == st.set {
+			var sum float64
+			for _, f := range st.numbers {
+				sum += f
+			}
+			metrics[MetricMean+":"+field] = sum / float64(len(st.numbers))
+		}
+	}
+	return metrics
+}
+
+// Detect compares the metrics of a run made at the given time with those of earlier runs,
+// returning the anomalies sorted by metric. Runs without metrics, like those of overdue
+// sources, are ignored.
+func Detect(at time.Time, metrics map[string]float64, history []domain.ValidationResult) []domain.Anomaly {
+	slot := hourOfWeek(at)
+	var anomalies []domain.Anomaly
+	for metric, value := range metrics {
+		var seasonal, all []float64
+		for _, run := range history {
+			v, ok := run.Metrics[metric]
+			if !ok {
+				continue
+			}
+			all = append(all, v)
+			if hourOfWeek(run.Timestamp) == slot {
+				seasonal = append(seasonal, v)
+			}
+		}
+
+		baseline, values := BaselineHourOfWeek, seasonal
+		if len(seasonal) < minSeasonal {
+			baseline, values = BaselineAll, all
+		}
+		if baseline == BaselineAll && len(values) < minHistory {
+			continue // Too little history to tell
+		}
+
+		median, score, ok := robustScore(values, value)
+		if !ok || math.Abs(score) <= threshold {
+			continue
+		}
+		anomalies = append(anomalies, domain.Anomaly{
+			Metric:   metric,
+			Value:    value,
+			Expected: median,
+			Score:    math.Round(score*100) / 100,
+			Baseline: baseline,
+			Samples:  len(values),
+		})
+	}
+
+	sort.Slice(anomalies, func(i, j int) bool { return anomalies[i].Metric < anomalies[j].Metric })
+	return anomalies
+}
+
+// hourOfWeek buckets a time into one of the 168 hours of a UTC week
+func hourOfWeek(t time.Time) int {
+	t = t.UTC()
+	return int(t.Weekday())*24 + t.Hour()
+}
+
+// robustScore returns the median of values and how many scaled median absolute deviations x
+// is away from it. Without any spread it falls back to the mean absolute deviation, then to a
+// fraction of the median; ok is false when the values are all zero.
+func robustScore(values []float64, x float64) (median, score float64, ok bool) {
+	median = medianOf(values)
+
+	deviations := make([]float64, len(values))
+	var sum float64
+	for i, v := range values {
+		deviations[i] = math.Abs(v - median)
+		sum += deviations[i]
+	}
+
+	// The constants make each estimate match the standard deviation of normal data
+	scale := 1.4826 * medianOf(deviations)
+	if scale == 0 {
+		scale = 1.2533 * sum / float64(len(values))
+	}
+	if scale == 0 {
+		scale = relativeFloor * math.Abs(median)
+	}
+	if scale == 0 {
+		return median, 0, false
+	}
+	return median, (x - median) / scale, true
+}
+
+func medianOf(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
@@ -0,0 +1,119 @@
+package anomaly
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/singh-anurag-7991/data-guard/internal/domain"
+)
+
+func TestMetrics(t *testing.T) {
+	res := domain.ValidationResult{
+		RecordsChecked: 4,
+		RuleSummaries: []domain.RuleSummary{
+			{RuleID: "positive_amount", Evaluated: 4, Failed: 1, FailureRatio: 0.25},
+			{RuleID: "never_applied"},
+		},
+	}
+	records := []domain.Record{
+		{"amount": 10, "email": "a@x.io"},
+		{"amount": 20.0, "email": nil},
+		{"amount": nil},
+		{"amount": 30, "email": "c@x.io"},
+	}
+
+	want := map[string]float64{
+		"record_count":                 4,
+		"failure_rate:positive_amount": 0.25,
+		"null_ratio:amount":            0.25,
+		"null_ratio:email":             0.5, // missing counts as null
+		"mean:amount":                  20,
+	}
+	if got := Metrics(res, records); !reflect.DeepEqual(got, want) {
+		t.Errorf("Metrics() = %v, want %v", got, want)
+	}
+}
+
+// hourly runs over four weeks: 1000 records during the day, 100 at night (UTC)
+func hourlyHistory(end time.Time) []domain.ValidationResult {
+	var history []domain.ValidationResult
+	for at := end.Add(-28 * 24 * time.Hour); at.Before(end); at = at.Add(time.Hour) {
+		count := 100.0
+		if at.Hour() >= 8 && at.Hour() < 20 {
+			count = 1000 + float64(at.Day()%5) // some noise
+		}
+		history = append(history, domain.ValidationResult{
+			Timestamp: at,
+			Metrics:   map[string]float64{MetricRecordCount: count},
+		})
+	}
+	return history
+}
+
+func TestDetect(t *testing.T) {
+	night := time.Date(2024, 5, 6, 3, 0, 0, 0, time.UTC)
+	day := time.Date(2024, 5, 6, 12, 0, 0, 0, time.UTC)
+	history := hourlyHistory(night)
+
+	tests := []struct {
+		name    string
+		at      time.Time
+		metrics map[string]float64
+		want    []domain.Anomaly
+	}{
+		{
+			name:    "quiet night is normal",
+			at:      night,
+			metrics: map[string]float64{MetricRecordCount: 100},
+		},
+		{
+			name:    "busy day is normal",
+			at:      day,
+			metrics: map[string]float64{MetricRecordCount: 1002},
+		},
+		{
+			name:    "day volume at night",
+			at:      night,
+			metrics: map[string]float64{MetricRecordCount: 1000},
+			want:    []domain.Anomaly{{Metric: MetricRecordCount, Value: 1000, Expected: 100, Score: 180, Baseline: BaselineHourOfWeek, Samples: 4}},
+		},
+		{
+			name:    "unknown metrics have no baseline",
+			at:      night,
+			metrics: map[string]float64{"mean:amount": 5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Detect(tt.at, tt.metrics, history)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Detect() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetect_AllRuns(t *testing.T) {
+	// Fewer than minSeasonal runs per hour of the week: all runs form the baseline
+	start := time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC)
+	var history []domain.ValidationResult
+	for i := 0; i < 12; i++ {
+		history = append(history, domain.ValidationResult{
+			Timestamp: start.Add(time.Duration(i) * time.Hour),
+			Metrics:   map[string]float64{"null_ratio:email": 0.1, MetricRecordCount: 500},
+		})
+	}
+
+	got := Detect(start.Add(12*time.Hour), map[string]float64{"null_ratio:email": 0.6, MetricRecordCount: 510}, history)
+	want := []domain.Anomaly{{Metric: "null_ratio:email", Value: 0.6, Expected: 0.1, Score: 100, Baseline: BaselineAll, Samples: 12}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Detect() = %+v, want %+v", got, want)
+	}
+
+	// Too little history flags nothing
+	if got := Detect(start, map[string]float64{MetricRecordCount: 5}, history[:5]); len(got) != 0 {
+		t.Errorf("expected no anomalies without enough history, got %+v", got)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/singh-anurag-7991/data-guard/internal/domain"
 	"github.com/singh-anurag-7991/data-guard/internal/storage"
 )
 
@@ -37,3 +38,67 @@ func (h *DashboardHandler) ListRuns(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Access-Control-Allow-Origin", "*") // Allow generic CORS for local dev
 	json.NewEncoder(w).Encode(runs)
 }
+
+// RuleStats returns per-rule summaries of recent runs for trend charts
+func (h *DashboardHandler) RuleStats(w http.ResponseWriter, r *http.Request) {
+	sourceID := r.URL.Query().Get("source_id")
+	ruleID := r.URL.Query().Get("rule_id")
+	limitStr := r.URL.Query().Get("limit")
+	limit := 50
+	if limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
+			limit = l
+		}
+	}
+
+	points, err := h.repo.GetRuleHistory(r.Context(), sourceID, ruleID, limit)
+	if err != nil {
+		http.Error(w, "Failed to fetch rule stats", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*") // Allow generic CORS for local dev
+	json.NewEncoder(w).Encode(points)
+}
+
+// Profiles returns the field profiles of recent runs of a source, optionally of one field
+func (h *DashboardHandler) Profiles(w http.ResponseWriter, r *http.Request) {
+	sourceID := r.URL.Query().Get("source_id")
+	if sourceID == "" {
+		http.Error(w, "source_id is required", http.StatusBadRequest)
+		return
+	}
+	field := r.URL.Query().Get("field")
+	limitStr := r.URL.Query().Get("limit")
+	limit := 10
+	if limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
+			limit = l
+		}
+	}
+
+	profiles, err := h.repo.GetProfiles(r.Context(), sourceID, limit)
+	if err != nil {
+		http.Error(w, "Failed to fetch profiles", http.StatusInternalServerError)
+		return
+	}
+	if profiles == nil {
+		profiles = []domain.RunProfile{}
+	}
+	if field != "" {
+		for i, p := range profiles {
+			var fields []domain.FieldProfile
+			for _, f := range p.Fields {
+				if f.Field == field {
+					fields = append(fields, f)
+				}
+			}
+			profiles[i].Fields = fields
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*") // Allow generic CORS for local dev
+	json.NewEncoder(w).Encode(profiles)
+}
@@ -1,30 +1,71 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"log/slog"
 	"net/http"
+	"time"
 
+	"github.com/singh-anurag-7991/data-guard/internal/alerting"
+	"github.com/singh-anurag-7991/data-guard/internal/anomaly"
 	"github.com/singh-anurag-7991/data-guard/internal/domain"
+	"github.com/singh-anurag-7991/data-guard/internal/drift"
 	"github.com/singh-anurag-7991/data-guard/internal/engine"
 	"github.com/singh-anurag-7991/data-guard/internal/storage"
 )
 
 type IngestRequest struct {
-	SourceID string          `json:"source_id"`
-	Schema   domain.Schema   `json:"schema"`
-	Rules    []domain.Rule   `json:"rules"`
-	Data     []domain.Record `json:"data"`
+	SourceID  string          `json:"source_id"`
+	Schema    domain.Schema   `json:"schema"`
+	Rules     []domain.Rule   `json:"rules"`
+	Data      []domain.Record `json:"data"`
+	KeyFields []string        `json:"key_fields,omitempty"` // Field(s) identifying a record in error details
+	Options   *IngestOptions  `json:"options,omitempty"`
+}
+
+// IngestOptions lets a caller bound the cost of validating its payload
+type IngestOptions struct {
+	TimeoutMs          int  `json:"timeout_ms,omitempty"`
+	MaxErrors          int  `json:"max_errors,omitempty"`
+	FailFast           bool `json:"fail_fast,omitempty"`
+	StrictSchema       bool `json:"strict_schema,omitempty"`         // Fail fields the schema does not declare
+	RulesOnValidFields bool `json:"rules_on_valid_fields,omitempty"` // Still run rules on fields that passed the schema
+	Profile            bool `json:"profile,omitempty"`               // Store a profile of every field with the run
+	NumericStrings     bool `json:"numeric_strings,omitempty"`       // Read numeric strings ("12.50") as numbers
+	Snapshot           bool `json:"snapshot,omitempty"`              // Keep the data as the source's latest dataset
+}
+
+func (o *IngestOptions) toEngine(keyFields []string) engine.ValidateOptions {
+	if o == nil {
+		return engine.ValidateOptions{KeyFields: keyFields}
+	}
+	return engine.ValidateOptions{
+		MaxDuration: time.Duration(o.TimeoutMs) * time.Millisecond,
+		MaxErrors:   o.MaxErrors,
+		FailFast:    o.FailFast,
+		KeyFields:   keyFields,
+
+		StrictSchema:       o.StrictSchema,
+		RulesOnValidFields: o.RulesOnValidFields,
+		Profile:            o.Profile,
+		NumericStrings:     o.NumericStrings,
+	}
 }
 
 type Handler struct {
-	executor *engine.Executor
-	repo     storage.Provider
+	executor   *engine.Executor
+	repo       storage.Provider
+	references engine.ReferenceResolver
+	alerts     *alerting.Manager // may be nil
 }
 
-func NewHandler(executor *engine.Executor, repo storage.Provider) *Handler {
+func NewHandler(executor *engine.Executor, repo storage.Provider, references engine.ReferenceResolver, alerts *alerting.Manager) *Handler {
 	return &Handler{
-		executor: executor,
-		repo:     repo,
+		executor:   executor,
+		repo:       repo,
+		references: references,
+		alerts:     alerts,
 	}
 }
 
@@ -45,18 +86,100 @@ func (h *Handler) Ingest(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result := h.executor.Validate(req.SourceID, req.Schema, req.Rules, req.Data)
+	// Tie validation to the request so a disconnected client stops the run
+	opts := req.Options.toEngine(req.KeyFields)
+	opts.References = h.references
+	opts.Profiles = storedProfiles(h.repo)
+	result := h.executor.ValidateContext(r.Context(), req.SourceID, req.Schema, req.Rules, req.Data, opts)
+	if result.TruncatedReason == engine.TruncatedCanceled {
+		return // Client is gone, nobody to respond to
+	}
 
 	// Save result to storage (Best effort)
 	if h.repo != nil {
+		detectDrift(r.Context(), h.repo, &result, req.Data)
+		detectAnomalies(r.Context(), h.repo, &result, req.Data)
 		if err := h.repo.SaveResult(r.Context(), result); err != nil {
-			// Log error but don't fail the response
-			// In a real app we'd use slog here
+			slog.Error("Failed to save result", "source_id", req.SourceID, "error", err)
+		}
+		// Latest dataset, for references of other sources and shift baselines. A truncated run
+		// must not replace the dataset of a complete one.
+		if wantsSnapshot(req) && !result.Truncated {
+			if err := h.repo.SaveSnapshot(r.Context(), req.SourceID, req.Data); err != nil {
+				slog.Error("Failed to save snapshot", "source_id", req.SourceID, "error", err)
+			}
 		}
 	}
+	processAlerts(h.alerts, result)
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(result); err != nil {
 		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
 	}
 }
+
+// wantsSnapshot reports whether the data is kept as the source's latest dataset: when asked to,
+// or when a shift check of the request compares with the source's previous dataset
+func wantsSnapshot(req IngestRequest) bool {
+	if req.Options != nil && req.Options.Snapshot {
+		return true
+	}
+	for _, rule := range req.Rules {
+		for _, check := range rule.Checks {
+			if check.IsShift() && check.Ref == nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// storedProfiles serves the profiles of earlier runs to profile_change checks, nil without storage
+func storedProfiles(repo storage.Provider) engine.ProfileSource {
+	if repo == nil {
+		return nil
+	}
+	return lastProfiles{repo}
+}
+
+type lastProfiles struct {
+	repo storage.Provider
+}
+
+func (l lastProfiles) LastProfile(ctx context.Context, sourceID string) ([]domain.FieldProfile, error) {
+	runs, err := l.repo.GetProfiles(ctx, sourceID, 1)
+	if err != nil || len(runs) == 0 {
+		return nil, err
+	}
+	return runs[0].Fields, nil
+}
+
+// detectDrift records on result how records differ in shape from earlier runs (best effort)
+func detectDrift(ctx context.Context, repo storage.Provider, result *domain.ValidationResult, records []domain.Record) {
+	d, err := drift.NewDetector(repo).Check(ctx, result.SourceID, records)
+	if err != nil {
+		slog.Error("Failed to detect schema drift", "source_id", result.SourceID, "error", err)
+	}
+	result.Drift = d
+}
+
+// detectAnomalies measures the run and compares it with earlier runs of the source (best effort)
+func detectAnomalies(ctx context.Context, repo storage.Provider, result *domain.ValidationResult, records []domain.Record) {
+	result.Metrics = anomaly.Metrics(*result, records)
+	history, err := repo.GetRecentRuns(ctx, result.SourceID, anomaly.HistoryLimit)
+	if err != nil {
+		slog.Error("Failed to load metrics history", "source_id", result.SourceID, "error", err)
+		return
+	}
+	result.Anomalies = anomaly.Detect(result.Timestamp, result.Metrics, history)
+}
+
+// processAlerts raises alerts on status changes of a source (best effort)
+func processAlerts(alerts *alerting.Manager, result domain.ValidationResult) {
+	if alerts == nil {
+		return
+	}
+	if err := alerts.ProcessResult(result); err != nil {
+		slog.Error("Failed to process alerts", "source_id", result.SourceID, "error", err)
+	}
+}
@@ -2,18 +2,25 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/singh-anurag-7991/data-guard/internal/domain"
 	"github.com/singh-anurag-7991/data-guard/internal/engine"
+	"github.com/singh-anurag-7991/data-guard/internal/operators"
+	"github.com/singh-anurag-7991/data-guard/internal/reference"
+	"github.com/singh-anurag-7991/data-guard/internal/schema"
+	"github.com/singh-anurag-7991/data-guard/internal/storage"
 )
 
 func TestHandler_Ingest(t *testing.T) {
 	exec := engine.NewExecutor()
-	handler := NewHandler(exec, nil)
+	handler := NewHandler(exec, nil, nil, nil)
 
 	reqBody := IngestRequest{
 		SourceID: "test_source",
@@ -58,3 +65,248 @@ func TestHandler_Ingest(t *testing.T) {
 		t.Errorf("expected 1 rule failure, got %d", result.RulesFailed)
 	}
 }
+
+func TestDashboardHandler_RuleStats(t *testing.T) {
+	repo := storage.NewMemoryStore()
+	exec := engine.NewExecutor()
+	rules := []domain.Rule{
+		{ID: "positive", Field: "amount", Checks: []domain.Check{{Op: "gt", Value: 0}}},
+		{ID: "present", Field: "amount", Checks: []domain.Check{{Op: "not_null"}}},
+	}
+	_ = repo.SaveResult(context.Background(), exec.Validate("orders", nil, rules, []domain.Record{{"amount": 1}, {"amount": -1}}))
+	_ = repo.SaveResult(context.Background(), exec.Validate("orders", nil, rules, []domain.Record{{"amount": 1}}))
+
+	handler := NewDashboardHandler(repo)
+	req := httptest.NewRequest(http.MethodGet, "/api/rules/stats?source_id=orders&rule_id=positive", nil)
+	w := httptest.NewRecorder()
+	handler.RuleStats(w, req)
+
+	var points []domain.RuleSummaryPoint
+	if err := json.NewDecoder(w.Result().Body).Decode(&points); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(points) != 2 {
+		t.Fatalf("expected 2 points (one per run), got %d", len(points))
+	}
+	for _, p := range points {
+		if p.RuleID != "positive" || p.SourceID != "orders" {
+			t.Errorf("unexpected point: %+v", p)
+		}
+	}
+}
+
+func TestOperatorHandler_List(t *testing.T) {
+	ops := operators.NewRegistry()
+	_ = ops.Register(operators.Operator{
+		Name:        "even",
+		Description: "the number is even",
+		Func:        func(value interface{}, check domain.Check) (bool, string) { return true, "" },
+	})
+	handler := NewOperatorHandler(engine.NewExecutorWithRegistry(ops))
+
+	w := httptest.NewRecorder()
+	handler.List(w, httptest.NewRequest(http.MethodGet, "/api/operators", nil))
+
+	var list []struct {
+		Name     string `json:"name"`
+		Scope    string `json:"scope"`
+		NullSafe bool   `json:"null_safe"`
+		Pushdown bool   `json:"pushdown"`
+	}
+	if err := json.NewDecoder(w.Result().Body).Decode(&list); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	byName := map[string]int{}
+	for i, op := range list {
+		byName[op.Name] = i
+	}
+	for name, want := range map[string]string{"even": engine.ScopeRecord, "gt": engine.ScopeRecord, "expr": engine.ScopeRecord, "unique": engine.ScopeDataset, "psi": engine.ScopeDataset} {
+		if i, ok := byName[name]; !ok || list[i].Scope != want {
+			t.Errorf("expected %s to be listed as a %s operator", name, want)
+		}
+	}
+	if even := list[byName["even"]]; even.NullSafe || even.Pushdown {
+		t.Errorf("expected even to be neither null-safe nor pushed down, got %+v", even)
+	}
+	if !list[byName["gt"]].Pushdown || list[byName["psi"]].Pushdown {
+		t.Error("expected gt but not psi to be pushed down")
+	}
+}
+
+func TestHandler_IngestProfiles(t *testing.T) {
+	repo := storage.NewMemoryStore()
+	handler := NewHandler(engine.NewExecutor(), repo, nil, nil)
+	drop := -0.2
+	rules := []domain.Rule{{ID: "countries_kept", Field: "country", Checks: []domain.Check{{Op: domain.OpProfileChange, Stat: "distinct", Min: &drop}}}}
+	ingest := func(countries ...string) domain.ValidationResult {
+		var data []domain.Record
+		for _, c := range countries {
+			data = append(data, domain.Record{"country": c})
+		}
+		body, _ := json.Marshal(IngestRequest{SourceID: "orders", Rules: rules, Data: data, Options: &IngestOptions{Profile: true}})
+		w := httptest.NewRecorder()
+		handler.Ingest(w, httptest.NewRequest(http.MethodPost, "/ingest/api", bytes.NewReader(body)))
+		var result domain.ValidationResult
+		if err := json.NewDecoder(w.Result().Body).Decode(&result); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return result
+	}
+
+	if res := ingest("DE", "FR", "IT", "ES", "NL"); res.Status != domain.StatusPass {
+		t.Fatalf("expected the first run to pass, got %+v", res.Errors)
+	}
+	if res := ingest("DE", "FR", "DE"); res.Status != domain.StatusFail {
+		t.Errorf("expected 5 -> 2 countries to fail, got %s", res.Status)
+	}
+
+	w := httptest.NewRecorder()
+	NewDashboardHandler(repo).Profiles(w, httptest.NewRequest(http.MethodGet, "/api/profiles?source_id=orders&field=country", nil))
+	var profiles []domain.RunProfile
+	if err := json.NewDecoder(w.Result().Body).Decode(&profiles); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(profiles) != 2 || len(profiles[0].Fields) != 1 || profiles[0].Fields[0].Distinct != 2 {
+		t.Errorf("expected the latest profile first, got %+v", profiles)
+	}
+}
+
+func TestHandler_IngestReferences(t *testing.T) {
+	repo := storage.NewMemoryStore()
+	lookups := NewLookupHandler(repo)
+
+	body, _ := json.Marshal(LookupRequest{Name: "countries", Values: []interface{}{"DE", "FR"}})
+	w := httptest.NewRecorder()
+	lookups.Lookups(w, httptest.NewRequest(http.MethodPost, "/api/lookups", bytes.NewReader(body)))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204 storing the lookup, got %d", w.Code)
+	}
+
+	handler := NewHandler(engine.NewExecutor(), repo, reference.NewResolver(repo, nil), nil)
+	ingest := func(req IngestRequest) domain.ValidationResult {
+		body, _ := json.Marshal(req)
+		w := httptest.NewRecorder()
+		handler.Ingest(w, httptest.NewRequest(http.MethodPost, "/ingest/api", bytes.NewReader(body)))
+		var result domain.ValidationResult
+		if err := json.NewDecoder(w.Result().Body).Decode(&result); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return result
+	}
+
+	// The customers batch becomes the reference of the orders source; batches are only kept
+	// when asked to, and not by truncated runs
+	ingest(IngestRequest{SourceID: "customers", Data: []domain.Record{{"id": 1}, {"id": 2}}, Options: &IngestOptions{Snapshot: true}})
+	ingest(IngestRequest{SourceID: "customers", Data: []domain.Record{{"id": 9}}})
+	ingest(IngestRequest{
+		SourceID: "customers",
+		Rules:    []domain.Rule{{ID: "positive", Field: "id", Checks: []domain.Check{{Op: "lt", Value: 0}}}},
+		Data:     []domain.Record{{"id": 8}, {"id": 7}},
+		Options:  &IngestOptions{Snapshot: true, FailFast: true},
+	})
+
+	result := ingest(IngestRequest{
+		SourceID: "orders",
+		Rules: []domain.Rule{
+			{ID: "country_known", Field: "country", Checks: []domain.Check{{Op: domain.OpExistsIn, Ref: &domain.Reference{Lookup: "countries"}}}},
+			{ID: "customer_exists", Field: "customer_id", Checks: []domain.Check{{Op: domain.OpExistsIn, Ref: &domain.Reference{Source: "customers", Column: "id"}}}},
+		},
+		Data: []domain.Record{
+			{"country": "DE", "customer_id": 1},
+			{"country": "US", "customer_id": 3},
+		},
+	})
+
+	if len(result.Errors) != 2 {
+		t.Fatalf("expected the unknown country and customer to fail, got %+v", result.Errors)
+	}
+	for _, e := range result.Errors {
+		if e.RecordID != "#1" {
+			t.Errorf("expected only record #1 to fail, got %+v", e)
+		}
+	}
+}
+
+func TestHandler_IngestDrift(t *testing.T) {
+	repo := storage.NewMemoryStore()
+	handler := NewHandler(engine.NewExecutor(), repo, nil, nil)
+	ingest := func(data []domain.Record) domain.ValidationResult {
+		body, _ := json.Marshal(IngestRequest{SourceID: "orders", Data: data})
+		w := httptest.NewRecorder()
+		handler.Ingest(w, httptest.NewRequest(http.MethodPost, "/ingest/api", bytes.NewReader(body)))
+		var result domain.ValidationResult
+		if err := json.NewDecoder(w.Result().Body).Decode(&result); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return result
+	}
+
+	if result := ingest([]domain.Record{{"id": 1, "amount": 10}}); len(result.Drift) != 0 {
+		t.Errorf("expected no drift on the first run, got %+v", result.Drift)
+	}
+	result := ingest([]domain.Record{{"id": 2, "amount": "10"}})
+	want := []domain.Drift{{Field: "amount", Kind: domain.DriftTypeChanged, Before: "number", After: "string"}}
+	if !reflect.DeepEqual(result.Drift, want) {
+		t.Errorf("expected %+v, got %+v", want, result.Drift)
+	}
+
+	runs, _ := repo.GetRecentRuns(context.Background(), "orders", 1)
+	if len(runs) != 1 || len(runs[0].Drift) != 1 {
+		t.Errorf("expected the drift to be stored with the run, got %+v", runs)
+	}
+}
+
+func TestSchemaHandler_Import(t *testing.T) {
+	doc := `{"type": "object", "required": ["id"], "properties": {"id": {"type": "integer"}, "tags": {"type": "array", "uniqueItems": true}}}`
+	w := httptest.NewRecorder()
+	NewSchemaHandler(nil).Import(w, httptest.NewRequest(http.MethodPost, "/api/schemas/import", strings.NewReader(doc)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	var imported schema.Imported
+	if err := json.NewDecoder(w.Result().Body).Decode(&imported); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if imported.Schema["id"] != "integer" || imported.Schema["tags"] != "optional array" {
+		t.Errorf("unexpected schema: %v", imported.Schema)
+	}
+	if len(imported.Unsupported) != 1 || imported.Unsupported[0] != "#/properties/tags/uniqueItems" {
+		t.Errorf("expected uniqueItems to be reported, got %v", imported.Unsupported)
+	}
+
+	w = httptest.NewRecorder()
+	NewSchemaHandler(nil).Import(w, httptest.NewRequest(http.MethodPost, "/api/schemas/import", strings.NewReader("{")))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an invalid document, got %d", w.Code)
+	}
+}
+
+func TestSchemaHandler_Infer(t *testing.T) {
+	body := `{"data": [{"id": 1, "tag": null}, {"id": 2, "tag": "x"}]}`
+	w := httptest.NewRecorder()
+	NewSchemaHandler(nil).Infer(w, httptest.NewRequest(http.MethodPost, "/api/schemas/infer", strings.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	var suggestion schema.Suggestion
+	if err := json.NewDecoder(w.Result().Body).Decode(&suggestion); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if suggestion.Schema["id"] != "integer" || suggestion.Schema["tag"] != "nullable string" {
+		t.Errorf("unexpected schema: %v", suggestion.Schema)
+	}
+
+	w = httptest.NewRecorder()
+	NewSchemaHandler(nil).Infer(w, httptest.NewRequest(http.MethodPost, "/api/schemas/infer", strings.NewReader(`{"table": "orders"}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 sampling a table without a database, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	NewSchemaHandler(nil).Infer(w, httptest.NewRequest(http.MethodPost, "/api/schemas/infer", strings.NewReader(`{"table": "orders", "limit": 1000000}`)))
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "limit must be") {
+		t.Errorf("expected 400 for a limit above the cap, got %d %s", w.Code, w.Body.String())
+	}
+}
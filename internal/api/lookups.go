@@ -0,0 +1,65 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/singh-anurag-7991/data-guard/internal/storage"
+)
+
+// LookupRequest stores a named list of reference values for exists_in checks
+type LookupRequest struct {
+	Name   string        `json:"name"`
+	Values []interface{} `json:"values"`
+}
+
+type LookupHandler struct {
+	repo storage.Provider
+}
+
+func NewLookupHandler(repo storage.Provider) *LookupHandler {
+	return &LookupHandler{repo: repo}
+}
+
+// Lookups stores a lookup list (POST) or returns one by name (GET ?name=)
+func (h *LookupHandler) Lookups(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		var req LookupRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if req.Name == "" {
+			http.Error(w, "name is required", http.StatusBadRequest)
+			return
+		}
+		if err := h.repo.SaveLookup(r.Context(), req.Name, req.Values); err != nil {
+			slog.Error("Failed to save lookup", "name", req.Name, "error", err)
+			http.Error(w, "Failed to save lookup", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	case http.MethodGet:
+		name := r.URL.Query().Get("name")
+		values, err := h.repo.GetLookup(r.Context(), name)
+		if errors.Is(err, storage.ErrNotFound) {
+			http.Error(w, "Lookup not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Failed to fetch lookup", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Access-Control-Allow-Origin", "*") // Allow generic CORS for local dev
+		json.NewEncoder(w).Encode(LookupRequest{Name: name, Values: values})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
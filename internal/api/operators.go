@@ -0,0 +1,28 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/singh-anurag-7991/data-guard/internal/engine"
+)
+
+type OperatorHandler struct {
+	executor *engine.Executor
+}
+
+func NewOperatorHandler(executor *engine.Executor) *OperatorHandler {
+	return &OperatorHandler{executor: executor}
+}
+
+// List returns the operators rules can use, with their arguments, for the rule editor
+func (h *OperatorHandler) List(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*") // Allow generic CORS for local dev
+	json.NewEncoder(w).Encode(h.executor.Operators())
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/singh-anurag-7991/data-guard/internal/domain"
+	"github.com/singh-anurag-7991/data-guard/internal/schema"
+)
+
+// maxSchemaBytes bounds the size of an imported document
+const maxSchemaBytes = 1 << 20
+
+// maxInferRows bounds the rows sampled from a table, which are held in memory
+const maxInferRows = 10000
+
+// InferRequest holds the sample a schema is inferred from: inline records or rows of a table
+type InferRequest struct {
+	Data  []domain.Record `json:"data,omitempty"`
+	Table string          `json:"table,omitempty"`
+	Limit int             `json:"limit,omitempty"` // Rows sampled from Table, 1000 by default, at most 10000
+}
+
+type SchemaHandler struct {
+	db schema.RowFetcher // nil without a database
+}
+
+func NewSchemaHandler(db schema.RowFetcher) *SchemaHandler {
+	return &SchemaHandler{db: db}
+}
+
+// Import converts the JSON Schema document in the body into a schema and rules (POST)
+func (h *SchemaHandler) Import(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	doc, err := io.ReadAll(io.LimitReader(r.Body, maxSchemaBytes))
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	imported, err := schema.FromJSONSchema(doc)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*") // Allow generic CORS for local dev
+	json.NewEncoder(w).Encode(imported)
+}
+
+// Infer proposes a schema and starter rules for sample records (POST)
+func (h *SchemaHandler) Infer(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req InferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.Limit > maxInferRows {
+		http.Error(w, fmt.Sprintf("limit must be at most %d", maxInferRows), http.StatusBadRequest)
+		return
+	}
+
+	records := req.Data
+	if req.Table != "" {
+		if h.db == nil {
+			http.Error(w, "No database connected", http.StatusBadRequest)
+			return
+		}
+		var err error
+		if records, err = schema.SampleTable(r.Context(), h.db, req.Table, req.Limit); err != nil {
+			slog.Error("Failed to sample table", "table", req.Table, "error", err)
+			http.Error(w, "Failed to sample table", http.StatusBadRequest)
+			return
+		}
+	}
+	if len(records) == 0 {
+		http.Error(w, "data or table is required", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*") // Allow generic CORS for local dev
+	json.NewEncoder(w).Encode(schema.Infer(records))
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/singh-anurag-7991/data-guard/internal/alerting"
+	"github.com/singh-anurag-7991/data-guard/internal/domain"
+	"github.com/singh-anurag-7991/data-guard/internal/engine"
+	"github.com/singh-anurag-7991/data-guard/internal/engine/optimizer"
+	"github.com/singh-anurag-7991/data-guard/internal/schema"
+	"github.com/singh-anurag-7991/data-guard/internal/storage"
+)
+
+// TableRequest asks for a database table to be validated in place
+type TableRequest struct {
+	SourceID  string         `json:"source_id"`
+	Table     string         `json:"table"`
+	Schema    domain.Schema  `json:"schema"`
+	Rules     []domain.Rule  `json:"rules"`
+	KeyFields []string       `json:"key_fields,omitempty"` // Defaults to the table's primary key
+	Options   *IngestOptions `json:"options,omitempty"`
+}
+
+type TableHandler struct {
+	executor   *engine.Executor
+	db         engine.TableQuerier
+	repo       storage.Provider
+	references engine.ReferenceResolver
+	alerts     *alerting.Manager // may be nil
+}
+
+func NewTableHandler(executor *engine.Executor, db engine.TableQuerier, repo storage.Provider, references engine.ReferenceResolver, alerts *alerting.Manager) *TableHandler {
+	return &TableHandler{
+		executor:   executor,
+		db:         db,
+		repo:       repo,
+		references: references,
+		alerts:     alerts,
+	}
+}
+
+// Validate runs the rules against a Postgres table, pushing them down to SQL where possible
+func (h *TableHandler) Validate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req TableRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.SourceID == "" || req.Table == "" {
+		http.Error(w, "source_id and table are required", http.StatusBadRequest)
+		return
+	}
+
+	opts := req.Options.toEngine(req.KeyFields)
+	opts.References = h.references
+	opts.Profiles = storedProfiles(h.repo)
+	result, err := h.executor.ValidateTable(r.Context(), h.db, req.SourceID, req.Table, req.Schema, req.Rules, opts)
+	if errors.Is(err, optimizer.ErrInvalidIdentifier) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		slog.Error("Table validation failed", "source_id", req.SourceID, "table", req.Table, "error", err)
+		http.Error(w, "Failed to validate table", http.StatusBadGateway)
+		return
+	}
+
+	// Save result to storage (Best effort)
+	if h.repo != nil {
+		// A sample shows the shape of the table, the nulls and means of its columns
+		sample, err := schema.SampleTable(r.Context(), h.db, req.Table, schema.DefaultSampleSize)
+		if err != nil {
+			slog.Error("Failed to sample table", "source_id", req.SourceID, "table", req.Table, "error", err)
+		}
+		detectDrift(r.Context(), h.repo, &result, sample)
+		detectAnomalies(r.Context(), h.repo, &result, sample)
+		if err := h.repo.SaveResult(r.Context(), result); err != nil {
+			slog.Error("Failed to save result", "source_id", req.SourceID, "error", err)
+		}
+	}
+	processAlerts(h.alerts, result)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
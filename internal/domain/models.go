@@ -1,45 +1,349 @@
 package domain
 
 import (
+	"fmt"
 	"time"
 )
 
+// Run statuses, ordered from best to worst
+const (
+	StatusPass = "PASS"
+	StatusWarn = "WARN"
+	StatusFail = "FAIL"
+)
+
+// Rule severities. An empty severity is treated as SeverityError.
+const (
+	SeverityError   = "error"   // Breaching the rule fails the run
+	SeverityWarning = "warning" // Breaching the rule marks the run as WARN
+	SeverityInfo    = "info"    // Failures are recorded but never change the status
+)
+
+// How a wildcard path ("items[*].price") is matched against its elements
+const (
+	MatchAll = "all" // every element must satisfy the checks (default for rules)
+	MatchAny = "any" // at least one element must satisfy them (default for conditions)
+)
+
 // Record represents a single data record (row or JSON object)
 type Record map[string]interface{}
 
 // Schema defines the expected structure of the data
-type Schema map[string]string // specific field -> expected type (e.g., "string", "number", "timestamp")
+// Types may take parameters after a colon, e.g. "integer:0..100", "decimal:10,2", "enum:a|b" or
+// "date:02.01.2006"; see package schema for the full list.
+type Schema map[string]string // specific field path -> expected type (e.g., "string", "number", "timestamp")
 
-// Condition defines when a rule should be applied
+// Condition defines when a rule should be applied.
+// A leaf compares one field ({"field": "country", "op": "eq", "value": "US"}); groups combine
+// other conditions with "all", "any" and "not". Every part that is set must hold.
 type Condition struct {
-	Field string      `json:"field"`
-	Op    string      `json:"op"`
-	Value interface{} `json:"value"`
+	Field      string      `json:"field,omitempty"` // Field path, e.g. "customer.address.zip" or "items[*].type"
+	Op         string      `json:"op,omitempty"`
+	Value      interface{} `json:"value,omitempty"`
+	ValueField string      `json:"value_field,omitempty"` // Compare against this field of the record instead of Value
+	Match      string      `json:"match,omitempty"`       // "any" (default) or "all" for wildcard paths
+
+	All []Condition `json:"all,omitempty"` // every condition holds
+	Any []Condition `json:"any,omitempty"` // at least one condition holds
+	Not *Condition  `json:"not,omitempty"` // the condition does not hold
+}
+
+// IsLeaf reports whether the condition compares a field itself
+func (c Condition) IsLeaf() bool {
+	return c.Field != ""
+}
+
+// Fields lists every field path referenced by the condition tree, without duplicates
+func (c Condition) Fields() []string {
+	seen := map[string]bool{}
+	var fields []string
+	var walk func(Condition)
+	walk = func(c Condition) {
+		if c.IsLeaf() {
+			for _, f := range []string{c.Field, c.ValueField} {
+				if f != "" && !seen[f] {
+					seen[f] = true
+					fields = append(fields, f)
+				}
+			}
+		}
+		for _, sub := range c.All {
+			walk(sub)
+		}
+		for _, sub := range c.Any {
+			walk(sub)
+		}
+		if c.Not != nil {
+			walk(*c.Not)
+		}
+	}
+	walk(c)
+	return fields
+}
+
+// Dataset-scoped check operators, evaluated over the whole batch instead of one value at a time
+const (
+	OpUnique        = "unique"         // no two records share the key
+	OpMaxDuplicates = "max_duplicates" // at most Value records repeat a key seen before
+)
+
+// Aggregate check operators, asserting Min <= aggregate <= Max over the records a rule applies to.
+// All but OpRowCount aggregate the rule's field; sum/avg/min/max only consider numbers.
+const (
+	OpRowCount      = "row_count"
+	OpSum           = "sum"
+	OpAvg           = "avg"
+	OpMin           = "min"
+	OpMax           = "max"
+	OpNullRatio     = "null_ratio" // fraction of records where the field is null or missing
+	OpDistinctCount = "distinct_count"
+	OpMaxAge        = "max_age" // the latest timestamp is at most Value ("2h") older than the run
+)
+
+// Date operators compare timestamps read with the check's Format and Timezone.
+// Thresholds of before/after are timestamps or "now".
+const (
+	OpBefore     = "before"
+	OpAfter      = "after"
+	OpWithinDays = "within_days" // no older than Value days and not in the future
+	OpNotFuture  = "not_future"
+)
+
+// String and numeric operators. between reads its bounds from Min and Max, the others Value;
+// not_in, is_empty and is_null pass null values, the others fail them.
+const (
+	OpBetween    = "between"     // Min <= value <= Max, either bound optional
+	OpNotIn      = "not_in"      // not one of the array Value, numbers compared by value
+	OpMinLength  = "min_length"  // a string of at least Value characters
+	OpMaxLength  = "max_length"  // a string of at most Value characters
+	OpContains   = "contains"    // a string containing Value
+	OpStartsWith = "starts_with" // a string starting with Value
+	OpEndsWith   = "ends_with"   // a string ending with Value
+	OpIsEmpty    = "is_empty"    // null or ""
+	OpIsNull     = "is_null"
+	OpMultipleOf = "multiple_of" // a number that is an exact multiple of Value, in decimal
+	OpPrecision  = "precision"   // a number with at most Value decimal places
+	OpIsInteger  = "is_integer"  // a number without a fractional part
+)
+
+// OpProfileChange bounds the relative change of a profile statistic of the rule's field since
+// the last profiled run of the source: Min <= (now - before) / |before| <= Max.
+const OpProfileChange = "profile_change"
+
+// Distribution shift operators compare the values of the rule's field with a baseline: the
+// values of Ref, by default the dataset ingested for the source before this run. Each asserts
+// Min <= statistic <= Max, reported in the CheckSummary.
+const (
+	OpPSI        = "psi"         // population stability index
+	OpKS         = "ks"          // Kolmogorov-Smirnov statistic, numbers only
+	OpChiSquare  = "chi_square"  // p-value of a chi-square test of value frequencies
+	OpShareDelta = "share_delta" // largest change in the share of one value
+)
+
+// OpExistsIn checks that a value exists in a reference set, like a foreign key. Null values pass.
+const OpExistsIn = "exists_in"
+
+// Reference is the set of values an exists_in check looks values up in: a table column of the
+// connected database, a stored lookup list, or the latest dataset ingested for another source.
+type Reference struct {
+	Table  string `json:"table,omitempty"`  // e.g. "customers"
+	Column string `json:"column,omitempty"` // column of Table, or field path in the records of Source
+	Lookup string `json:"lookup,omitempty"` // name of a stored lookup list
+	Source string `json:"source,omitempty"` // source whose latest dataset is the reference
+}
+
+// String describes the reference in failure reasons
+func (r Reference) String() string {
+	switch {
+	case r.Lookup != "":
+		return fmt.Sprintf("lookup %s", r.Lookup)
+	case r.Source != "":
+		return fmt.Sprintf("source %s (%s)", r.Source, r.Column)
+	default:
+		return fmt.Sprintf("%s.%s", r.Table, r.Column)
+	}
 }
 
 // Check defines the actual validation logic
 type Check struct {
-	Op    string      `json:"op"`
-	Value interface{} `json:"value,omitempty"`
+	Op         string      `json:"op"`
+	Value      interface{} `json:"value,omitempty"`
+	ValueField string      `json:"value_field,omitempty"` // Compare against this field of the record instead of Value, e.g. "order_date"
+	Fields     []string    `json:"fields,omitempty"`      // Key of a dataset check (composite when several), defaults to the rule's field
+	Min        *float64    `json:"min,omitempty"`         // Lower bound of an aggregate, shift or between check (inclusive)
+	Max        *float64    `json:"max,omitempty"`         // Upper bound of an aggregate, shift or between check (inclusive)
+	Ref        *Reference  `json:"ref,omitempty"`         // Reference set of an exists_in check, baseline of a shift check
+	Stat       string      `json:"stat,omitempty"`        // Profile statistic of a profile_change check, e.g. "distinct"
+	Format     string      `json:"format,omitempty"`      // Timestamp format of date operators: "rfc3339" (default), "epoch_seconds", "epoch_millis" or a Go layout
+	Timezone   string      `json:"timezone,omitempty"`    // Zone of timestamps without an offset, e.g. "Europe/Berlin" (UTC by default)
+
+	NumericStrings bool `json:"numeric_strings,omitempty"` // Read strings in decimal syntax ("12.50") as numbers
+}
+
+// IsDataset reports whether the check is evaluated over the whole batch
+func (c Check) IsDataset() bool {
+	return c.Op == OpUnique || c.Op == OpMaxDuplicates || c.Op == OpProfileChange || c.IsAggregate() || c.IsShift()
+}
+
+// IsShift reports whether the check compares the distribution of values with a baseline
+func (c Check) IsShift() bool {
+	switch c.Op {
+	case OpPSI, OpKS, OpChiSquare, OpShareDelta:
+		return true
+	}
+	return false
+}
+
+// IsAggregate reports whether the check asserts a range on an aggregate
+func (c Check) IsAggregate() bool {
+	switch c.Op {
+	case OpRowCount, OpSum, OpAvg, OpMin, OpMax, OpNullRatio, OpDistinctCount, OpMaxAge:
+		return true
+	}
+	return false
+}
+
+// KeyFields returns the key of a dataset check, falling back to field
+func (c Check) KeyFields(field string) []string {
+	if len(c.Fields) > 0 {
+		return c.Fields
+	}
+	return []string{field}
 }
 
 // Rule defines a validation rule
 type Rule struct {
-	ID       string      `json:"id"`
-	Field    string      `json:"field"`
-	When     *Condition  `json:"when,omitempty"` // Pointer to allow null (always apply)
-	Checks   []Check     `json:"checks"`
-	Severity string      `json:"severity"` // "error", "warning", "info"
+	ID        string     `json:"id"`
+	Field     string     `json:"field"`           // Field path, e.g. "customer.address.zip" or "items[*].price"
+	Match     string     `json:"match,omitempty"` // "all" (default) or "any" for wildcard paths
+	When      *Condition `json:"when,omitempty"`  // Pointer to allow null (always apply)
+	Checks    []Check    `json:"checks"`
+	Severity  string     `json:"severity"`            // "error", "warning", "info"
+	Tolerance float64    `json:"tolerance,omitempty"` // Fraction of evaluated records allowed to fail before the rule counts as breached (0.02 = 2%)
+}
+
+// EffectiveSeverity returns the rule severity, defaulting to SeverityError
+func (r Rule) EffectiveSeverity() string {
+	switch r.Severity {
+	case SeverityWarning, SeverityInfo:
+		return r.Severity
+	default:
+		return SeverityError
+	}
+}
+
+// IsDataset reports whether the rule only has dataset checks. Rules mixing dataset and
+// record checks are not dataset rules; their dataset checks fail per record.
+func (r Rule) IsDataset() bool {
+	for _, check := range r.Checks {
+		if !check.IsDataset() {
+			return false
+		}
+	}
+	return len(r.Checks) > 0
 }
 
+// TriggerFreshness marks runs created because a source is overdue, not because data arrived
+const TriggerFreshness = "freshness"
+
 // ValidationResult represents the outcome of a validation run
 type ValidationResult struct {
-	SourceID       string        `json:"source_id"`
-	Status         string        `json:"status"` // "PASS", "FAIL"
-	RecordsChecked int           `json:"records_checked"`
-	RulesFailed    int           `json:"rules_failed"`
-	Errors         []ErrorDetail `json:"errors,omitempty"`
-	Timestamp      time.Time     `json:"timestamp"`
+	SourceID        string             `json:"source_id"`
+	Status          string             `json:"status"`            // "PASS", "WARN", "FAIL"
+	Trigger         string             `json:"trigger,omitempty"` // empty for validated data, TriggerFreshness for overdue sources
+	RecordsChecked  int                `json:"records_checked"`
+	RulesFailed     int                `json:"rules_failed"`             // failing checks (plus schema errors) across all records; see RuleSummaries for per-rule counts
+	RuleSummaries   []RuleSummary      `json:"rule_summaries,omitempty"` // per-rule statistics, accurate even when Errors is truncated
+	Errors          []ErrorDetail      `json:"errors,omitempty"`
+	Truncated       bool               `json:"truncated,omitempty"`        // a time or error budget stopped collection early
+	TruncatedReason string             `json:"truncated_reason,omitempty"` // which budget was hit
+	Drift           []Drift            `json:"drift,omitempty"`            // how the data's shape changed since earlier runs
+	Metrics         map[string]float64 `json:"metrics,omitempty"`          // per-run measurements kept as time series, e.g. "record_count"
+	Anomalies       []Anomaly          `json:"anomalies,omitempty"`        // metrics far off their baseline
+	Profile         []FieldProfile     `json:"profile,omitempty"`          // distribution of each field, when profiling
+	Timestamp       time.Time          `json:"timestamp"`
+}
+
+// FieldProfile summarizes the values of one field over a run. Distinct counts, quantiles and
+// top values are estimated with sketches, so profiles cost the same memory for any batch size.
+type FieldProfile struct {
+	Field     string             `json:"field"`
+	Count     int                `json:"count"`               // records with a value
+	Nulls     int                `json:"nulls"`               // records where the field is null or missing
+	Distinct  int                `json:"distinct"`            // estimated distinct values (HyperLogLog)
+	Min       interface{}        `json:"min,omitempty"`       // smallest number, else earliest timestamp, else smallest string
+	Max       interface{}        `json:"max,omitempty"`       // the counterpart of Min
+	Mean      *float64           `json:"mean,omitempty"`      // of numbers
+	Quantiles map[string]float64 `json:"quantiles,omitempty"` // "p01" to "p99" of numbers (t-digest)
+	TopK      []ValueCount       `json:"top_k,omitempty"`     // most frequent values
+}
+
+// ValueCount is a value with how often it was seen
+type ValueCount struct {
+	Value interface{} `json:"value"`
+	Count int         `json:"count"`
+}
+
+// RunProfile is the profile of a past run
+type RunProfile struct {
+	SourceID  string         `json:"source_id"`
+	Timestamp time.Time      `json:"timestamp"`
+	Fields    []FieldProfile `json:"fields"`
+}
+
+// Anomaly is a metric of a run far outside what earlier runs of the source measured
+type Anomaly struct {
+	Metric   string  `json:"metric"` // e.g. "record_count", "null_ratio:email"
+	Value    float64 `json:"value"`
+	Expected float64 `json:"expected"` // median of the baseline
+	Score    float64 `json:"score"`    // robust z-score of Value against the baseline
+	Baseline string  `json:"baseline"` // "hour_of_week" when compared with the same hour of earlier weeks, else "all"
+	Samples  int     `json:"samples"`  // runs in the baseline
+}
+
+// Kinds of schema drift
+const (
+	DriftAdded       = "added"               // a field not seen before
+	DriftRemoved     = "removed"             // a field always present before is missing
+	DriftTypeChanged = "type_changed"        // the field's values have another type
+	DriftNullability = "nullability_changed" // a field always set before is now null or missing in some records
+)
+
+// Drift is a change in the observed shape of a source between runs
+type Drift struct {
+	Field  string `json:"field"`
+	Kind   string `json:"kind"`
+	Before string `json:"before,omitempty"` // observed type of earlier runs, e.g. "number"
+	After  string `json:"after,omitempty"`  // observed type of this run, e.g. "nullable string"
+}
+
+// RuleSummary aggregates how a single rule behaved over a run
+type RuleSummary struct {
+	RuleID       string         `json:"rule_id"`
+	Field        string         `json:"field"`
+	Severity     string         `json:"severity"`
+	Evaluated    int            `json:"evaluated"`     // records the rule was applied to
+	Skipped      int            `json:"skipped"`       // records skipped by the When condition
+	SchemaFailed int            `json:"schema_failed"` // records skipped because a field the rule uses failed the schema
+	Passed       int            `json:"passed"`        // evaluated records where every check passed
+	Failed       int            `json:"failed"`        // evaluated records with at least one failing check
+	FailureRatio float64        `json:"failure_ratio"` // Failed / Evaluated
+	Checks       []CheckSummary `json:"checks,omitempty"`
+	SampleValues []interface{}  `json:"sample_values,omitempty"` // first few failing values
+}
+
+// CheckSummary counts failures of one check within a rule
+type CheckSummary struct {
+	Op        string   `json:"op"`
+	Failed    int      `json:"failed"`
+	Statistic *float64 `json:"statistic,omitempty"` // value of a distribution shift statistic
+}
+
+// RuleSummaryPoint is a RuleSummary from a past run, used for trend charts
+type RuleSummaryPoint struct {
+	SourceID  string    `json:"source_id"`
+	Timestamp time.Time `json:"timestamp"`
+	RuleSummary
 }
 
 // ErrorDetail captures specific validation failures
@@ -48,5 +352,6 @@ type ErrorDetail struct {
 	Field    string      `json:"field"`
 	Value    interface{} `json:"value"`
 	Reason   string      `json:"reason"`
+	Severity string      `json:"severity,omitempty"`  // severity of the rule that produced the failure
 	RecordID string      `json:"record_id,omitempty"` // specific identifier if available
 }
@@ -0,0 +1,140 @@
+// Package drift detects changes in the shape of a source's data between runs: fields that
+// appear, disappear, change type or start being null. Each source keeps an observed schema
+// in storage, which every run is compared against and then merged into.
+package drift
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/singh-anurag-7991/data-guard/internal/domain"
+	"github.com/singh-anurag-7991/data-guard/internal/schema"
+	"github.com/singh-anurag-7991/data-guard/internal/storage"
+)
+
+// Detector compares runs with the observed schema of their source
+type Detector struct {
+	repo storage.Provider
+}
+
+func NewDetector(repo storage.Provider) *Detector {
+	return &Detector{repo: repo}
+}
+
+// Check reports how records differ from what earlier runs of the source looked like and
+// updates the observed schema. The first run of a source only records its shape.
+func (d *Detector) Check(ctx context.Context, sourceID string, records []domain.Record) ([]domain.Drift, error) {
+	if len(records) == 0 {
+		return nil, nil // Nothing to observe, and no reason to think every field is gone
+	}
+	observed := schema.Observe(records)
+
+	baseline, err := d.repo.GetObservedSchema(ctx, sourceID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, d.repo.SaveObservedSchema(ctx, sourceID, observed)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load observed schema of %s: %w", sourceID, err)
+	}
+
+	drift := Compare(baseline, observed)
+	if err := d.repo.SaveObservedSchema(ctx, sourceID, Merge(baseline, observed)); err != nil {
+		return drift, fmt.Errorf("failed to save observed schema of %s: %w", sourceID, err)
+	}
+	return drift, nil
+}
+
+// field is one parsed entry of an observed schema
+type field struct {
+	kind     string
+	optional bool
+	nullable bool
+}
+
+func parseField(spec string) field {
+	var f field
+	rest := spec
+	for {
+		if r, ok := strings.CutPrefix(rest, "optional "); ok {
+			f.optional, rest = true, r
+		} else if r, ok := strings.CutPrefix(rest, "nullable "); ok {
+			f.nullable, rest = true, r
+		} else {
+			break
+		}
+	}
+	f.kind = rest
+	return f
+}
+
+func (f field) String() string {
+	var keywords []string
+	if f.optional {
+		keywords = append(keywords, "optional")
+	}
+	if f.nullable {
+		keywords = append(keywords, "nullable")
+	}
+	return strings.Join(append(keywords, f.kind), " ")
+}
+
+// Compare lists the drift of observed against baseline, sorted by field. Fields the baseline
+// already knows to be optional or nullable do not drift by being missing or null, and fields
+// seen only as null keep whatever type they had.
+func Compare(baseline, observed domain.Schema) []domain.Drift {
+	var drift []domain.Drift
+	for path, spec := range observed {
+		before, ok := baseline[path]
+		if !ok {
+			drift = append(drift, domain.Drift{Field: path, Kind: domain.DriftAdded, After: spec})
+			continue
+		}
+		was, now := parseField(before), parseField(spec)
+		if was.kind != now.kind && was.kind != schema.KindNull && now.kind != schema.KindNull {
+			drift = append(drift, domain.Drift{Field: path, Kind: domain.DriftTypeChanged, Before: was.kind, After: now.kind})
+		}
+		if (now.nullable && !was.nullable) || (now.optional && !was.optional) {
+			drift = append(drift, domain.Drift{Field: path, Kind: domain.DriftNullability, Before: before, After: spec})
+		}
+	}
+	for path, spec := range baseline {
+		if _, ok := observed[path]; !ok && !parseField(spec).optional {
+			drift = append(drift, domain.Drift{Field: path, Kind: domain.DriftRemoved, Before: spec})
+		}
+	}
+
+	sort.Slice(drift, func(i, j int) bool {
+		if drift[i].Field != drift[j].Field {
+			return drift[i].Field < drift[j].Field
+		}
+		return drift[i].Kind < drift[j].Kind
+	})
+	return drift
+}
+
+// Merge folds observed into baseline so that each change drifts once: fields take their
+// latest type, stay nullable and optional once seen so, and missing fields become optional.
+func Merge(baseline, observed domain.Schema) domain.Schema {
+	merged := domain.Schema{}
+	for path, spec := range baseline {
+		f := parseField(spec)
+		f.optional = true // Unless observed below
+		merged[path] = f.String()
+	}
+	for path, spec := range observed {
+		now := parseField(spec)
+		if before, ok := baseline[path]; ok {
+			was := parseField(before)
+			if now.kind == schema.KindNull {
+				now.kind = was.kind
+			}
+			now.optional = now.optional || was.optional
+			now.nullable = now.nullable || was.nullable
+		}
+		merged[path] = now.String()
+	}
+	return merged
+}
@@ -0,0 +1,113 @@
+package drift
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/singh-anurag-7991/data-guard/internal/domain"
+	"github.com/singh-anurag-7991/data-guard/internal/storage"
+)
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name     string
+		baseline domain.Schema
+		observed domain.Schema
+		want     []domain.Drift
+	}{
+		{
+			name:     "unchanged",
+			baseline: domain.Schema{"id": "number", "name": "string"},
+			observed: domain.Schema{"id": "number", "name": "string"},
+		},
+		{
+			name:     "added and removed",
+			baseline: domain.Schema{"id": "number", "name": "string"},
+			observed: domain.Schema{"id": "number", "full_name": "string"},
+			want: []domain.Drift{
+				{Field: "full_name", Kind: domain.DriftAdded, After: "string"},
+				{Field: "name", Kind: domain.DriftRemoved, Before: "string"},
+			},
+		},
+		{
+			name:     "optional fields may be missing",
+			baseline: domain.Schema{"id": "number", "note": "optional string"},
+			observed: domain.Schema{"id": "number"},
+		},
+		{
+			name:     "type changed",
+			baseline: domain.Schema{"amount": "number"},
+			observed: domain.Schema{"amount": "string"},
+			want:     []domain.Drift{{Field: "amount", Kind: domain.DriftTypeChanged, Before: "number", After: "string"}},
+		},
+		{
+			name:     "only nulls keep the type",
+			baseline: domain.Schema{"amount": "nullable number"},
+			observed: domain.Schema{"amount": "nullable null"},
+		},
+		{
+			name:     "became nullable",
+			baseline: domain.Schema{"email": "string"},
+			observed: domain.Schema{"email": "nullable string"},
+			want:     []domain.Drift{{Field: "email", Kind: domain.DriftNullability, Before: "string", After: "nullable string"}},
+		},
+		{
+			name:     "became optional",
+			baseline: domain.Schema{"email": "string"},
+			observed: domain.Schema{"email": "optional string"},
+			want:     []domain.Drift{{Field: "email", Kind: domain.DriftNullability, Before: "string", After: "optional string"}},
+		},
+		{
+			name:     "known nullable",
+			baseline: domain.Schema{"email": "optional nullable string"},
+			observed: domain.Schema{"email": "nullable string"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Compare(tt.baseline, tt.observed)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Compare() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetector_Check(t *testing.T) {
+	ctx := context.Background()
+	d := NewDetector(storage.NewMemoryStore())
+
+	runs := []struct {
+		records []domain.Record
+		want    []domain.Drift
+	}{
+		// The first run only records the shape
+		{[]domain.Record{{"id": 1, "amount": 9.5, "note": "x"}}, nil},
+		{[]domain.Record{{"id": 2, "amount": 3.0, "note": "y"}}, nil},
+		// Upstream renamed note and sends amounts as strings
+		{[]domain.Record{{"id": 3, "amount": "3.00", "comment": "z"}}, []domain.Drift{
+			{Field: "amount", Kind: domain.DriftTypeChanged, Before: "number", After: "string"},
+			{Field: "comment", Kind: domain.DriftAdded, After: "string"},
+			{Field: "note", Kind: domain.DriftRemoved, Before: "string"},
+		}},
+		// Each change is reported once
+		{[]domain.Record{{"id": 4, "amount": "1.00", "comment": "z"}}, nil},
+		// An empty batch says nothing about the shape
+		{nil, nil},
+		{[]domain.Record{{"id": 5, "amount": "2.00", "comment": nil}}, []domain.Drift{
+			{Field: "comment", Kind: domain.DriftNullability, Before: "string", After: "nullable null"},
+		}},
+	}
+
+	for i, run := range runs {
+		got, err := d.Check(ctx, "orders", run.records)
+		if err != nil {
+			t.Fatalf("run %d: unexpected error: %v", i, err)
+		}
+		if !reflect.DeepEqual(got, run.want) {
+			t.Errorf("run %d: Check() = %+v, want %+v", i, got, run.want)
+		}
+	}
+}
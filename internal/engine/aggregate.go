@@ -0,0 +1,210 @@
+package engine
+
+import (
+	"fmt"
+	"hash/maphash"
+	"math"
+	"time"
+
+	"github.com/singh-anurag-7991/data-guard/internal/domain"
+	"github.com/singh-anurag-7991/data-guard/internal/fieldpath"
+	"github.com/singh-anurag-7991/data-guard/internal/operators"
+)
+
+// aggregator computes one aggregate (row_count, sum, ...) over the records a rule applies to.
+// Distinct values are kept as 64-bit hashes. With pushdown the value is loaded from SQL instead.
+type aggregator struct {
+	op       string
+	field    string
+	rows     int
+	nulls    int
+	numbers  int
+	sum      float64
+	min, max float64
+	seed     maphash.Seed
+	distinct map[uint64]struct{}
+	latest   time.Time      // max_age: the most recent timestamp
+	format   string         // max_age: the format of the timestamps
+	loc      *time.Location // max_age: the timezone of timestamps without offset, nil if unknown
+
+	loaded bool
+	value  interface{} // loaded from SQL, nil when there were no values
+}
+
+func newAggregator(field string, check domain.Check) *aggregator {
+	a := &aggregator{op: check.Op, field: field}
+	switch check.Op {
+	case domain.OpDistinctCount:
+		a.seed = maphash.MakeSeed()
+		a.distinct = map[uint64]struct{}{}
+	case domain.OpMaxAge:
+		a.format = check.Format
+		a.loc, _ = operators.LoadLocation(check.Timezone) // An unknown timezone fails in assertAge
+	}
+	return a
+}
+
+func (a *aggregator) observe(records []domain.Record, index int) {
+	a.rows++
+	if a.op == domain.OpRowCount {
+		return
+	}
+
+	val, _ := fieldpath.Get(records[index], a.field)
+	if val == nil {
+		a.nulls++
+		return
+	}
+
+	if a.op == domain.OpMaxAge {
+		if a.loc == nil {
+			return
+		}
+		if t, ok := operators.ParseTime(val, a.format, a.loc); ok && t.After(a.latest) {
+			a.latest = t
+		}
+		return
+	}
+
+	if a.distinct != nil {
+		key, _ := encodeKey(domain.Record{"v": val}, []string{"v"})
+		a.distinct[maphash.Bytes(a.seed, key)] = struct{}{}
+		return
+	}
+
+	f, ok := operators.ToFloat(val)
+	if !ok {
+		return // sum/avg/min/max skip non-numbers, like a numeric cast that would reject the row
+	}
+	if a.numbers == 0 || f < a.min {
+		a.min = f
+	}
+	if a.numbers == 0 || f > a.max {
+		a.max = f
+	}
+	a.numbers++
+	a.sum += f
+}
+
+// load sets the aggregate from a value computed by the database
+func (a *aggregator) load(val interface{}) {
+	a.loaded = true
+	a.value = val
+}
+
+// result returns the aggregate; ok is false when it is undefined (avg/min/max of no numbers)
+func (a *aggregator) result() (float64, bool) {
+	if a.loaded {
+		return operators.ToFloat(a.value)
+	}
+
+	switch a.op {
+	case domain.OpRowCount:
+		return float64(a.rows), true
+	case domain.OpSum:
+		return a.sum, true
+	case domain.OpNullRatio:
+		if a.rows == 0 {
+			return 0, true
+		}
+		return float64(a.nulls) / float64(a.rows), true
+	case domain.OpDistinctCount:
+		return float64(len(a.distinct)), true
+	case domain.OpMaxAge:
+		if a.latest.IsZero() {
+			return 0, false
+		}
+		return float64(a.latest.UnixNano()) / 1e9, true
+	}
+
+	if a.numbers == 0 {
+		return 0, false
+	}
+	switch a.op {
+	case domain.OpAvg:
+		return a.sum / float64(a.numbers), true
+	case domain.OpMin:
+		return a.min, true
+	default:
+		return a.max, true
+	}
+}
+
+// assert checks the aggregate against the check's bounds, returning the failure if it is out of range.
+// now is the time of the run, max_age is measured from it.
+func (a *aggregator) assert(rule domain.Rule, check domain.Check, now time.Time) (domain.ErrorDetail, bool) {
+	detail := domain.ErrorDetail{RuleID: rule.ID, Field: rule.Field}
+	if check.Op == domain.OpMaxAge {
+		return a.assertAge(detail, check, now)
+	}
+
+	label := check.Op
+	if check.Op != domain.OpRowCount {
+		label = fmt.Sprintf("%s of %s", check.Op, rule.Field)
+	}
+
+	val, ok := a.result()
+	if !ok {
+		detail.Reason = fmt.Sprintf("%s is undefined, there are no numeric values", label)
+		return detail, false
+	}
+	detail.Value = val
+
+	switch {
+	case check.Min != nil && val < *check.Min:
+		detail.Reason = fmt.Sprintf("%s is %v, below the minimum %v", label, formatAggregate(val), *check.Min)
+	case check.Max != nil && val > *check.Max:
+		detail.Reason = fmt.Sprintf("%s is %v, above the maximum %v", label, formatAggregate(val), *check.Max)
+	default:
+		return detail, true
+	}
+	return detail, false
+}
+
+// assertAge checks that the latest timestamp is within the check's window of now
+func (a *aggregator) assertAge(detail domain.ErrorDetail, check domain.Check, now time.Time) (domain.ErrorDetail, bool) {
+	window, ok := toDuration(check.Value)
+	if !ok {
+		detail.Value = check.Value
+		detail.Reason = `max_age expects a duration like "2h"`
+		return detail, false
+	}
+	if _, err := operators.LoadLocation(check.Timezone); err != nil {
+		detail.Reason = fmt.Sprintf("unknown timezone %s", check.Timezone)
+		return detail, false
+	}
+
+	epoch, ok := a.result()
+	if !ok {
+		detail.Reason = fmt.Sprintf("no timestamps in %s", detail.Field)
+		return detail, false
+	}
+	latest := time.Unix(0, int64(epoch*1e9)).UTC()
+	detail.Value = latest.Format(time.RFC3339)
+
+	if age := now.Sub(latest); age > window {
+		detail.Reason = fmt.Sprintf("latest %s is %s old, over the %s window", detail.Field, age.Truncate(time.Second), window)
+		return detail, false
+	}
+	return detail, true
+}
+
+// toDuration reads a window given as a Go duration string ("90m") or a number of seconds
+func toDuration(v interface{}) (time.Duration, bool) {
+	if s, ok := v.(string); ok {
+		d, err := time.ParseDuration(s)
+		return d, err == nil && d > 0
+	}
+	if f, ok := operators.ToFloat(v); ok && f > 0 {
+		return time.Duration(f * float64(time.Second)), true
+	}
+	return 0, false
+}
+
+// formatAggregate keeps reasons readable for averages and ratios
+func formatAggregate(v float64) interface{} {
+	if v == math.Trunc(v) {
+		return int64(v)
+	}
+	return math.Round(v*1e6) / 1e6
+}
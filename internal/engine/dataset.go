@@ -0,0 +1,251 @@
+package engine
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/maphash"
+	"strconv"
+	"strings"
+
+	"github.com/singh-anurag-7991/data-guard/internal/domain"
+	"github.com/singh-anurag-7991/data-guard/internal/fieldpath"
+	"github.com/singh-anurag-7991/data-guard/internal/operators"
+)
+
+// datasetState accumulates what a dataset check needs while records stream by
+type datasetState interface {
+	observe(records []domain.Record, index int)
+}
+
+// newDatasetState creates the state of a dataset check
+func newDatasetState(rule domain.Rule, check domain.Check) datasetState {
+	if check.IsAggregate() {
+		return newAggregator(rule.Field, check)
+	}
+	if check.Op == domain.OpProfileChange {
+		return newProfileChange(rule.Field)
+	}
+	if check.IsShift() {
+		return newShiftState(rule.Field)
+	}
+	return newDuplicateTracker(check.KeyFields(rule.Field))
+}
+
+// maxListedRecords caps how many record ids a duplicate reason lists
+const maxListedRecords = 5
+
+// duplicateTracker finds records sharing a key. Keys are kept as 64-bit hashes pointing at the
+// records that have them, so memory grows with the number of records, not the size of their keys.
+// Hash collisions are resolved by re-reading the key of the first record of a group.
+type duplicateTracker struct {
+	fields     []string
+	seed       maphash.Seed
+	groups     map[uint64][]*duplicateGroup // several groups per hash only on collisions
+	duplicated []*duplicateGroup            // groups with more than one record, by first occurrence
+	extra      int                          // records repeating a key seen before
+}
+
+// duplicateGroup is the set of records sharing one key, first occurrence first
+type duplicateGroup struct {
+	indexes []int
+}
+
+func newDuplicateTracker(fields []string) *duplicateTracker {
+	return &duplicateTracker{
+		fields: fields,
+		seed:   maphash.MakeSeed(),
+		groups: map[uint64][]*duplicateGroup{},
+	}
+}
+
+// observe adds records[index] to its group. Records with a missing or null key part are ignored,
+// as a SQL unique constraint would.
+func (t *duplicateTracker) observe(records []domain.Record, index int) {
+	key, ok := encodeKey(records[index], t.fields)
+	if !ok {
+		return
+	}
+
+	h := maphash.Bytes(t.seed, key)
+	for _, g := range t.groups[h] {
+		if first, _ := encodeKey(records[g.indexes[0]], t.fields); string(first) != string(key) {
+			continue // Collision
+		}
+		g.indexes = append(g.indexes, index)
+		t.extra++
+		if len(g.indexes) == 2 {
+			t.duplicated = append(t.duplicated, g)
+		}
+		return
+	}
+	t.groups[h] = append(t.groups[h], &duplicateGroup{indexes: []int{index}})
+}
+
+// encodeKey serializes the key of a record, normalizing numbers to their exact decimal so 1 and
+// 1.0 are the same key while int64 IDs beyond float64 precision stay apart
+func encodeKey(record domain.Record, fields []string) ([]byte, bool) {
+	var buf []byte
+	for _, field := range fields {
+		val, ok := fieldpath.Get(record, field)
+		if !ok || val == nil {
+			return nil, false
+		}
+
+		var part string
+		if d, ok := operators.FormatDecimal(val); ok {
+			part = "n" + d
+		} else if f, ok := operators.ToFloat(val); ok {
+			part = "n" + strconv.FormatFloat(f, 'g', -1, 64) // Infinities and NaN
+		} else if s, ok := val.(string); ok {
+			part = "s" + s
+		} else {
+			part = fmt.Sprintf("%T:%v", val, val)
+		}
+		buf = binary.AppendUvarint(buf, uint64(len(part)))
+		buf = append(buf, part...)
+	}
+	return buf, true
+}
+
+// keyValue returns the key of a record as reported in ErrorDetail.Value
+func keyValue(record domain.Record, fields []string) interface{} {
+	if len(fields) == 1 {
+		val, _ := fieldpath.Get(record, fields[0])
+		return val
+	}
+	vals := make([]interface{}, len(fields))
+	for i, field := range fields {
+		vals[i], _ = fieldpath.Get(record, field)
+	}
+	return vals
+}
+
+// observeDataset feeds the current record to the dataset checks of the i-th rule
+func (c *collector) observeDataset(i int) {
+	for _, state := range c.datasets[i] {
+		state.observe(c.records, c.index)
+	}
+}
+
+// finishDataset reports the dataset checks of the i-th rule once every record was observed.
+// A breached aggregate, profile_change or shift check fails every evaluated record and the rule regardless of its tolerance;
+// duplicates only fail the records sharing a key.
+func (c *collector) finishDataset(i int, rule domain.Rule) {
+	summary := &c.rules[i]
+	failed := map[int]bool{}
+	breached := false
+
+	for j, check := range rule.Checks {
+		var detail domain.ErrorDetail
+		ok := true
+		switch state := c.datasets[i][j].(type) {
+		case *aggregator:
+			detail, ok = state.assert(rule, check, c.result.Timestamp)
+		case *profileChange:
+			detail, ok = state.assert(rule, check)
+		case *shiftState:
+			detail, ok = state.assert(rule, check)
+			summary.Checks[j].Statistic = state.statistic
+		case *duplicateTracker:
+			c.reportDuplicates(summary, j, rule, check, state, failed)
+		}
+		if !ok {
+			summary.Checks[j].Failed++
+			if len(summary.SampleValues) < maxSampleValues {
+				summary.SampleValues = append(summary.SampleValues, detail.Value)
+			}
+			breached = true
+			c.breached[i] = true
+			c.recordID = "" // About the dataset, not a record
+			c.fail(detail, summary.Severity)
+		}
+		if c.stopped {
+			return
+		}
+	}
+
+	summary.Failed = len(failed)
+	if breached {
+		summary.Failed = summary.Evaluated
+	}
+	summary.Passed = summary.Evaluated - summary.Failed
+}
+
+// reportDuplicates reports every record sharing a key once the j-th check is breached.
+// Each one gets an ErrorDetail naming the other records involved; failed collects their indexes.
+func (c *collector) reportDuplicates(summary *domain.RuleSummary, j int, rule domain.Rule, check domain.Check, t *duplicateTracker, failed map[int]bool) {
+	fields := t.fields
+
+	limit := 0
+	if check.Op == domain.OpMaxDuplicates && check.Value != nil {
+		n, ok := operators.ToFloat(check.Value)
+		if !ok {
+			summary.Checks[j].Failed++
+			c.recordID = ""
+			c.fail(domain.ErrorDetail{
+				RuleID: rule.ID,
+				Field:  strings.Join(fields, ","),
+				Value:  check.Value,
+				Reason: "max_duplicates expects a number",
+			}, summary.Severity)
+			return
+		}
+		limit = int(n)
+	}
+	if t.extra <= limit {
+		return
+	}
+
+	for _, g := range t.duplicated {
+		key := keyValue(c.records[g.indexes[0]], fields)
+		reason := duplicateReason(fields, key, len(g.indexes), c.recordIDs(g.indexes))
+		for _, idx := range g.indexes {
+			summary.Checks[j].Failed++
+			if !failed[idx] {
+				failed[idx] = true
+				if len(summary.SampleValues) < maxSampleValues {
+					summary.SampleValues = append(summary.SampleValues, key)
+				}
+			}
+
+			c.recordID = recordKey(c.records[idx], c.keyFields, idx)
+			c.fail(domain.ErrorDetail{
+				RuleID: rule.ID,
+				Field:  strings.Join(fields, ","),
+				Value:  key,
+				Reason: reason,
+			}, summary.Severity)
+			if c.stopped {
+				return
+			}
+		}
+	}
+}
+
+// recordIDs identifies the first few records of a group
+func (c *collector) recordIDs(indexes []int) []string {
+	var ids []string
+	for _, idx := range indexes {
+		if len(ids) == maxListedRecords {
+			ids = append(ids, "...")
+			break
+		}
+		ids = append(ids, recordKey(c.records[idx], c.keyFields, idx))
+	}
+	return ids
+}
+
+// duplicateReason describes a duplicated key, e.g. `duplicate order_id 42 shared by 2 records (#0, #3)`
+func duplicateReason(fields []string, key interface{}, total int, ids []string) string {
+	label, value := fields[0], fmt.Sprint(key)
+	if len(fields) > 1 {
+		label = "(" + strings.Join(fields, ", ") + ")"
+		parts := make([]string, len(fields))
+		for i, v := range key.([]interface{}) {
+			parts[i] = fmt.Sprint(v)
+		}
+		value = strings.Join(parts, "|")
+	}
+
+	return fmt.Sprintf("duplicate %s %s shared by %d records (%s)", label, value, total, strings.Join(ids, ", "))
+}
@@ -1,133 +1,503 @@
 package engine
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/singh-anurag-7991/data-guard/internal/domain"
+	"github.com/singh-anurag-7991/data-guard/internal/fieldpath"
 	"github.com/singh-anurag-7991/data-guard/internal/operators"
+	"github.com/singh-anurag-7991/data-guard/internal/profile"
 )
 
+// Reasons reported in ValidationResult.TruncatedReason when a budget is hit
+const (
+	TruncatedDeadline  = "deadline_exceeded"
+	TruncatedCanceled  = "canceled"
+	TruncatedMaxErrors = "max_errors"
+	TruncatedFailFast  = "fail_fast"
+)
+
+// ValidateOptions bounds the work done by a single validation run.
+// Zero values mean "no limit".
+type ValidateOptions struct {
+	MaxDuration time.Duration     // Abort the run once this much time has passed
+	MaxErrors   int               // Stop collecting ErrorDetails after this many (counters stay accurate)
+	FailFast    bool              // Stop at the first error-severity failure
+	KeyFields   []string          // Fields identifying a record (composite when several), used for ErrorDetail.RecordID
+	References  ReferenceResolver // Resolves exists_in references, and shift baselines if a BaselineResolver; such checks fail without one
+	Profiles    ProfileSource     // Earlier profiles for profile_change checks; such checks fail without one
+	Profile     bool              // Profile every field into ValidationResult.Profile
+
+	StrictSchema       bool // Report fields the schema does not declare
+	RulesOnValidFields bool // Run rules on records failing the schema, except rules using a failed field
+	NumericStrings     bool // Read strings in decimal syntax as numbers, in every check and number or integer schema types
+}
+
 // Executor is responsible for running validations
-type Executor struct{}
+type Executor struct {
+	ops *operators.Registry
+}
 
-// NewExecutor creates a new validation executor
+// NewExecutor creates a new validation executor with the built-in operators
 func NewExecutor() *Executor {
-	return &Executor{}
+	return NewExecutorWithRegistry(operators.NewRegistry())
+}
+
+// NewExecutorWithRegistry creates a validation executor running the operators of ops,
+// which may be extended with custom operators at any time
+func NewExecutorWithRegistry(ops *operators.Registry) *Executor {
+	return &Executor{ops: ops}
+}
+
+// Registry returns the operators the executor runs
+func (e *Executor) Registry() *operators.Registry {
+	return e.ops
 }
 
 // Validate executes the rules against the provided records
 func (e *Executor) Validate(sourceID string, schema domain.Schema, rules []domain.Rule, records []domain.Record) domain.ValidationResult {
+	return e.ValidateContext(context.Background(), sourceID, schema, rules, records, ValidateOptions{})
+}
+
+// ValidateContext executes the rules against the provided records, stopping early
+// when ctx is done or one of the budgets in opts is exhausted.
+// A stopped run is returned as-is with Truncated set.
+func (e *Executor) ValidateContext(ctx context.Context, sourceID string, schema domain.Schema, rules []domain.Rule, records []domain.Record, opts ValidateOptions) domain.ValidationResult {
+	if opts.MaxDuration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.MaxDuration)
+		defer cancel()
+	}
+
 	result := domain.ValidationResult{
-		SourceID:       sourceID,
-		Status:         "PASS",
-		RecordsChecked: len(records),
-		Errors:         []domain.ErrorDetail{},
-		Timestamp:      time.Now(),
+		SourceID:  sourceID,
+		Status:    domain.StatusPass,
+		Errors:    []domain.ErrorDetail{},
+		Timestamp: time.Now(),
 	}
+	c := newCollector(&result, rules, opts)
+	c.records = records
+	c.resolveReferences(ctx, rules, records)
+	c.resolveProfiles(ctx, sourceID)
+	c.resolveBaselines(ctx, sourceID, rules)
+
+	for i, record := range records {
+		if err := ctx.Err(); err != nil {
+			c.truncate(contextReason(err))
+			break
+		}
+		result.RecordsChecked++
+		c.index = i
+		c.recordID = recordKey(record, opts.KeyFields, i)
 
-	for _, record := range records {
 		// 1. Schema Validation (First Gate)
-		if err := e.validateSchema(record, schema); err != nil {
-			result.Status = "FAIL"
-			result.RulesFailed++ // Counting schema failure as a rule failure
-			result.Errors = append(result.Errors, *err)
+		failures, failed := validateSchema(record, schema, opts.StrictSchema, opts.NumericStrings)
+		for _, detail := range failures {
+			c.schemaFailed = true
+			c.fail(detail, domain.SeverityError)
+			if c.stopped {
+				break
+			}
+		}
+		if c.stopped {
+			break
+		}
+		if len(failures) > 0 && !opts.RulesOnValidFields {
 			continue // Skip processing rules if schema fails
 		}
 
 		// 2. Rule Execution
-		for _, rule := range rules {
-			// Check 'When' condition
-			if !e.evaluateCondition(record, rule.When) {
-				continue // Skip rule if condition not met
-			}
+		e.applyRules(c, record, rules, failed)
+		if c.stopped {
+			break
+		}
+	}
 
-			// Execute Rule Checks
-			for _, check := range rule.Checks {
-				val, exists := record[rule.Field]
-				// If field is missing and op is not 'not_null', it might be valid or invalid depending on rule.
-				// For simplicity, if field missing and we check it, we treat as nil.
-				if !exists {
-					val = nil
-				}
-
-				opFunc, found := operators.Get(check.Op)
-				if !found {
-					result.Status = "FAIL"
-					result.RulesFailed++
-					result.Errors = append(result.Errors, domain.ErrorDetail{
-						RuleID: rule.ID,
-						Field:  rule.Field,
-						Reason: fmt.Sprintf("unknown operator: %s", check.Op),
-					})
-					continue
-				}
-
-				pass, reason := opFunc(val, check)
-				if !pass {
-					result.Status = "FAIL"
-					result.RulesFailed++
-					result.Errors = append(result.Errors, domain.ErrorDetail{
-						RuleID: rule.ID,
-						Field:  rule.Field,
-						Value:  val,
-						Reason: reason,
-					})
-				}
-			}
+	// 3. Dataset checks, once every record was seen
+	for i, rule := range rules {
+		if c.stopped {
+			break
 		}
+		if rule.IsDataset() {
+			c.finishDataset(i, rule)
+		}
+	}
+
+	// profile_change checks of later runs compare with this profile
+	if fields := profileFields(rules); opts.Profile || len(fields) > 0 {
+		result.Profile = profile.Build(records[:result.RecordsChecked], fields)
 	}
 
+	result.Status = c.status(rules)
+	result.RuleSummaries = c.summaries()
 	return result
 }
 
-// validateSchema checks if fields match expected types (Basic implementation)
-func (e *Executor) validateSchema(record domain.Record, schema domain.Schema) *domain.ErrorDetail {
-	for field, expectedType := range schema {
-		val, exists := record[field]
-		if !exists {
-			return &domain.ErrorDetail{
-				Field:  field,
-				Reason: "field missing",
-			}
+// applyRules runs every rule against a single record. Rules using a field that failed the
+// schema are skipped, counted apart from records their When condition skips.
+func (e *Executor) applyRules(c *collector, record domain.Record, rules []domain.Rule, failed map[string]bool) {
+	for i, rule := range rules {
+		if usesFailedField(rule, failed) {
+			c.rules[i].SchemaFailed++
+			continue
 		}
+		e.applyRule(c, i, rule, record)
+		if c.stopped {
+			return
+		}
+	}
+}
 
-		// Very basic type check
-		switch expectedType {
-		case "string":
-			if _, ok := val.(string); !ok {
-				return &domain.ErrorDetail{Field: field, Reason: "expected string"}
-			}
-		case "number":
-			if _, ok := operators.ToFloat(val); !ok {
-				return &domain.ErrorDetail{Field: field, Reason: "expected number"}
+// applyRule runs the i-th rule against a single record, updating its summary
+func (e *Executor) applyRule(c *collector, i int, rule domain.Rule, record domain.Record) {
+	summary := &c.rules[i]
+
+	// Check 'When' condition
+	if !e.evaluateCondition(record, rule.When, c.opts.NumericStrings) {
+		summary.Skipped++
+		return // Skip rule if condition not met
+	}
+
+	summary.Evaluated++
+
+	// Dataset rules only collect keys here, see finishDataset
+	if rule.IsDataset() {
+		c.observeDataset(i)
+		return
+	}
+
+	// A wildcard path yields one value per array element, a plain path exactly one.
+	// If field is missing and op is not 'not_null', it might be valid or invalid depending on rule.
+	// For simplicity, if field missing and we check it, we treat as nil.
+	matches := fieldpath.Resolve(record, rule.Field)
+
+	var failures []checkFailure
+	elementPassed := false
+	for _, m := range matches {
+		f := e.checkValue(c, rule, record, m.Path, m.Value)
+		if len(f) == 0 {
+			elementPassed = true
+		}
+		failures = append(failures, f...)
+	}
+
+	if rule.Match == domain.MatchAny {
+		switch {
+		case elementPassed:
+			failures = nil // One passing element is enough
+		case len(matches) == 0:
+			failures = []checkFailure{{check: -1, detail: domain.ErrorDetail{
+				RuleID: rule.ID,
+				Field:  rule.Field,
+				Reason: "no elements to match",
+			}}}
+		}
+	}
+
+	if len(failures) == 0 {
+		summary.Passed++
+		return
+	}
+
+	summary.Failed++
+	for _, f := range failures {
+		if f.check >= 0 {
+			summary.Checks[f.check].Failed++
+		}
+		if f.hasValue && len(summary.SampleValues) < maxSampleValues {
+			summary.SampleValues = append(summary.SampleValues, f.detail.Value)
+		}
+		c.fail(f.detail, summary.Severity)
+		if c.stopped {
+			return
+		}
+	}
+}
+
+// checkFailure is a failing check of a rule on one value
+type checkFailure struct {
+	check    int // index into rule.Checks, -1 if not tied to a check
+	detail   domain.ErrorDetail
+	hasValue bool // the value was actually tested (not an unknown operator)
+}
+
+// checkValue runs every check of the rule against a single value found at path
+func (e *Executor) checkValue(c *collector, rule domain.Rule, record domain.Record, path string, val interface{}) []checkFailure {
+	var failures []checkFailure
+	for j, check := range rule.Checks {
+		if check.IsDataset() {
+			failures = append(failures, checkFailure{check: j, detail: domain.ErrorDetail{
+				RuleID: rule.ID,
+				Field:  path,
+				Reason: fmt.Sprintf("%s is a dataset check and cannot be combined with record checks", check.Op),
+			}})
+			continue
+		}
+		check = resolveOperand(record, check, c.opts.NumericStrings)
+		op, found := e.ops.Get(check.Op)
+		switch check.Op {
+		case ExprOp:
+			op, found = operators.Operator{Func: c.exprOperator(record), NullSafe: true}, true
+		case domain.OpExistsIn:
+			op, found = operators.Operator{Func: c.referenceOperator(rule.Field), NullSafe: true}, true
+		}
+		if !found {
+			failures = append(failures, checkFailure{check: j, detail: domain.ErrorDetail{
+				RuleID: rule.ID,
+				Field:  path,
+				Reason: fmt.Sprintf("unknown operator: %s", check.Op),
+			}})
+		} else if pass, reason := runOperator(op, val, check); !pass {
+			if check.ValueField != "" {
+				reason = fmt.Sprintf("%s (compared to %s)", reason, check.ValueField)
 			}
-		case "boolean":
-			if _, ok := val.(bool); !ok {
-				return &domain.ErrorDetail{Field: field, Reason: "expected boolean"}
+			failures = append(failures, checkFailure{check: j, hasValue: true, detail: domain.ErrorDetail{
+				RuleID: rule.ID,
+				Field:  path,
+				Value:  val,
+				Reason: reason,
+			}})
+		}
+	}
+	return failures
+}
+
+// maxSampleValues caps how many failing values each RuleSummary keeps
+const maxSampleValues = 5
+
+// collector accumulates failures into a result while enforcing error budgets
+type collector struct {
+	result       *domain.ValidationResult
+	opts         ValidateOptions
+	rules        []domain.RuleSummary   // aligned with the rules being executed
+	recordID     string                 // identity of the record being validated
+	records      []domain.Record        // the batch, for dataset checks
+	index        int                    // index of the record being validated in records
+	keyFields    []string               // fields identifying records[i], see recordKey
+	datasets     map[int][]datasetState // rule index -> one state per check, dataset rules only
+	breached     map[int]bool           // rules breached by an aggregate, whatever their tolerance
+	refs         map[referenceID]referenceSet
+	exprs        map[string]compiledExpr
+	schemaFailed bool
+	stopped      bool
+}
+
+func newCollector(result *domain.ValidationResult, rules []domain.Rule, opts ValidateOptions) *collector {
+	c := &collector{
+		result:    result,
+		opts:      opts,
+		rules:     make([]domain.RuleSummary, len(rules)),
+		keyFields: opts.KeyFields,
+		datasets:  map[int][]datasetState{},
+		breached:  map[int]bool{},
+		refs:      map[referenceID]referenceSet{},
+		exprs:     compileExprs(rules),
+	}
+	for i, rule := range rules {
+		if rule.IsDataset() {
+			for _, check := range rule.Checks {
+				c.datasets[i] = append(c.datasets[i], newDatasetState(rule, check))
 			}
 		}
+		c.rules[i] = domain.RuleSummary{
+			RuleID:   rule.ID,
+			Field:    rule.Field,
+			Severity: rule.EffectiveSeverity(),
+			Checks:   make([]domain.CheckSummary, len(rule.Checks)),
+		}
+		for j, check := range rule.Checks {
+			c.rules[i].Checks[j].Op = check.Op
+		}
+	}
+	return c
+}
+
+// fail records a failure. Counters are always updated, the detail is only kept while under MaxErrors.
+func (c *collector) fail(detail domain.ErrorDetail, severity string) {
+	detail.Severity = severity
+	detail.RecordID = c.recordID
+	c.result.RulesFailed++ // Schema failures count as rule failures too
+
+	if c.opts.MaxErrors > 0 && len(c.result.Errors) >= c.opts.MaxErrors {
+		c.markTruncated(TruncatedMaxErrors)
+	} else {
+		c.result.Errors = append(c.result.Errors, detail)
+	}
+
+	// Only failures that can fail the run are worth stopping for
+	if c.opts.FailFast && severity == domain.SeverityError {
+		c.truncate(TruncatedFailFast)
+	}
+}
+
+// status derives the run status: schema errors always fail, otherwise each rule
+// whose failure ratio exceeds its tolerance contributes its severity.
+func (c *collector) status(rules []domain.Rule) string {
+	if c.schemaFailed {
+		return domain.StatusFail
+	}
+
+	status := domain.StatusPass
+	for i, rule := range rules {
+		summary := c.rules[i]
+		if summary.Failed == 0 && !c.breached[i] {
+			continue
+		}
+		if !c.breached[i] && float64(summary.Failed)/float64(summary.Evaluated) <= rule.Tolerance {
+			continue // Within tolerance
+		}
+
+		switch summary.Severity {
+		case domain.SeverityError:
+			return domain.StatusFail
+		case domain.SeverityWarning:
+			status = domain.StatusWarn
+		}
+	}
+	return status
+}
+
+// summaries finalizes the per-rule statistics
+func (c *collector) summaries() []domain.RuleSummary {
+	for i := range c.rules {
+		if c.rules[i].Evaluated > 0 {
+			c.rules[i].FailureRatio = float64(c.rules[i].Failed) / float64(c.rules[i].Evaluated)
+		}
+	}
+	return c.rules
+}
+
+// truncate flags the result and stops the run
+func (c *collector) truncate(reason string) {
+	c.markTruncated(reason)
+	c.stopped = true
+}
+
+// markTruncated flags the result without stopping; the first reason wins
+func (c *collector) markTruncated(reason string) {
+	if c.result.Truncated {
+		return
 	}
-	return nil
+	c.result.Truncated = true
+	c.result.TruncatedReason = reason
+}
+
+// recordKey identifies a record by its key fields, joined with "|" for composite keys.
+// Records without keys (or with a missing key value) fall back to their batch index, e.g. "#3".
+func recordKey(record domain.Record, keyFields []string, index int) string {
+	if len(keyFields) == 0 {
+		return fmt.Sprintf("#%d", index)
+	}
+
+	parts := make([]string, len(keyFields))
+	for i, field := range keyFields {
+		val, ok := fieldpath.Get(record, field)
+		if !ok || val == nil {
+			return fmt.Sprintf("#%d", index)
+		}
+		parts[i] = fmt.Sprint(val)
+	}
+	return strings.Join(parts, "|")
+}
+
+func contextReason(err error) string {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return TruncatedDeadline
+	}
+	return TruncatedCanceled
+}
+
+// resolveOperand substitutes a cross-field operand with the referenced value (nil if missing),
+// so every operator can compare two fields of the same record
+func resolveOperand(record domain.Record, check domain.Check, numericStrings bool) domain.Check {
+	if check.ValueField != "" {
+		check.Value, _ = fieldpath.Get(record, check.ValueField)
+	}
+	check.NumericStrings = check.NumericStrings || numericStrings
+	return check
 }
 
 // evaluateCondition checks if the "When" condition is met
-func (e *Executor) evaluateCondition(record domain.Record, cond *domain.Condition) bool {
+func (e *Executor) evaluateCondition(record domain.Record, cond *domain.Condition, numericStrings bool) bool {
 	if cond == nil {
 		return true // Always run if no condition
 	}
 
-	val, exists := record[cond.Field]
-	if !exists {
-		val = nil
+	pass, ok := e.evaluateNode(record, *cond, numericStrings)
+	return pass && ok // Fail safe: a tree with an unknown operator never applies
+}
+
+// evaluateNode evaluates one node of a condition tree; ok is false if it uses an unknown operator
+func (e *Executor) evaluateNode(record domain.Record, cond domain.Condition, numericStrings bool) (pass bool, ok bool) {
+	if cond.IsLeaf() {
+		if pass, ok := e.evaluateLeaf(record, cond, numericStrings); !ok || !pass {
+			return pass, ok
+		}
+	}
+
+	for _, sub := range cond.All {
+		if pass, ok := e.evaluateNode(record, sub, numericStrings); !ok || !pass {
+			return pass, ok
+		}
 	}
 
-	check := domain.Check{Op: cond.Op, Value: cond.Value}
-	opFunc, found := operators.Get(cond.Op)
+	if len(cond.Any) > 0 {
+		matched := false
+		for _, sub := range cond.Any {
+			pass, ok := e.evaluateNode(record, sub, numericStrings)
+			if !ok {
+				return false, false
+			}
+			if pass {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false, true
+		}
+	}
+
+	if cond.Not != nil {
+		pass, ok := e.evaluateNode(record, *cond.Not, numericStrings)
+		if !ok || pass {
+			return false, ok
+		}
+	}
+
+	return true, true
+}
+
+// evaluateLeaf compares a single field against the condition
+func (e *Executor) evaluateLeaf(record domain.Record, cond domain.Condition, numericStrings bool) (pass bool, ok bool) {
+	check := resolveOperand(record, domain.Check{Op: cond.Op, Value: cond.Value, ValueField: cond.ValueField}, numericStrings)
+	op, found := e.ops.Get(cond.Op)
 	if !found {
-		return false // Fail safe
+		return false, false
 	}
 
-	pass, _ := opFunc(val, check)
-	return pass
+	// Missing fields are evaluated as nil. Wildcard paths need any (default) or all elements to match.
+	all := cond.Match == domain.MatchAll
+	for _, m := range fieldpath.Resolve(record, cond.Field) {
+		pass, _ := runOperator(op, m.Value, check)
+		if pass && !all {
+			return true, true
+		}
+		if !pass && all {
+			return false, true
+		}
+	}
+	return all, true
+}
+
+// runOperator checks a value, failing null values for operators that are not null-safe
+func runOperator(op operators.Operator, val interface{}, check domain.Check) (bool, string) {
+	if val == nil && !op.NullSafe {
+		return false, "value is null"
+	}
+	return op.Func(val, check)
 }
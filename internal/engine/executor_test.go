@@ -1,9 +1,17 @@
 package engine
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/singh-anurag-7991/data-guard/internal/domain"
+	"github.com/singh-anurag-7991/data-guard/internal/operators"
 )
 
 func TestExecutor_Validate(t *testing.T) {
@@ -126,3 +134,950 @@ func TestExecutor_ConditionalRule(t *testing.T) {
 		t.Errorf("Case 3 failed but condition should have skipped validation")
 	}
 }
+
+func TestExecutor_ValidateContextBudgets(t *testing.T) {
+	e := NewExecutor()
+	rules := []domain.Rule{
+		{ID: "positive", Field: "amount", Checks: []domain.Check{{Op: "gt", Value: 0}}},
+	}
+	records := []domain.Record{{"amount": -1}, {"amount": -2}, {"amount": 3}, {"amount": -4}}
+
+	t.Run("max_errors_keeps_counts", func(t *testing.T) {
+		res := e.ValidateContext(context.Background(), "src", nil, rules, records, ValidateOptions{MaxErrors: 2})
+		if len(res.Errors) != 2 {
+			t.Errorf("expected 2 collected errors, got %d", len(res.Errors))
+		}
+		if res.RulesFailed != 3 || res.RuleSummaries[0].Failed != 3 {
+			t.Errorf("expected 3 failures counted, got %d / %+v", res.RulesFailed, res.RuleSummaries)
+		}
+		if res.RecordsChecked != 4 {
+			t.Errorf("expected all 4 records checked, got %d", res.RecordsChecked)
+		}
+		if !res.Truncated || res.TruncatedReason != TruncatedMaxErrors {
+			t.Errorf("expected truncated by max_errors, got %v %q", res.Truncated, res.TruncatedReason)
+		}
+	})
+
+	t.Run("fail_fast", func(t *testing.T) {
+		res := e.ValidateContext(context.Background(), "src", nil, rules, records, ValidateOptions{FailFast: true})
+		if res.Status != "FAIL" || res.RecordsChecked != 1 || len(res.Errors) != 1 {
+			t.Errorf("expected stop after first record, got status=%s checked=%d errors=%d", res.Status, res.RecordsChecked, len(res.Errors))
+		}
+		if res.TruncatedReason != TruncatedFailFast {
+			t.Errorf("expected fail_fast reason, got %q", res.TruncatedReason)
+		}
+	})
+
+	t.Run("canceled_context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		res := e.ValidateContext(ctx, "src", nil, rules, records, ValidateOptions{})
+		if res.RecordsChecked != 0 || res.TruncatedReason != TruncatedCanceled {
+			t.Errorf("expected no records checked and canceled reason, got %d %q", res.RecordsChecked, res.TruncatedReason)
+		}
+	})
+
+	t.Run("no_budget_hit", func(t *testing.T) {
+		res := e.ValidateContext(context.Background(), "src", nil, rules, records, ValidateOptions{MaxErrors: 10, MaxDuration: time.Minute})
+		if res.Truncated {
+			t.Errorf("did not expect truncation, got %q", res.TruncatedReason)
+		}
+	})
+}
+
+func TestExecutor_SeverityAndTolerance(t *testing.T) {
+	e := NewExecutor()
+	// 1 of 50 records (2%) violates the rule
+	records := make([]domain.Record, 50)
+	for i := range records {
+		records[i] = domain.Record{"amount": 10}
+	}
+	records[0] = domain.Record{"amount": -1}
+
+	tests := []struct {
+		name       string
+		severity   string
+		tolerance  float64
+		wantStatus string
+	}{
+		{"error_default", "", 0, "FAIL"},
+		{"warning", "warning", 0, "WARN"},
+		{"info_only_records", "info", 0, "PASS"},
+		{"error_within_tolerance", "error", 0.02, "PASS"},
+		{"error_over_tolerance", "error", 0.01, "FAIL"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := []domain.Rule{{
+				ID:        "positive",
+				Field:     "amount",
+				Severity:  tt.severity,
+				Tolerance: tt.tolerance,
+				Checks:    []domain.Check{{Op: "gt", Value: 0}},
+			}}
+			res := e.Validate("src", nil, rules, records)
+			if res.Status != tt.wantStatus {
+				t.Errorf("expected %s, got %s", tt.wantStatus, res.Status)
+			}
+			if len(res.Errors) != 1 {
+				t.Errorf("expected the failure to be recorded regardless of status, got %d errors", len(res.Errors))
+			}
+		})
+	}
+}
+
+func TestExecutor_RuleSummaries(t *testing.T) {
+	e := NewExecutor()
+	rules := []domain.Rule{
+		{
+			ID:     "credit_range",
+			Field:  "amount",
+			When:   &domain.Condition{Field: "type", Op: "eq", Value: "credit"},
+			Checks: []domain.Check{{Op: "gt", Value: 0}, {Op: "lt", Value: 1000}},
+		},
+	}
+	records := []domain.Record{
+		{"type": "credit", "amount": 10},
+		{"type": "credit", "amount": -5},
+		{"type": "credit", "amount": 5000},
+		{"type": "debit", "amount": -5},
+	}
+
+	res := e.Validate("src", nil, rules, records)
+	if len(res.RuleSummaries) != 1 {
+		t.Fatalf("expected 1 rule summary, got %d", len(res.RuleSummaries))
+	}
+
+	s := res.RuleSummaries[0]
+	if s.Evaluated != 3 || s.Skipped != 1 || s.Passed != 1 || s.Failed != 2 {
+		t.Errorf("unexpected counts: %+v", s)
+	}
+	if s.FailureRatio < 0.66 || s.FailureRatio > 0.67 {
+		t.Errorf("expected failure ratio ~0.667, got %f", s.FailureRatio)
+	}
+	if s.Checks[0].Op != "gt" || s.Checks[0].Failed != 1 || s.Checks[1].Failed != 1 {
+		t.Errorf("unexpected per-check counts: %+v", s.Checks)
+	}
+	if len(s.SampleValues) != 2 {
+		t.Errorf("expected 2 sample values, got %v", s.SampleValues)
+	}
+}
+
+func TestExecutor_RecordID(t *testing.T) {
+	e := NewExecutor()
+	rules := []domain.Rule{
+		{ID: "positive", Field: "amount", Checks: []domain.Check{{Op: "gt", Value: 0}}},
+	}
+	records := []domain.Record{
+		{"order_id": "A1", "line": 1, "amount": 5},
+		{"order_id": "A1", "line": 2, "amount": -5},
+		{"line": 3, "amount": -1}, // key incomplete, falls back to index
+	}
+
+	tests := []struct {
+		name      string
+		keyFields []string
+		want      []string
+	}{
+		{"index_fallback", nil, []string{"#1", "#2"}},
+		{"composite_key", []string{"order_id", "line"}, []string{"A1|2", "#2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := e.ValidateContext(context.Background(), "src", nil, rules, records, ValidateOptions{KeyFields: tt.keyFields})
+			if len(res.Errors) != len(tt.want) {
+				t.Fatalf("expected %d errors, got %d", len(tt.want), len(res.Errors))
+			}
+			for i, want := range tt.want {
+				if res.Errors[i].RecordID != want {
+					t.Errorf("error %d: expected record id %q, got %q", i, want, res.Errors[i].RecordID)
+				}
+			}
+		})
+	}
+}
+
+func TestExecutor_NestedPaths(t *testing.T) {
+	e := NewExecutor()
+	record := domain.Record{
+		"customer": map[string]interface{}{
+			"address": map[string]interface{}{"zip": "10001", "country": "US"},
+		},
+		"items": []interface{}{
+			map[string]interface{}{"price": 10.0, "type": "physical"},
+			map[string]interface{}{"price": -1.0, "type": "digital"},
+		},
+	}
+	schema := domain.Schema{"customer.address.zip": "string", "items[*].price": "number"}
+
+	tests := []struct {
+		name       string
+		rule       domain.Rule
+		wantStatus string
+		wantFields []string
+	}{
+		{
+			name:       "nested_object",
+			rule:       domain.Rule{ID: "zip", Field: "customer.address.zip", Checks: []domain.Check{{Op: "regex", Value: `^\d{5}$`}}},
+			wantStatus: "PASS",
+		},
+		{
+			name:       "wildcard_all",
+			rule:       domain.Rule{ID: "prices", Field: "items[*].price", Checks: []domain.Check{{Op: "gt", Value: 0}}},
+			wantStatus: "FAIL",
+			wantFields: []string{"items[1].price"},
+		},
+		{
+			name:       "wildcard_any",
+			rule:       domain.Rule{ID: "prices", Field: "items[*].price", Match: "any", Checks: []domain.Check{{Op: "gt", Value: 0}}},
+			wantStatus: "PASS",
+		},
+		{
+			name: "wildcard_condition_any",
+			rule: domain.Rule{
+				ID:     "digital_zip",
+				Field:  "customer.address.country",
+				When:   &domain.Condition{Field: "items[*].type", Op: "eq", Value: "digital"},
+				Checks: []domain.Check{{Op: "eq", Value: "CA"}},
+			},
+			wantStatus: "FAIL",
+			wantFields: []string{"customer.address.country"},
+		},
+		{
+			name: "wildcard_condition_all",
+			rule: domain.Rule{
+				ID:     "all_digital",
+				Field:  "customer.address.country",
+				When:   &domain.Condition{Field: "items[*].type", Op: "eq", Value: "digital", Match: "all"},
+				Checks: []domain.Check{{Op: "eq", Value: "CA"}},
+			},
+			wantStatus: "PASS",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := e.Validate("src", schema, []domain.Rule{tt.rule}, []domain.Record{record})
+			if res.Status != tt.wantStatus {
+				t.Errorf("expected %s, got %s (errors: %v)", tt.wantStatus, res.Status, res.Errors)
+			}
+			if len(res.Errors) != len(tt.wantFields) {
+				t.Fatalf("expected %d errors, got %v", len(tt.wantFields), res.Errors)
+			}
+			for i, f := range tt.wantFields {
+				if res.Errors[i].Field != f {
+					t.Errorf("expected error on %s, got %s", f, res.Errors[i].Field)
+				}
+			}
+		})
+	}
+}
+
+func TestExecutor_CompoundConditions(t *testing.T) {
+	e := NewExecutor()
+
+	// "apply when country = US and channel != wholesale"
+	when := `{"all": [
+		{"field": "country", "op": "eq", "value": "US"},
+		{"not": {"field": "channel", "op": "eq", "value": "wholesale"}}
+	]}`
+	var cond domain.Condition
+	if err := json.Unmarshal([]byte(when), &cond); err != nil {
+		t.Fatalf("failed to decode condition: %v", err)
+	}
+
+	rules := []domain.Rule{{
+		ID:     "us_retail_tax",
+		Field:  "tax",
+		When:   &cond,
+		Checks: []domain.Check{{Op: "gt", Value: 0}},
+	}}
+
+	tests := []struct {
+		name   string
+		record domain.Record
+		want   string
+	}{
+		{"applies_and_fails", domain.Record{"country": "US", "channel": "retail", "tax": 0}, "FAIL"},
+		{"applies_channel_missing", domain.Record{"country": "US", "tax": 0}, "FAIL"},
+		{"excluded_by_not", domain.Record{"country": "US", "channel": "wholesale", "tax": 0}, "PASS"},
+		{"excluded_by_all", domain.Record{"country": "CA", "channel": "retail", "tax": 0}, "PASS"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := e.Validate("src", nil, rules, []domain.Record{tt.record})
+			if res.Status != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, res.Status)
+			}
+		})
+	}
+
+	t.Run("any_group", func(t *testing.T) {
+		anyCond := &domain.Condition{Any: []domain.Condition{
+			{Field: "country", Op: "eq", Value: "US"},
+			{Field: "country", Op: "eq", Value: "CA"},
+		}}
+		rule := domain.Rule{ID: "na", Field: "tax", When: anyCond, Checks: []domain.Check{{Op: "gt", Value: 0}}}
+		if res := e.Validate("src", nil, []domain.Rule{rule}, []domain.Record{{"country": "CA", "tax": 0}}); res.Status != "FAIL" {
+			t.Errorf("expected CA to match the any group")
+		}
+		if res := e.Validate("src", nil, []domain.Rule{rule}, []domain.Record{{"country": "MX", "tax": 0}}); res.Status != "PASS" {
+			t.Errorf("expected MX to be skipped")
+		}
+	})
+
+	t.Run("unknown_operator_never_applies", func(t *testing.T) {
+		notCond := &domain.Condition{Not: &domain.Condition{Field: "country", Op: "bogus"}}
+		rule := domain.Rule{ID: "x", Field: "tax", When: notCond, Checks: []domain.Check{{Op: "gt", Value: 0}}}
+		if res := e.Validate("src", nil, []domain.Rule{rule}, []domain.Record{{"country": "US", "tax": 0}}); res.Status != "PASS" {
+			t.Errorf("expected rule with invalid condition to be skipped")
+		}
+	})
+}
+
+func TestExecutor_CrossFieldChecks(t *testing.T) {
+	e := NewExecutor()
+	rules := []domain.Rule{
+		{ID: "discount_le_subtotal", Field: "discount", Checks: []domain.Check{{Op: "lt", ValueField: "subtotal"}}},
+		{ID: "end_after_start", Field: "end", Checks: []domain.Check{{Op: "gt", ValueField: "start"}}},
+		{
+			ID:     "refund_matches_total",
+			Field:  "refund",
+			When:   &domain.Condition{Field: "refund", Op: "not_null"},
+			Checks: []domain.Check{{Op: "eq", ValueField: "totals.charged"}},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		record   domain.Record
+		wantRule []string
+	}{
+		{"all_pass", domain.Record{"discount": 5, "subtotal": 50, "start": 1, "end": 2, "refund": nil}, nil},
+		{"discount_too_big", domain.Record{"discount": 60, "subtotal": 50, "start": 1, "end": 2}, []string{"discount_le_subtotal"}},
+		{"reference_missing", domain.Record{"discount": 5, "subtotal": 50, "end": 2}, []string{"end_after_start"}},
+		{"nested_reference", domain.Record{"discount": 5, "subtotal": 50, "start": 1, "end": 2, "refund": "x", "totals": map[string]interface{}{"charged": "y"}}, []string{"refund_matches_total"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := e.Validate("src", nil, rules, []domain.Record{tt.record})
+			if len(res.Errors) != len(tt.wantRule) {
+				t.Fatalf("expected %d errors, got %v", len(tt.wantRule), res.Errors)
+			}
+			for i, id := range tt.wantRule {
+				if res.Errors[i].RuleID != id {
+					t.Errorf("expected failure of %s, got %s", id, res.Errors[i].RuleID)
+				}
+			}
+		})
+	}
+
+	t.Run("condition_compares_fields", func(t *testing.T) {
+		rule := domain.Rule{
+			ID:     "late_shipments_flagged",
+			Field:  "late",
+			When:   &domain.Condition{Field: "shipped_day", Op: "gt", ValueField: "promised_day"},
+			Checks: []domain.Check{{Op: "eq", Value: true}},
+		}
+		res := e.Validate("src", nil, []domain.Rule{rule}, []domain.Record{
+			{"shipped_day": 5, "promised_day": 3, "late": false},
+			{"shipped_day": 2, "promised_day": 3, "late": false},
+		})
+		if s := res.RuleSummaries[0]; s.Evaluated != 1 || s.Failed != 1 {
+			t.Errorf("expected only the late shipment to be evaluated and fail, got %+v", s)
+		}
+	})
+}
+
+func TestExecutor_Expressions(t *testing.T) {
+	e := NewExecutor()
+	rules := []domain.Rule{
+		{ID: "line_total", Field: "line_total", Checks: []domain.Check{{Op: ExprOp, Value: "quantity * unit_price == $ ± 0.01"}}},
+		{ID: "discount", Field: "discount", Checks: []domain.Check{{Op: ExprOp, Value: "coalesce($, 0) <= subtotal"}}},
+	}
+
+	tests := []struct {
+		name       string
+		record     domain.Record
+		wantRule   string
+		wantReason string
+	}{
+		{"pass", domain.Record{"quantity": 3, "unit_price": 2.5, "line_total": 7.5, "subtotal": 10}, "", ""},
+		{"rounding_tolerated", domain.Record{"quantity": 3, "unit_price": 2.5, "line_total": 7.505, "discount": nil, "subtotal": 10}, "", ""},
+		{"mismatch", domain.Record{"quantity": 3, "unit_price": 2.5, "line_total": 8, "subtotal": 10}, "line_total", `expression "quantity * unit_price == $ ± 0.01" is false`},
+		{"runtime_error", domain.Record{"quantity": "three", "unit_price": 2.5, "line_total": 7.5, "subtotal": 10}, "line_total", "expression error: * expects numbers"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := e.Validate("src", nil, rules, []domain.Record{tt.record})
+			if tt.wantRule == "" {
+				if len(res.Errors) != 0 {
+					t.Fatalf("expected no errors, got %v", res.Errors)
+				}
+				return
+			}
+			if len(res.Errors) != 1 || res.Errors[0].RuleID != tt.wantRule {
+				t.Fatalf("expected a failure of %s, got %v", tt.wantRule, res.Errors)
+			}
+			if got := res.Errors[0].Reason; len(got) < len(tt.wantReason) || got[:len(tt.wantReason)] != tt.wantReason {
+				t.Errorf("expected reason starting with %q, got %q", tt.wantReason, got)
+			}
+		})
+	}
+
+	t.Run("invalid_expression", func(t *testing.T) {
+		rule := domain.Rule{ID: "bad", Field: "x", Checks: []domain.Check{{Op: ExprOp, Value: "x +"}}}
+		res := e.Validate("src", nil, []domain.Rule{rule}, []domain.Record{{"x": 1}})
+		if len(res.Errors) != 1 || res.Errors[0].Reason[:len("invalid expression")] != "invalid expression" {
+			t.Errorf("expected an invalid expression failure, got %v", res.Errors)
+		}
+	})
+
+	t.Run("value_field", func(t *testing.T) {
+		rule := domain.Rule{ID: "bad", Field: "x", Checks: []domain.Check{{Op: ExprOp, Value: "$ > 0", ValueField: "y"}}}
+		res := e.Validate("src", nil, []domain.Rule{rule}, []domain.Record{{"x": 1, "y": "$ > 0"}})
+		if len(res.Errors) != 1 || !strings.HasPrefix(res.Errors[0].Reason, "expr checks take no value_field") {
+			t.Errorf("expected value_field to be rejected, got %v", res.Errors)
+		}
+	})
+}
+
+func TestExecutor_Duplicates(t *testing.T) {
+	e := NewExecutor()
+	records := []domain.Record{
+		{"order_id": 1, "line": 1, "status": "paid"},
+		{"order_id": 2, "line": 1, "status": "paid"},
+		{"order_id": 1.0, "line": 2, "status": "paid"}, // same key as record 0
+		{"order_id": nil, "line": 1, "status": "paid"}, // null keys are never duplicates
+		{"order_id": nil, "line": 1, "status": "paid"},
+		{"order_id": 2, "line": 1, "status": "void"},
+	}
+
+	t.Run("unique", func(t *testing.T) {
+		rule := domain.Rule{ID: "unique_order", Field: "order_id", Checks: []domain.Check{{Op: domain.OpUnique}}}
+		res := e.Validate("src", nil, []domain.Rule{rule}, records)
+		if len(res.Errors) != 4 {
+			t.Fatalf("expected the 4 records sharing a key to be reported, got %v", res.Errors)
+		}
+		want := "duplicate order_id 1 shared by 2 records (#0, #2)"
+		if res.Errors[0].Reason != want || res.Errors[0].RecordID != "#0" || res.Errors[1].RecordID != "#2" {
+			t.Errorf("expected %q for #0 and #2, got %+v", want, res.Errors[:2])
+		}
+		if s := res.RuleSummaries[0]; s.Evaluated != 6 || s.Failed != 4 || s.Passed != 2 || res.Status != domain.StatusFail {
+			t.Errorf("unexpected summary %+v (status %s)", s, res.Status)
+		}
+	})
+
+	t.Run("large_int64_keys", func(t *testing.T) {
+		// Distinct bigint IDs that round to the same float64
+		ids := []domain.Record{{"id": int64(1<<53 + 1)}, {"id": int64(1 << 53)}, {"id": json.Number("9007199254740993")}}
+		rule := domain.Rule{ID: "unique_id", Field: "id", Checks: []domain.Check{{Op: domain.OpUnique}}}
+		res := e.Validate("src", nil, []domain.Rule{rule}, ids)
+		if len(res.Errors) != 2 || res.Errors[0].RecordID != "#0" || res.Errors[1].RecordID != "#2" {
+			t.Errorf("expected only #0 and #2 to share a key, got %v", res.Errors)
+		}
+	})
+
+	t.Run("composite_key_with_when", func(t *testing.T) {
+		rule := domain.Rule{
+			ID:     "unique_line",
+			When:   &domain.Condition{Field: "status", Op: "eq", Value: "paid"},
+			Checks: []domain.Check{{Op: domain.OpUnique, Fields: []string{"order_id", "line"}}},
+		}
+		res := e.Validate("src", nil, []domain.Rule{rule}, records)
+		if len(res.Errors) != 0 {
+			t.Errorf("expected no duplicates among paid lines, got %v", res.Errors)
+		}
+	})
+
+	t.Run("max_duplicates", func(t *testing.T) {
+		rule := func(limit int) domain.Rule {
+			return domain.Rule{ID: "few_dupes", Field: "order_id", Checks: []domain.Check{{Op: domain.OpMaxDuplicates, Value: limit}}}
+		}
+		if res := e.Validate("src", nil, []domain.Rule{rule(2)}, records); len(res.Errors) != 0 {
+			t.Errorf("expected 2 repeated records to be allowed, got %v", res.Errors)
+		}
+		if res := e.Validate("src", nil, []domain.Rule{rule(1)}, records); len(res.Errors) != 4 {
+			t.Errorf("expected every duplicate to be reported over the limit, got %v", res.Errors)
+		}
+	})
+
+	t.Run("mixed_with_record_checks", func(t *testing.T) {
+		rule := domain.Rule{ID: "mixed", Field: "order_id", Checks: []domain.Check{{Op: "not_null"}, {Op: domain.OpUnique}}}
+		res := e.Validate("src", nil, []domain.Rule{rule}, records[:1])
+		if len(res.Errors) != 1 || res.Errors[0].Reason != "unique is a dataset check and cannot be combined with record checks" {
+			t.Errorf("expected the dataset check to be rejected, got %v", res.Errors)
+		}
+	})
+}
+
+func TestExecutor_Aggregates(t *testing.T) {
+	e := NewExecutor()
+	records := []domain.Record{
+		{"revenue": 10, "region": "eu", "country": "DE"},
+		{"revenue": 30.5, "region": "eu", "country": "FR"},
+		{"revenue": nil, "region": "eu", "country": "FR"},
+		{"revenue": 1000, "region": "us", "country": "US"},
+	}
+	bound := func(v float64) *float64 { return &v }
+	eu := &domain.Condition{Field: "region", Op: "eq", Value: "eu"}
+
+	tests := []struct {
+		name       string
+		check      domain.Check
+		wantReason string
+	}{
+		{"row_count_ok", domain.Check{Op: domain.OpRowCount, Min: bound(3), Max: bound(3)}, ""},
+		{"row_count_low", domain.Check{Op: domain.OpRowCount, Min: bound(100)}, "row_count is 3, below the minimum 100"},
+		{"sum", domain.Check{Op: domain.OpSum, Min: bound(50)}, "sum of revenue is 40.5, below the minimum 50"},
+		{"avg", domain.Check{Op: domain.OpAvg, Max: bound(20)}, "avg of revenue is 20.25, above the maximum 20"},
+		{"min_max_ok", domain.Check{Op: domain.OpMin, Min: bound(0)}, ""},
+		{"max", domain.Check{Op: domain.OpMax, Max: bound(30)}, "max of revenue is 30.5, above the maximum 30"},
+		{"null_ratio", domain.Check{Op: domain.OpNullRatio, Max: bound(0.1)}, "null_ratio of revenue is 0.333333, above the maximum 0.1"},
+		{"distinct_count", domain.Check{Op: domain.OpDistinctCount, Min: bound(3)}, "distinct_count of revenue is 2, below the minimum 3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := domain.Rule{ID: "agg", Field: "revenue", When: eu, Checks: []domain.Check{tt.check}}
+			res := e.Validate("src", nil, []domain.Rule{rule}, records)
+			if tt.wantReason == "" {
+				if len(res.Errors) != 0 || res.Status != domain.StatusPass {
+					t.Errorf("expected a pass, got %s with %v", res.Status, res.Errors)
+				}
+				return
+			}
+			if len(res.Errors) != 1 || res.Errors[0].Reason != tt.wantReason {
+				t.Fatalf("expected %q, got %v", tt.wantReason, res.Errors)
+			}
+			if s := res.RuleSummaries[0]; res.Status != domain.StatusFail || s.Failed != 3 || s.Skipped != 1 {
+				t.Errorf("expected the breach to fail the run and every evaluated record, got %s with %+v", res.Status, s)
+			}
+		})
+	}
+
+	t.Run("distinct_large_int64", func(t *testing.T) {
+		ids := []domain.Record{{"id": int64(1<<53 + 1)}, {"id": int64(1 << 53)}}
+		rule := domain.Rule{ID: "agg", Field: "id", Checks: []domain.Check{{Op: domain.OpDistinctCount, Min: bound(2)}}}
+		if res := e.Validate("src", nil, []domain.Rule{rule}, ids); len(res.Errors) != 0 {
+			t.Errorf("expected IDs beyond float64 precision to stay distinct, got %v", res.Errors)
+		}
+	})
+
+	t.Run("undefined_on_empty_batch", func(t *testing.T) {
+		rule := domain.Rule{ID: "agg", Field: "revenue", Checks: []domain.Check{{Op: domain.OpAvg, Min: bound(1)}}}
+		res := e.Validate("src", nil, []domain.Rule{rule}, nil)
+		if res.Status != domain.StatusFail || len(res.Errors) != 1 {
+			t.Errorf("expected an undefined average to fail, got %s with %v", res.Status, res.Errors)
+		}
+	})
+}
+
+// staticReferences resolves every reference against a fixed set of keys
+type staticReferences map[string]bool
+
+func (s staticReferences) ExistingKeys(ctx context.Context, ref domain.Reference, keys []string) ([]string, error) {
+	var found []string
+	for _, k := range keys {
+		if s[k] {
+			found = append(found, k)
+		}
+	}
+	return found, nil
+}
+
+func TestExecutor_References(t *testing.T) {
+	e := NewExecutor()
+	rule := domain.Rule{
+		ID:     "customer_exists",
+		Field:  "customer_id",
+		Checks: []domain.Check{{Op: domain.OpExistsIn, Ref: &domain.Reference{Table: "customers", Column: "id"}}},
+	}
+	records := []domain.Record{
+		{"customer_id": 1},
+		{"customer_id": int64(2)},
+		{"customer_id": nil}, // nullable, like a foreign key
+		{"customer_id": 99},
+	}
+
+	res := e.ValidateContext(context.Background(), "orders", nil, []domain.Rule{rule}, records, ValidateOptions{
+		References: staticReferences{"1": true, "2": true},
+	})
+	if len(res.Errors) != 1 || res.Errors[0].RecordID != "#3" || res.Errors[0].Reason != "value 99 not found in customers.id" {
+		t.Errorf("expected only #3 to miss its customer, got %+v", res.Errors)
+	}
+
+	res = e.Validate("orders", nil, []domain.Rule{rule}, records[:1])
+	if len(res.Errors) != 1 || res.Errors[0].Reason != "reference customers.id unavailable: no reference resolver configured" {
+		t.Errorf("expected the check to fail without a resolver, got %+v", res.Errors)
+	}
+
+	// Keys beyond float64 precision match exactly
+	large := []domain.Record{{"customer_id": int64(1<<53 + 1)}, {"customer_id": json.Number("9007199254740992")}}
+	res = e.ValidateContext(context.Background(), "orders", nil, []domain.Rule{rule}, large, ValidateOptions{
+		References: staticReferences{"9007199254740993": true},
+	})
+	if len(res.Errors) != 1 || res.Errors[0].RecordID != "#1" {
+		t.Errorf("expected only #1 to miss its customer, got %+v", res.Errors)
+	}
+
+	injected := rule
+	injected.Checks = []domain.Check{{Op: domain.OpExistsIn, Ref: &domain.Reference{Table: "customers; DROP TABLE x --", Column: "id"}}}
+	res = e.ValidateContext(context.Background(), "orders", nil, []domain.Rule{injected}, records[:1], ValidateOptions{
+		References: staticReferences{"1": true},
+	})
+	if len(res.Errors) != 1 || !strings.Contains(res.Errors[0].Reason, "unavailable: invalid identifier") {
+		t.Errorf("expected a reference to an invalid table to be rejected before resolving, got %+v", res.Errors)
+	}
+}
+
+// staticProfiles serves a fixed last profile
+type staticProfiles []domain.FieldProfile
+
+func (s staticProfiles) LastProfile(ctx context.Context, sourceID string) ([]domain.FieldProfile, error) {
+	return s, nil
+}
+
+func TestExecutor_ProfileChange(t *testing.T) {
+	e := NewExecutor()
+	bound := func(v float64) *float64 { return &v }
+	rule := domain.Rule{
+		ID:     "countries_kept",
+		Field:  "country",
+		Checks: []domain.Check{{Op: domain.OpProfileChange, Stat: "distinct", Min: bound(-0.2)}},
+	}
+	var records []domain.Record
+	for _, c := range []string{"DE", "FR", "DE", "IT"} {
+		records = append(records, domain.Record{"country": c})
+	}
+	validate := func(last staticProfiles, rule domain.Rule) domain.ValidationResult {
+		return e.ValidateContext(context.Background(), "orders", nil, []domain.Rule{rule}, records, ValidateOptions{Profiles: last})
+	}
+
+	// The first profiled run has nothing to compare with, but stores the profile
+	res := validate(staticProfiles{}, rule)
+	if len(res.Errors) != 0 || len(res.Profile) != 1 || res.Profile[0].Distinct != 3 {
+		t.Fatalf("expected a passing run with a profile of country, got %+v %+v", res.Errors, res.Profile)
+	}
+
+	if res := validate(staticProfiles{{Field: "country", Count: 4, Distinct: 3}}, rule); len(res.Errors) != 0 {
+		t.Errorf("expected an unchanged distinct count to pass, got %+v", res.Errors)
+	}
+
+	res = validate(staticProfiles{{Field: "country", Count: 10, Distinct: 5}}, rule)
+	want := "distinct of country changed by -40.0% (5 -> 3), below the minimum -20.0%"
+	if len(res.Errors) != 1 || res.Errors[0].Reason != want || res.Status != domain.StatusFail {
+		t.Errorf("expected %q, got %+v", want, res.Errors)
+	}
+
+	unknown := rule
+	unknown.Checks = []domain.Check{{Op: domain.OpProfileChange, Stat: "median"}}
+	if res := validate(staticProfiles{}, unknown); len(res.Errors) != 1 || !strings.HasPrefix(res.Errors[0].Reason, "profile_change expects a stat") {
+		t.Errorf("expected an unknown stat to fail, got %+v", res.Errors)
+	}
+
+	res = e.Validate("orders", nil, []domain.Rule{rule}, records)
+	if len(res.Errors) != 1 || res.Errors[0].Reason != "last profile unavailable: no profile source configured" {
+		t.Errorf("expected the check to fail without profiles, got %+v", res.Errors)
+	}
+}
+
+// staticBaselines serves the values of references by source, ErrNoReferenceData for others
+type staticBaselines map[string][]interface{}
+
+func (s staticBaselines) ExistingKeys(ctx context.Context, ref domain.Reference, keys []string) ([]string, error) {
+	return nil, nil
+}
+
+func (s staticBaselines) ReferenceValues(ctx context.Context, ref domain.Reference) ([]interface{}, error) {
+	values, ok := s[ref.Source]
+	if !ok {
+		return nil, ErrNoReferenceData
+	}
+	return values, nil
+}
+
+func TestExecutor_DistributionShift(t *testing.T) {
+	e := NewExecutor()
+	bound := func(v float64) *float64 { return &v }
+	// Prices went up by 50, so half of them are above every earlier price
+	var before, after []interface{}
+	var records []domain.Record
+	for i := 0; i < 200; i++ {
+		p := float64(i % 100)
+		before = append(before, p)
+		after = append(after, p+50)
+		records = append(records, domain.Record{"price": p + 50, "country": []string{"DE", "FR"}[i%2]})
+	}
+	baselines := staticBaselines{
+		"orders":   before,
+		"shop_de":  {"DE", "DE", "DE", "FR"},
+		"repriced": after,
+	}
+
+	tests := []struct {
+		name       string
+		field      string
+		check      domain.Check
+		wantReason string
+	}{
+		{"ks against the previous dataset", "price", domain.Check{Op: domain.OpKS}, "ks of price is 0.5 against source orders (price), above the maximum 0.1"},
+		{"ks within bounds", "price", domain.Check{Op: domain.OpKS, Max: bound(0.6)}, ""},
+		{"psi of the same values", "price", domain.Check{Op: domain.OpPSI, Ref: &domain.Reference{Source: "repriced", Column: "price"}}, ""},
+		{"share_delta", "country", domain.Check{Op: domain.OpShareDelta, Ref: &domain.Reference{Source: "shop_de", Column: "country"}}, `share_delta of country is 0.25 (share of "DE") against source shop_de (country), above the maximum 0.1`},
+		{"chi_square of a small baseline", "country", domain.Check{Op: domain.OpChiSquare, Ref: &domain.Reference{Source: "shop_de", Column: "country"}}, ""},
+		{"chi_square with a minimum p-value", "country", domain.Check{Op: domain.OpChiSquare, Ref: &domain.Reference{Source: "shop_de", Column: "country"}, Min: bound(0.5)}, "chi_square p-value of country is 0.322079 (statistic 0.980486, 1 degrees of freedom) against source shop_de (country), below the minimum 0.5"},
+		{"ks needs numbers", "country", domain.Check{Op: domain.OpKS, Ref: &domain.Reference{Source: "shop_de", Column: "country"}}, "ks of country needs numeric values"},
+		{"first run of a source", "price", domain.Check{Op: domain.OpPSI}, ""},
+		{"missing explicit baseline", "price", domain.Check{Op: domain.OpPSI, Ref: &domain.Reference{Source: "nope", Column: "price"}}, "baseline source nope (price) unavailable: no dataset ingested"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := "orders"
+			if tt.name == "first run of a source" {
+				source = "new_source"
+			}
+			rule := domain.Rule{ID: "shift", Field: tt.field, Checks: []domain.Check{tt.check}}
+			res := e.ValidateContext(context.Background(), source, nil, []domain.Rule{rule}, records, ValidateOptions{References: baselines})
+
+			var reason string
+			if len(res.Errors) > 0 {
+				reason = res.Errors[0].Reason
+			}
+			if reason != tt.wantReason {
+				t.Errorf("expected reason %q, got %q", tt.wantReason, reason)
+			}
+		})
+	}
+
+	// The statistic is reported whether the check passes or not
+	rule := domain.Rule{ID: "shift", Field: "price", Checks: []domain.Check{{Op: domain.OpKS, Max: bound(0.6)}}}
+	res := e.ValidateContext(context.Background(), "orders", nil, []domain.Rule{rule}, records, ValidateOptions{References: baselines})
+	if stat := res.RuleSummaries[0].Checks[0].Statistic; stat == nil || *stat != 0.5 {
+		t.Errorf("expected the KS statistic 0.5 in the summary, got %v", res.RuleSummaries[0].Checks[0])
+	}
+}
+
+// evenOperator is a custom operator passing even numbers
+var evenOperator = operators.Operator{
+	Name: "even",
+	Func: func(value interface{}, check domain.Check) (bool, string) {
+		if f, ok := operators.ToFloat(value); ok && int(f)%2 == 0 {
+			return true, ""
+		}
+		return false, fmt.Sprintf("%v is odd", value)
+	},
+	SQL: func(column string, check domain.Check) (string, interface{}) {
+		return fmt.Sprintf("%s %% 2 <>", column), 0
+	},
+}
+
+func TestExecutor_CustomOperator(t *testing.T) {
+	ops := operators.NewRegistry()
+	if err := ops.Register(evenOperator); err != nil {
+		t.Fatal(err)
+	}
+	e := NewExecutorWithRegistry(ops)
+	rules := []domain.Rule{
+		{ID: "even", Field: "n", Checks: []domain.Check{{Op: "even"}}},
+		{ID: "when_even", Field: "label", When: &domain.Condition{Field: "n", Op: "even"}, Checks: []domain.Check{{Op: "not_null"}}},
+	}
+	records := []domain.Record{{"n": 2, "label": "two"}, {"n": 3}, {"n": nil}, {"n": 4}}
+
+	res := e.Validate("src", nil, rules, records)
+	var reasons []string
+	for _, err := range res.Errors {
+		reasons = append(reasons, err.RuleID+": "+err.Reason)
+	}
+	want := []string{"even: 3 is odd", "even: value is null", "when_even: value is null"}
+	if !reflect.DeepEqual(reasons, want) {
+		t.Errorf("expected %v, got %v", want, reasons)
+	}
+	if s := res.RuleSummaries[1]; s.Evaluated != 2 || s.Skipped != 2 {
+		t.Errorf("expected the condition to hold for even numbers only, got %+v", s)
+	}
+
+	if res := NewExecutor().Validate("src", nil, rules[:1], records[:1]); len(res.Errors) != 1 || res.Errors[0].Reason != "unknown operator: even" {
+		t.Errorf("expected executors not to share operators, got %v", res.Errors)
+	}
+}
+
+func TestExecutor_MaxAge(t *testing.T) {
+	e := NewExecutor()
+	now := time.Now()
+	rule := domain.Rule{ID: "fresh", Field: "updated_at", Checks: []domain.Check{{Op: domain.OpMaxAge, Value: "2h"}}}
+
+	fresh := []domain.Record{
+		{"updated_at": now.Add(-5 * time.Hour).Format(time.RFC3339)},
+		{"updated_at": now.Add(-time.Hour)},
+	}
+	if res := e.Validate("src", nil, []domain.Rule{rule}, fresh); len(res.Errors) != 0 {
+		t.Errorf("expected the latest timestamp to be fresh, got %v", res.Errors)
+	}
+
+	stale := []domain.Record{{"updated_at": now.Add(-3 * time.Hour).UTC().Format(time.RFC3339)}}
+	res := e.Validate("src", nil, []domain.Rule{rule}, stale)
+	if len(res.Errors) != 1 || res.Status != domain.StatusFail {
+		t.Fatalf("expected a stale failure, got %v", res.Errors)
+	}
+	if reason := res.Errors[0].Reason; len(reason) < 24 || reason[:24] != "latest updated_at is 3h0" {
+		t.Errorf("unexpected reason %q", reason)
+	}
+
+	res = e.Validate("src", nil, []domain.Rule{rule}, []domain.Record{{"updated_at": nil}})
+	if len(res.Errors) != 1 || res.Errors[0].Reason != "no timestamps in updated_at" {
+		t.Errorf("expected missing timestamps to fail, got %v", res.Errors)
+	}
+
+	// Timestamps are read in the check's format and timezone
+	epoch := domain.Rule{ID: "fresh", Field: "paid_at", Checks: []domain.Check{{Op: domain.OpMaxAge, Value: "2h", Format: "epoch_seconds"}}}
+	if res := e.Validate("src", nil, []domain.Rule{epoch}, []domain.Record{{"paid_at": now.Add(-time.Hour).Unix()}}); len(res.Errors) != 0 {
+		t.Errorf("expected the epoch timestamp to be fresh, got %v", res.Errors)
+	}
+	zoned := domain.Rule{ID: "fresh", Field: "updated_at", Checks: []domain.Check{{Op: domain.OpMaxAge, Value: "2h", Timezone: "Asia/Tokyo"}}}
+	local := []domain.Record{{"updated_at": now.Add(-time.Hour).In(time.FixedZone("JST", 9*3600)).Format("2006-01-02 15:04:05")}}
+	if res := e.Validate("src", nil, []domain.Rule{zoned}, local); len(res.Errors) != 0 {
+		t.Errorf("expected the local timestamp to be fresh, got %v", res.Errors)
+	}
+	zoned.Checks[0].Timezone = "Mars/Olympus"
+	if res := e.Validate("src", nil, []domain.Rule{zoned}, local); len(res.Errors) != 1 || res.Errors[0].Reason != "unknown timezone Mars/Olympus" {
+		t.Errorf("expected an unknown timezone to fail, got %v", res.Errors)
+	}
+}
+
+func TestExecutor_TimeTypes(t *testing.T) {
+	e := NewExecutor()
+	schema := domain.Schema{
+		"created_at": "timestamp",
+		"paid_at":    "timestamp:epoch_millis",
+		"due":        "date",
+		"shipped":    "date:02.01.2006",
+		"opens":      "time",
+	}
+	valid := domain.Record{
+		"created_at": "2024-05-01T12:00:00Z",
+		"paid_at":    1714564800000.0,
+		"due":        time.Date(2024, 5, 31, 0, 0, 0, 0, time.UTC),
+		"shipped":    "03.05.2024",
+		"opens":      "09:30",
+	}
+	if res := e.Validate("src", schema, nil, []domain.Record{valid}); res.Status != domain.StatusPass {
+		t.Fatalf("expected valid time types to pass, got %v", res.Errors)
+	}
+
+	invalid := []struct {
+		field string
+		value interface{}
+	}{
+		{"created_at", "yesterday"},
+		{"paid_at", "2024-05-01"},
+		{"due", "2024-05-31T10:00:00Z"},
+		{"shipped", "2024-05-03"},
+		{"opens", "9.30"},
+	}
+	for _, tt := range invalid {
+		record := domain.Record{}
+		for k, v := range valid {
+			record[k] = v
+		}
+		record[tt.field] = tt.value
+		res := e.Validate("src", schema, nil, []domain.Record{record})
+		if len(res.Errors) != 1 || res.Errors[0].Field != tt.field || res.Errors[0].Reason != "expected "+schema[tt.field] {
+			t.Errorf("%s = %v: expected a type error, got %v", tt.field, tt.value, res.Errors)
+		}
+	}
+}
+
+func TestExecutor_NumericTypes(t *testing.T) {
+	e := NewExecutor()
+	var price pgtype.Numeric
+	if err := price.Scan("19.90"); err != nil {
+		t.Fatal(err)
+	}
+	schema := domain.Schema{"price": "decimal:4,2", "qty": "integer:1..", "total": "number"}
+	rules := []domain.Rule{
+		{ID: "price_band", Field: "price", Checks: []domain.Check{{Op: "gte", Value: 19.9}, {Op: "eq", Value: 19.9}}},
+		{ID: "qty_kind", Field: "qty", When: &domain.Condition{Field: "total", Op: "gt", Value: 0}, Checks: []domain.Check{{Op: "enum", Value: []interface{}{1.0, 2.0}}}},
+	}
+	// As pgx returns numeric, integer and real columns
+	record := domain.Record{"price": price, "qty": int32(2), "total": float32(39.8)}
+	if res := e.Validate("src", schema, rules, []domain.Record{record}); res.Status != domain.StatusPass {
+		t.Errorf("expected database numbers to pass, got %v", res.Errors)
+	}
+
+	// As read from a CSV file
+	record = domain.Record{"price": "19.90", "qty": "2", "total": "39.8"}
+	res := e.Validate("src", schema, rules, []domain.Record{record})
+	if len(res.Errors) != 2 || res.Errors[0].Reason != "expected integer:1.." || res.Errors[1].Reason != "expected number" {
+		t.Errorf("expected numeric strings to fail number types by default, got %v", res.Errors)
+	}
+	res = e.ValidateContext(context.Background(), "src", schema, rules, []domain.Record{record}, ValidateOptions{NumericStrings: true})
+	if res.Status != domain.StatusPass {
+		t.Errorf("expected numeric strings to pass as numbers, got %v", res.Errors)
+	}
+
+	// Or per check
+	check := []domain.Rule{{ID: "r", Field: "total", Checks: []domain.Check{{Op: "lt", Value: 40, NumericStrings: true}}}}
+	if res := e.Validate("src", nil, check, []domain.Record{record}); res.Status != domain.StatusPass {
+		t.Errorf("expected the check to read numeric strings, got %v", res.Errors)
+	}
+}
+
+func TestExecutor_SchemaReport(t *testing.T) {
+	e := NewExecutor()
+	schema := domain.Schema{
+		"id":           "integer",
+		"email":        "nullable email",
+		"nickname":     "optional string",
+		"amount":       "number",
+		"items[*].sku": "string",
+	}
+	rules := []domain.Rule{
+		{ID: "positive", Field: "amount", Checks: []domain.Check{{Op: "gt", Value: 0}}},
+		{ID: "known_id", Field: "id", Checks: []domain.Check{{Op: "gt", Value: 0}}},
+	}
+	record := domain.Record{
+		"id":     "7",
+		"email":  nil,
+		"amount": -5.0,
+		"items":  []interface{}{map[string]interface{}{"sku": 1.0, "qty": 2.0}},
+		"note":   "extra",
+	}
+
+	// Every field error is reported, rules are skipped
+	res := e.Validate("src", schema, rules, []domain.Record{record})
+	var got []string
+	for _, err := range res.Errors {
+		got = append(got, err.Field+": "+err.Reason)
+	}
+	want := []string{"id: expected integer", "items[0].sku: expected string"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	// Strict mode flags undeclared fields, also inside declared arrays
+	res = e.ValidateContext(context.Background(), "src", schema, rules, []domain.Record{record}, ValidateOptions{StrictSchema: true})
+	got = nil
+	for _, err := range res.Errors[2:] {
+		got = append(got, err.Field+": "+err.Reason)
+	}
+	want = []string{"items[0].qty: unexpected field", "note: unexpected field"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	// Rules on fields that passed the schema still run
+	res = e.ValidateContext(context.Background(), "src", schema, rules, []domain.Record{record}, ValidateOptions{RulesOnValidFields: true})
+	if len(res.Errors) != 3 || res.Errors[2].RuleID != "positive" {
+		t.Errorf("expected the amount rule to run, got %+v", res.Errors)
+	}
+	if s := res.RuleSummaries[1]; s.Evaluated != 0 || s.Skipped != 0 || s.SchemaFailed != 1 {
+		t.Errorf("expected the rule on the failed id to be skipped for the schema, got %+v", s)
+	}
+}
@@ -0,0 +1,67 @@
+package engine
+
+import (
+	"fmt"
+
+	"github.com/singh-anurag-7991/data-guard/internal/domain"
+	"github.com/singh-anurag-7991/data-guard/internal/expr"
+	"github.com/singh-anurag-7991/data-guard/internal/operators"
+)
+
+// ExprOp is the operator evaluating an expression (see internal/expr) against the whole record.
+// The check value holds the expression; "$" in it is the value of the rule's field. Expressions
+// read other fields themselves, so value_field is rejected.
+const ExprOp = "expr"
+
+// compiledExpr is the outcome of compiling one expression
+type compiledExpr struct {
+	prog *expr.Program
+	err  error
+}
+
+// compileExprs compiles every expression used by the rule set, once per run
+func compileExprs(rules []domain.Rule) map[string]compiledExpr {
+	compiled := map[string]compiledExpr{}
+	for _, rule := range rules {
+		for _, check := range rule.Checks {
+			src, ok := check.Value.(string)
+			if check.Op != ExprOp || !ok || check.ValueField != "" {
+				continue
+			}
+			if _, done := compiled[src]; !done {
+				prog, err := expr.Compile(src)
+				compiled[src] = compiledExpr{prog, err}
+			}
+		}
+	}
+	return compiled
+}
+
+// exprOperator adapts the run's compiled expressions to the operator signature, bound to a record
+func (c *collector) exprOperator(record domain.Record) operators.OperatorFunc {
+	return func(value interface{}, check domain.Check) (bool, string) {
+		if check.ValueField != "" {
+			return false, "expr checks take no value_field, the expression reads fields itself"
+		}
+		src, ok := check.Value.(string)
+		if !ok {
+			return false, "expression must be a string"
+		}
+		compiled, ok := c.exprs[src]
+		if !ok {
+			return false, "expression was not compiled"
+		}
+		if compiled.err != nil {
+			return false, fmt.Sprintf("invalid expression: %v", compiled.err)
+		}
+
+		pass, err := compiled.prog.EvalBool(expr.Env{Record: record, Value: value, Now: c.result.Timestamp})
+		if err != nil {
+			return false, fmt.Sprintf("expression error: %v", err)
+		}
+		if !pass {
+			return false, fmt.Sprintf("expression %q is false", src)
+		}
+		return true, ""
+	}
+}
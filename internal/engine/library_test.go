@@ -0,0 +1,133 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/singh-anurag-7991/data-guard/internal/domain"
+	"github.com/singh-anurag-7991/data-guard/internal/engine/optimizer"
+	"github.com/singh-anurag-7991/data-guard/internal/ingest/postgres"
+)
+
+// libraryCases are shared by the memory and SQL tests of the built-in and standard operators:
+// values of a column of the given SQL type, as pgx returns them, and whether each passes the check
+var libraryCases = []struct {
+	name   string
+	check  domain.Check
+	column string
+	values []interface{}
+	pass   []bool
+}{
+	{"eq", domain.Check{Op: "eq", Value: "a"}, "text", []interface{}{"a", "b", nil}, []bool{true, false, false}},
+	{"neq", domain.Check{Op: "neq", Value: "a"}, "text", []interface{}{"a", "b", nil}, []bool{false, true, true}},
+	{"gt", domain.Check{Op: "gt", Value: 0.0}, "bigint", []interface{}{int64(1), int64(0), nil}, []bool{true, false, false}},
+	{"lt", domain.Check{Op: "lt", Value: 0.0}, "double precision", []interface{}{-0.5, 0.0, nil}, []bool{true, false, false}},
+	{"gte", domain.Check{Op: "gte", Value: 5.0}, "double precision", []interface{}{4.5, 5.0, 6.0, nil}, []bool{false, true, true, false}},
+	{"lte", domain.Check{Op: "lte", Value: 5.0}, "bigint", []interface{}{int64(4), int64(5), int64(6), nil}, []bool{true, true, false, false}},
+	{"between", domain.Check{Op: domain.OpBetween, Min: bound(1), Max: bound(5)}, "double precision",
+		[]interface{}{0.5, 1.0, 5.0, 5.5, nil}, []bool{false, true, true, false, false}},
+	{"between_min", domain.Check{Op: domain.OpBetween, Min: bound(0)}, "bigint", []interface{}{int64(-1), int64(0), int64(7)}, []bool{false, true, true}},
+	{"between_text", domain.Check{Op: domain.OpBetween, Min: bound(1)}, "text", []interface{}{"3"}, []bool{false}},
+	{"not_in", domain.Check{Op: domain.OpNotIn, Value: []interface{}{"a", "b"}}, "text", []interface{}{"a", "c", "", nil}, []bool{false, true, true, true}},
+	{"not_in_numbers", domain.Check{Op: domain.OpNotIn, Value: []interface{}{1.0, 2.5}}, "double precision", []interface{}{2.5, 3.0, nil}, []bool{false, true, true}},
+	{"not_in_mixed", domain.Check{Op: domain.OpNotIn, Value: []interface{}{1.0, "2"}}, "bigint", []interface{}{int64(1), int64(2)}, []bool{false, true}},
+	{"not_in_booleans", domain.Check{Op: domain.OpNotIn, Value: []interface{}{false}}, "boolean", []interface{}{true, false}, []bool{true, false}},
+	{"min_length", domain.Check{Op: domain.OpMinLength, Value: 3.0}, "text", []interface{}{"ab", "abc", "äöü", "", nil}, []bool{false, true, true, false, false}},
+	{"max_length", domain.Check{Op: domain.OpMaxLength, Value: 2.0}, "text", []interface{}{"ab", "abc", "äö", nil}, []bool{true, false, true, false}},
+	{"contains", domain.Check{Op: domain.OpContains, Value: "@"}, "text", []interface{}{"a@b", "ab", nil}, []bool{true, false, false}},
+	{"starts_with", domain.Check{Op: domain.OpStartsWith, Value: "ab"}, "text", []interface{}{"abc", "cab", "a"}, []bool{true, false, false}},
+	{"starts_with_wildcard", domain.Check{Op: domain.OpStartsWith, Value: "%_"}, "text", []interface{}{"%_x", "ax"}, []bool{true, false}},
+	{"ends_with", domain.Check{Op: domain.OpEndsWith, Value: "ab"}, "text", []interface{}{"cab", "abc", "b", nil}, []bool{true, false, false, false}},
+	{"is_empty", domain.Check{Op: domain.OpIsEmpty}, "text", []interface{}{"", " ", "x", nil}, []bool{true, false, false, true}},
+	{"is_null", domain.Check{Op: domain.OpIsNull}, "bigint", []interface{}{nil, int64(0)}, []bool{true, false}},
+	{"multiple_of", domain.Check{Op: domain.OpMultipleOf, Value: 0.1}, "double precision", []interface{}{0.3, 0.35, 1.0, nil}, []bool{true, false, true, false}},
+	{"multiple_of_integer", domain.Check{Op: domain.OpMultipleOf, Value: 5.0}, "bigint", []interface{}{int64(10), int64(12), int64(0)}, []bool{true, false, true}},
+	{"precision", domain.Check{Op: domain.OpPrecision, Value: 2.0}, "double precision", []interface{}{1.25, 1.255, 3.0, 1e-7}, []bool{true, false, true, false}},
+	{"is_integer", domain.Check{Op: domain.OpIsInteger}, "double precision", []interface{}{2.0, 2.5, -3.0, nil}, []bool{true, false, true, false}},
+	{"is_integer_text", domain.Check{Op: domain.OpIsInteger}, "text", []interface{}{"2"}, []bool{false}},
+	{"between_integer", domain.Check{Op: domain.OpBetween, Max: bound(5)}, "integer", []interface{}{int32(5), int32(6)}, []bool{true, false}},
+	{"multiple_of_real", domain.Check{Op: domain.OpMultipleOf, Value: 0.1}, "real", []interface{}{float32(0.3), float32(0.35)}, []bool{true, false}},
+	{"multiple_of_numeric", domain.Check{Op: domain.OpMultipleOf, Value: 0.1}, "numeric", []interface{}{decimalValue("0.30"), decimalValue("0.301")}, []bool{true, false}},
+	{"precision_numeric", domain.Check{Op: domain.OpPrecision, Value: 2.0}, "numeric", []interface{}{decimalValue("1.250"), decimalValue("1.255")}, []bool{true, false}},
+	{"not_in_numeric", domain.Check{Op: domain.OpNotIn, Value: []interface{}{0.1}}, "numeric", []interface{}{decimalValue("0.10"), decimalValue("0.2")}, []bool{false, true}},
+}
+
+func bound(v float64) *float64 { return &v }
+
+// decimalValue is a numeric column value as pgx returns it
+func decimalValue(s string) pgtype.Numeric {
+	var n pgtype.Numeric
+	if err := n.Scan(s); err != nil {
+		panic(err)
+	}
+	return n
+}
+
+func TestExecutor_LibraryOperators(t *testing.T) {
+	e := NewExecutor()
+	for _, tt := range libraryCases {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := []domain.Rule{{ID: "r", Field: "v", Checks: []domain.Check{tt.check}}}
+			for i, val := range tt.values {
+				res := e.Validate("src", nil, rules, []domain.Record{{"v": val}})
+				if pass := len(res.Errors) == 0; pass != tt.pass[i] {
+					t.Errorf("%s(%#v): expected pass %v, got errors %v", tt.check.Op, val, tt.pass[i], res.Errors)
+				}
+			}
+		})
+	}
+}
+
+// TestLibraryOperators_SQLParity runs the failure query of every case against Postgres, which
+// must fetch exactly the values failing in memory
+func TestLibraryOperators_SQLParity(t *testing.T) {
+	connStr := os.Getenv("TEST_DB_URL")
+	if connStr == "" {
+		t.Skip("Skipping postgres integration test: TEST_DB_URL not set")
+	}
+
+	ctx := context.Background()
+	client, err := postgres.NewClient(ctx, connStr)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer client.Close()
+
+	for i, tt := range libraryCases {
+		t.Run(tt.name, func(t *testing.T) {
+			table := fmt.Sprintf("dataguard_parity_%d", i)
+			if _, err := client.Pool().Exec(ctx, fmt.Sprintf("CREATE TABLE %s (id bigint PRIMARY KEY, v %s)", table, tt.column)); err != nil {
+				t.Fatalf("failed to create %s: %v", table, err)
+			}
+			defer client.Pool().Exec(ctx, "DROP TABLE "+table)
+			for id, val := range tt.values {
+				if _, err := client.Pool().Exec(ctx, fmt.Sprintf("INSERT INTO %s VALUES ($1, $2)", table), id, val); err != nil {
+					t.Fatalf("failed to insert %#v: %v", val, err)
+				}
+			}
+
+			rule := domain.Rule{ID: "r", Field: "v", Checks: []domain.Check{tt.check}}
+			if plan := optimizer.Plan([]domain.Rule{rule}, nil); len(plan.SQLRules) != 1 {
+				t.Fatalf("expected %s to be pushed down", tt.check.Op)
+			}
+			query, args := optimizer.BuildRuleFailureQuery(table, []string{"id"}, rule, nil)
+			rows, err := client.FetchRows(ctx, query, args...)
+			if err != nil {
+				t.Fatalf("query %s failed: %v", query, err)
+			}
+
+			fetched := map[int64]bool{}
+			for _, row := range rows {
+				fetched[row["id"].(int64)] = true
+			}
+			for id, val := range tt.values {
+				if fetched[int64(id)] == tt.pass[id] {
+					t.Errorf("%s(%#v): expected fetched %v with %s", tt.check.Op, val, !tt.pass[id], query)
+				}
+			}
+		})
+	}
+}
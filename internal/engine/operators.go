@@ -0,0 +1,66 @@
+package engine
+
+import (
+	"sort"
+
+	"github.com/singh-anurag-7991/data-guard/internal/domain"
+	"github.com/singh-anurag-7991/data-guard/internal/engine/optimizer"
+	"github.com/singh-anurag-7991/data-guard/internal/operators"
+)
+
+// Scopes of operators: record operators check one value at a time, dataset operators the batch
+const (
+	ScopeRecord  = "record"
+	ScopeDataset = "dataset"
+)
+
+// OperatorInfo describes an operator for rule editors
+type OperatorInfo struct {
+	operators.Operator
+	Scope    string `json:"scope"`
+	Pushdown bool   `json:"pushdown"` // checks can run as SQL against table sources
+}
+
+var (
+	boundArgs = []operators.Arg{
+		{Name: "min", Type: "number", Description: "inclusive lower bound"},
+		{Name: "max", Type: "number", Description: "inclusive upper bound"},
+	}
+	keyArg = operators.Arg{Name: "fields", Type: "array", Description: "fields of a composite key, the rule's field by default"}
+	refArg = operators.Arg{Name: "ref", Type: "object", Description: `{"table", "column"}, {"lookup"} or {"source", "column"}`}
+)
+
+// checkOperators are the operators the executor runs itself rather than through the registry
+var checkOperators = []OperatorInfo{
+	{Operator: operators.Operator{Name: ExprOp, Description: `the expression value holds is true, "$" being the field's value`, Args: []operators.Arg{{Name: "value", Type: "string", Required: true}}}, Scope: ScopeRecord, Pushdown: true},
+	{Operator: operators.Operator{Name: domain.OpExistsIn, Description: "the value exists in the reference set, null values pass", Args: []operators.Arg{{Name: "ref", Type: "object", Required: true, Description: refArg.Description}}, NullSafe: true}, Scope: ScopeRecord, Pushdown: true},
+
+	{Operator: operators.Operator{Name: domain.OpUnique, Description: "no two records share the key", Args: []operators.Arg{keyArg}}, Scope: ScopeDataset, Pushdown: true},
+	{Operator: operators.Operator{Name: domain.OpMaxDuplicates, Description: "at most value records repeat a key seen before", Args: []operators.Arg{{Name: "value", Type: "number", Required: true}, keyArg}}, Scope: ScopeDataset, Pushdown: true},
+
+	{Operator: operators.Operator{Name: domain.OpRowCount, Description: "the number of records is within bounds", Args: boundArgs}, Scope: ScopeDataset, Pushdown: true},
+	{Operator: operators.Operator{Name: domain.OpSum, Description: "the sum of the numbers is within bounds", Args: boundArgs}, Scope: ScopeDataset, Pushdown: true},
+	{Operator: operators.Operator{Name: domain.OpAvg, Description: "the mean of the numbers is within bounds", Args: boundArgs}, Scope: ScopeDataset, Pushdown: true},
+	{Operator: operators.Operator{Name: domain.OpMin, Description: "the smallest number is within bounds", Args: boundArgs}, Scope: ScopeDataset, Pushdown: true},
+	{Operator: operators.Operator{Name: domain.OpMax, Description: "the largest number is within bounds", Args: boundArgs}, Scope: ScopeDataset, Pushdown: true},
+	{Operator: operators.Operator{Name: domain.OpNullRatio, Description: "the fraction of null or missing values is within bounds", Args: boundArgs}, Scope: ScopeDataset, Pushdown: true},
+	{Operator: operators.Operator{Name: domain.OpDistinctCount, Description: "the number of distinct values is within bounds", Args: boundArgs}, Scope: ScopeDataset, Pushdown: true},
+	{Operator: operators.Operator{Name: domain.OpMaxAge, Description: "the latest timestamp is at most value older than the run", Args: []operators.Arg{{Name: "value", Type: "duration", Required: true}}}, Scope: ScopeDataset, Pushdown: true},
+
+	{Operator: operators.Operator{Name: domain.OpProfileChange, Description: "the relative change of a profile statistic since the last profiled run is within bounds", Args: append([]operators.Arg{{Name: "stat", Type: "string", Required: true}}, boundArgs...)}, Scope: ScopeDataset},
+
+	{Operator: operators.Operator{Name: domain.OpPSI, Description: "the population stability index against the baseline is within bounds (at most 0.25 by default)", Args: append([]operators.Arg{refArg}, boundArgs...)}, Scope: ScopeDataset},
+	{Operator: operators.Operator{Name: domain.OpKS, Description: "the Kolmogorov-Smirnov statistic of the numbers against the baseline is within bounds (at most 0.1 by default)", Args: append([]operators.Arg{refArg}, boundArgs...)}, Scope: ScopeDataset},
+	{Operator: operators.Operator{Name: domain.OpChiSquare, Description: "the chi-square p-value of the value frequencies against the baseline is within bounds (at least 0.01 by default)", Args: append([]operators.Arg{refArg}, boundArgs...)}, Scope: ScopeDataset},
+	{Operator: operators.Operator{Name: domain.OpShareDelta, Description: "the largest change in the share of one value against the baseline is within bounds (at most 0.1 by default)", Args: append([]operators.Arg{refArg}, boundArgs...)}, Scope: ScopeDataset},
+}
+
+// Operators lists every operator rules can use, the registered ones included, sorted by name
+func (e *Executor) Operators() []OperatorInfo {
+	list := append([]OperatorInfo{}, checkOperators...)
+	for _, op := range e.ops.List() {
+		list = append(list, OperatorInfo{Operator: op, Scope: ScopeRecord, Pushdown: optimizer.IsPushdownOp(op.Name, e.ops)})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
@@ -0,0 +1,85 @@
+package optimizer
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/singh-anurag-7991/data-guard/internal/domain"
+	"github.com/singh-anurag-7991/data-guard/internal/expr"
+	"github.com/singh-anurag-7991/data-guard/internal/fieldpath"
+	"github.com/singh-anurag-7991/data-guard/internal/schema"
+)
+
+// ErrInvalidIdentifier is returned for table, column and field names that cannot be put in SQL
+var ErrInvalidIdentifier = errors.New("invalid identifier")
+
+// CheckTable checks every name the queries of a table validation paste into SQL: the table,
+// its key fields, the schema fields, the fields of the rules and the tables they reference.
+// Field paths are checked by their top-level key, the column; the keys below it are quoted
+// as JSONB keys.
+func CheckTable(table string, keyFields []string, s domain.Schema, rules []domain.Rule) error {
+	if !schema.IsIdentifier(table) {
+		return fmt.Errorf("%w: table %q", ErrInvalidIdentifier, table)
+	}
+	for _, field := range keyFields {
+		if !schema.IsIdentifier(field) {
+			return fmt.Errorf("%w: key field %q", ErrInvalidIdentifier, field)
+		}
+	}
+	for field := range s {
+		if err := checkField(field); err != nil {
+			return fmt.Errorf("schema: %w", err)
+		}
+	}
+
+	for _, rule := range rules {
+		fields := []string{rule.Field}
+		if rule.When != nil {
+			fields = append(fields, rule.When.Fields()...)
+		}
+		for _, check := range rule.Checks {
+			if check.Ref != nil {
+				if err := CheckReference(*check.Ref); err != nil {
+					return fmt.Errorf("rule %s: %w", rule.ID, err)
+				}
+			}
+			fields = append(fields, check.ValueField)
+			fields = append(fields, check.Fields...)
+			if src, ok := check.Value.(string); ok && check.Op == "expr" {
+				if prog, err := expr.Compile(src); err == nil {
+					fields = append(fields, prog.Fields()...)
+				}
+			}
+		}
+		for _, field := range fields {
+			if err := checkField(field); err != nil {
+				return fmt.Errorf("rule %s: %w", rule.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// CheckReference checks the table and column of a table reference, which the reference queries
+// paste into SQL. Lookup and source references are not queried with SQL.
+func CheckReference(ref domain.Reference) error {
+	if ref.Table == "" {
+		return nil
+	}
+	if !schema.IsIdentifier(ref.Table) || !schema.IsIdentifier(ref.Column) {
+		return fmt.Errorf("%w: reference %s", ErrInvalidIdentifier, ref)
+	}
+	return nil
+}
+
+// checkField checks the column of a field path; "" (no field) and "$" (the rule's field) pass
+func checkField(field string) error {
+	if field == "" || field == "$" {
+		return nil
+	}
+	p, err := fieldpath.Parse(field)
+	if err != nil || !schema.IsIdentifier(p[0].Key) {
+		return fmt.Errorf("%w: field %q", ErrInvalidIdentifier, field)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package optimizer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/singh-anurag-7991/data-guard/internal/domain"
+)
+
+func TestCheckTable(t *testing.T) {
+	rule := func(field string, check domain.Check) []domain.Rule {
+		return []domain.Rule{{ID: "r", Field: field, Checks: []domain.Check{check}}}
+	}
+	tests := []struct {
+		name      string
+		table     string
+		keyFields []string
+		schema    domain.Schema
+		rules     []domain.Rule
+		valid     bool
+	}{
+		{"valid", "sales.orders", []string{"id"}, domain.Schema{"payload.qty": "integer"},
+			rule("items[*].sku", domain.Check{Op: "expr", Value: "$ != '' && qty > 0"}), true},
+		{"table", "orders; DROP TABLE users", nil, nil, nil, false},
+		{"key_field", "orders", []string{"id, (SELECT 1)"}, nil, nil, false},
+		{"schema_field", "orders", nil, domain.Schema{"a b": "string"}, nil, false},
+		{"field", "orders", nil, nil, rule("name; DROP TABLE users; --", domain.Check{Op: "not_null"}), false},
+		{"value_field", "orders", nil, nil, rule("a", domain.Check{Op: "eq", ValueField: "b--"}), false},
+		{"dataset_fields", "orders", nil, nil, rule("a", domain.Check{Op: domain.OpUnique, Fields: []string{"a", "b)"}}), false},
+		{"when", "orders", nil, nil, []domain.Rule{{ID: "r", Field: "a", When: &domain.Condition{Field: "1=1 OR b", Op: "eq", Value: 1}}}, false},
+		{"reference", "orders", nil, nil, rule("a", domain.Check{Op: domain.OpExistsIn, Ref: &domain.Reference{Table: "customers", Column: "id) OR (1=1"}}), false},
+		{"lookup_reference", "orders", nil, nil, rule("a", domain.Check{Op: domain.OpExistsIn, Ref: &domain.Reference{Lookup: "any name"}}), true},
+		{"expr_field", "orders", nil, nil, rule("a", domain.Check{Op: "expr", Value: "items[x] > 0"}), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckTable(tt.table, tt.keyFields, tt.schema, tt.rules)
+			if valid := err == nil; valid != tt.valid {
+				t.Errorf("expected valid %v, got %v", tt.valid, err)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidIdentifier) {
+				t.Errorf("expected ErrInvalidIdentifier, got %v", err)
+			}
+		})
+	}
+}
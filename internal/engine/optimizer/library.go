@@ -0,0 +1,177 @@
+package optimizer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/singh-anurag-7991/data-guard/internal/domain"
+	"github.com/singh-anurag-7991/data-guard/internal/fieldpath"
+	"github.com/singh-anurag-7991/data-guard/internal/operators"
+)
+
+// maxScale is the most decimal places of a Postgres numeric
+const maxScale = 16383
+
+// isLibraryOp reports whether op is one of the string and numeric operators translated by
+// libraryCheckToSQL
+func isLibraryOp(op string) bool {
+	switch op {
+	case domain.OpBetween, domain.OpNotIn, domain.OpMinLength, domain.OpMaxLength,
+		domain.OpContains, domain.OpStartsWith, domain.OpEndsWith, domain.OpIsEmpty,
+		domain.OpIsNull, domain.OpMultipleOf, domain.OpPrecision, domain.OpIsInteger:
+		return true
+	}
+	return false
+}
+
+// libraryCheckToSQL returns a condition holding exactly for the values of field that pass the
+// check in memory, or with invert that fail it, never NULL: null values pass not_in, is_empty and
+// is_null and fail the others.
+// Like the schema translation it guards on the column type, so a string never passes a numeric
+// check. Only plain columns are translated, since JSONB text hides whether a value was a string
+// or a number. Arguments are only bound on success.
+func libraryCheckToSQL(field string, check domain.Check, invert bool, args *[]interface{}) (string, bool) {
+	if !isLibraryOp(check.Op) || check.ValueField != "" {
+		return "", false
+	}
+	if p, err := fieldpath.Parse(field); err != nil || p.IsNested() {
+		return "", false
+	}
+
+	typeIn := func(types string) string {
+		return fmt.Sprintf("pg_typeof(%s)::text IN (%s)", field, types)
+	}
+	text := fmt.Sprintf("(%s)::text", field)
+	number := fmt.Sprintf("(%s)::text::numeric", field)
+	// holds fails null values and values of other types
+	holds := func(types, cond string) string {
+		return fmt.Sprintf("COALESCE(CASE WHEN %s THEN %s ELSE FALSE END, FALSE)", typeIn(types), cond)
+	}
+	var bound []interface{}
+	bind := func(val interface{}) string {
+		bound = append(bound, val)
+		return fmt.Sprintf("$%d", len(*args)+len(bound))
+	}
+
+	var cond, inverse string // inverse defaults to NOT cond
+	switch check.Op {
+	case domain.OpIsNull:
+		cond, inverse = fmt.Sprintf("%s IS NULL", field), fmt.Sprintf("%s IS NOT NULL", field)
+	case domain.OpIsEmpty:
+		cond = fmt.Sprintf("(%s IS NULL OR %s)", field, holds(textTypes, text+" = ''"))
+	case domain.OpNotIn:
+		in, ok := excludedToSQL(field, check.Value, typeIn, bind)
+		if !ok {
+			return "", false
+		}
+		inverse = fmt.Sprintf("COALESCE(%s, FALSE)", in)
+		cond = "NOT " + inverse
+	case domain.OpBetween:
+		var bounds []string
+		if check.Min != nil {
+			bounds = append(bounds, fmt.Sprintf("%s >= %v", number, *check.Min))
+		}
+		if check.Max != nil {
+			bounds = append(bounds, fmt.Sprintf("%s <= %v", number, *check.Max))
+		}
+		if len(bounds) == 0 {
+			return "", false
+		}
+		cond = holds(numericTypes, strings.Join(bounds, " AND "))
+	case domain.OpIsInteger:
+		cond = holds(numericTypes, fmt.Sprintf("%s = trunc(%s)", number, number))
+	case domain.OpMultipleOf:
+		m, ok := operators.FormatDecimal(check.Value)
+		if c, _ := operators.Compare(check.Value, 0, false); !ok || c <= 0 {
+			return "", false
+		}
+		cond = holds(numericTypes, fmt.Sprintf("mod(%s, %s) = 0", number, m))
+	case domain.OpPrecision:
+		places, ok := operators.ToFloat(check.Value)
+		if !ok || places < 0 || places > maxScale {
+			return "", false
+		}
+		// Unlike scale, rounding ignores trailing zeros of numeric columns (1.250), as in memory
+		cond = holds(numericTypes, fmt.Sprintf("%s = round(%s, %d)", number, number, int(places)))
+	case domain.OpMinLength, domain.OpMaxLength:
+		limit, ok := operators.ToFloat(check.Value)
+		if !ok {
+			return "", false
+		}
+		op := ">="
+		if check.Op == domain.OpMaxLength {
+			op = "<="
+		}
+		cond = holds(textTypes, fmt.Sprintf("char_length(%s) %s %v", text, op, limit))
+	default: // contains, starts_with, ends_with
+		sub, ok := check.Value.(string)
+		if !ok {
+			return "", false
+		}
+		placeholder := bind(sub) + "::text"
+		switch check.Op {
+		case domain.OpContains:
+			cond = holds(textTypes, fmt.Sprintf("strpos(%s, %s) > 0", text, placeholder))
+		case domain.OpStartsWith:
+			cond = holds(textTypes, fmt.Sprintf("starts_with(%s, %s)", text, placeholder))
+		default:
+			cond = holds(textTypes, fmt.Sprintf("right(%s, char_length(%s)) = %s", text, placeholder, placeholder))
+		}
+	}
+
+	if invert {
+		if inverse == "" {
+			inverse = "NOT " + cond
+		}
+		cond = inverse
+	}
+	*args = append(*args, bound...)
+	return cond, true
+}
+
+// excludedToSQL translates membership in the value list of a not_in check, comparing numbers
+// by value and strings and booleans with values of their own type, as in memory. Values that
+// never equal a column value (null, arrays) are left out; false for lists that are not arrays.
+func excludedToSQL(field string, value interface{}, typeIn func(string) string, bind func(interface{}) string) (string, bool) {
+	var list []interface{}
+	switch v := value.(type) {
+	case []interface{}:
+		list = v
+	case []string:
+		for _, s := range v {
+			list = append(list, s)
+		}
+	default:
+		return "", false
+	}
+
+	var numbers, strs []string
+	var bools []bool
+	for _, item := range list {
+		if d, ok := operators.FormatDecimal(item); ok {
+			numbers = append(numbers, d)
+			continue
+		}
+		switch v := item.(type) {
+		case string:
+			strs = append(strs, v)
+		case bool:
+			bools = append(bools, v)
+		}
+	}
+
+	var branches []string
+	if len(numbers) > 0 {
+		branches = append(branches, fmt.Sprintf("WHEN %s THEN (%s)::text::numeric = ANY(%s::text[]::numeric[])", typeIn(numericTypes), field, bind(numbers)))
+	}
+	if len(strs) > 0 {
+		branches = append(branches, fmt.Sprintf("WHEN %s THEN (%s)::text = ANY(%s::text[])", typeIn(textTypes), field, bind(strs)))
+	}
+	if len(bools) > 0 {
+		branches = append(branches, fmt.Sprintf("WHEN %s THEN (%s)::text::boolean = ANY(%s::boolean[])", typeIn("'boolean'"), field, bind(bools)))
+	}
+	if len(branches) == 0 {
+		return "FALSE", true // Nothing is excluded
+	}
+	return fmt.Sprintf("CASE %s ELSE FALSE END", strings.Join(branches, " ")), true
+}
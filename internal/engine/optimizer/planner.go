@@ -1,6 +1,11 @@
 package optimizer
 
-import "github.com/singh-anurag-7991/data-guard/internal/domain"
+import (
+	"github.com/singh-anurag-7991/data-guard/internal/domain"
+	"github.com/singh-anurag-7991/data-guard/internal/fieldpath"
+	"github.com/singh-anurag-7991/data-guard/internal/operators"
+	"github.com/singh-anurag-7991/data-guard/internal/schema"
+)
 
 // ExecutionPlan determines how rules should be executed
 type ExecutionPlan struct {
@@ -8,15 +13,16 @@ type ExecutionPlan struct {
 	MemoryRules []domain.Rule // Must be checked in Go
 }
 
-// Plan separates rules into execution buckets
-func Plan(rules []domain.Rule) ExecutionPlan {
+// Plan separates rules into execution buckets. Checks of operators in ops with a SQL
+// translation are pushed down too; ops may be nil.
+func Plan(rules []domain.Rule, ops *operators.Registry) ExecutionPlan {
 	plan := ExecutionPlan{
 		SQLRules:    []domain.Rule{},
 		MemoryRules: []domain.Rule{},
 	}
 
 	for _, rule := range rules {
-		if isSQLPushdownSafe(rule) {
+		if isSQLPushdownSafe(rule, ops) {
 			plan.SQLRules = append(plan.SQLRules, rule)
 		} else {
 			plan.MemoryRules = append(plan.MemoryRules, rule)
@@ -27,17 +33,76 @@ func Plan(rules []domain.Rule) ExecutionPlan {
 }
 
 // isSQLPushdownSafe is the decision logic for the optimizer
-func isSQLPushdownSafe(rule domain.Rule) bool {
+func isSQLPushdownSafe(rule domain.Rule, ops *operators.Registry) bool {
+	// 0. Wildcard paths ("items[*].price") need per-element semantics, only the executor has them
+	if fieldpath.HasWildcard(rule.Field) {
+		return false
+	}
+
 	// 1. If there's a "When" condition, we only support basic SQL operators
 	if rule.When != nil {
-		if !isOpSafe(rule.When.Op) {
+		if !isConditionSafe(*rule.When) {
 			return false
 		}
 	}
 
 	// 2. Check all check operators
 	for _, check := range rule.Checks {
-		if !isOpSafe(check.Op) {
+		if check.NumericStrings {
+			return false // SQL compares text columns as text
+		}
+		if check.IsDataset() {
+			// Duplicates are found with GROUP BY and aggregates with aggregate SQL;
+			// rules mixing them with record checks stay in memory
+			if !rule.IsDataset() || check.Op == domain.OpProfileChange || check.IsShift() {
+				return false // Earlier profiles and baselines are compared in memory
+			}
+			if check.Op == domain.OpMaxAge && (check.Format != "" || check.Timezone != "") {
+				return false // Formats and timezones are applied in Go
+			}
+			for _, field := range check.KeyFields(rule.Field) {
+				if fieldpath.HasWildcard(field) {
+					return false
+				}
+			}
+			continue
+		}
+		if check.Op == domain.OpExistsIn {
+			// Tables become an anti-join; lookup lists and other sources only exist outside the database
+			if check.Ref == nil || check.Ref.Table == "" || check.Ref.Column == "" {
+				return false
+			}
+			continue
+		}
+		if check.Op == "expr" {
+			// Expressions are safe when every function and field they use has a SQL form
+			if _, ok := exprToSQL(rule.Field, check, new([]interface{})); !ok {
+				return false
+			}
+			continue
+		}
+		if isDateOp(check.Op) {
+			// Comparing to another field or reading custom layouts stays in memory
+			if cond, _ := invertDateCheckToSQL(rule.Field, check); cond == "" || check.ValueField != "" {
+				return false
+			}
+			continue
+		}
+		if op, ok := ops.Get(check.Op); ok && op.SQL != nil {
+			// Registered operators translate themselves, from a value of the check only
+			if cond, _ := op.SQL(columnExpr(rule.Field, check), check); cond == "" || check.ValueField != "" {
+				return false
+			}
+			continue
+		}
+		if isLibraryOp(check.Op) {
+			// Plain columns translate, given the arguments the operator needs
+			if _, ok := libraryCheckToSQL(rule.Field, check, true, new([]interface{})); !ok {
+				return false
+			}
+			continue
+		}
+		if !isCheckSafe(check.Op, check.ValueField) {
 			return false
 		}
 	}
@@ -45,6 +110,68 @@ func isSQLPushdownSafe(rule domain.Rule) bool {
 	return true
 }
 
+// IsSchemaPushdownSafe reports whether the schema can be checked with BuildSchemaFailureQuery.
+// Nested fields are not, JSONB text hides whether a value was a string or a number;
+// neither are malformed types, whose errors are reported by the executor.
+func IsSchemaPushdownSafe(s domain.Schema) bool {
+	for field, spec := range s {
+		if p, err := fieldpath.Parse(field); err != nil || p.IsNested() {
+			return false
+		}
+		if _, err := schema.Parse(spec); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// isConditionSafe checks every leaf of a condition tree
+func isConditionSafe(cond domain.Condition) bool {
+	if cond.IsLeaf() {
+		if fieldpath.HasWildcard(cond.Field) {
+			return false
+		}
+		check := domain.Check{Op: cond.Op, Value: cond.Value, ValueField: cond.ValueField}
+		if _, ok := libraryCheckToSQL(cond.Field, check, false, new([]interface{})); !ok && !isCheckSafe(cond.Op, cond.ValueField) {
+			return false
+		}
+	}
+	for _, sub := range cond.All {
+		if !isConditionSafe(sub) {
+			return false
+		}
+	}
+	for _, sub := range cond.Any {
+		if !isConditionSafe(sub) {
+			return false
+		}
+	}
+	if cond.Not != nil && !isConditionSafe(*cond.Not) {
+		return false
+	}
+	return true
+}
+
+// isCheckSafe also accepts cross-field comparisons, which become column-to-column SQL
+func isCheckSafe(op, valueField string) bool {
+	if valueField == "" {
+		return isOpSafe(op)
+	}
+	if _, ok := comparisonOps[op]; !ok {
+		return false
+	}
+	return !fieldpath.HasWildcard(valueField)
+}
+
+// IsPushdownOp reports whether checks of a record operator can be translated to SQL, ops
+// holding the registered operators
+func IsPushdownOp(op string, ops *operators.Registry) bool {
+	if o, ok := ops.Get(op); ok && o.SQL != nil {
+		return true
+	}
+	return isOpSafe(op) || isDateOp(op) || isLibraryOp(op)
+}
+
 func isOpSafe(op string) bool {
 	switch op {
 	case "not_null", "eq", "neq", "gt", "lt", "gte", "lte":
@@ -36,7 +36,7 @@ func TestPlan(t *testing.T) {
 		},
 	}
 
-	plan := Plan(rules)
+	plan := Plan(rules, nil)
 
 	if len(plan.SQLRules) != 2 {
 		t.Errorf("expected 2 SQL rules, got %d", len(plan.SQLRules))
@@ -48,3 +48,153 @@ func TestPlan(t *testing.T) {
 		t.Errorf("expected 'memory_only' to be in memory rules")
 	}
 }
+
+func TestPlan_WildcardPathsStayInMemory(t *testing.T) {
+	rules := []domain.Rule{
+		{ID: "nested", Field: "payload.customer.zip", Checks: []domain.Check{{Op: "not_null"}}},
+		{ID: "wildcard", Field: "items[*].price", Checks: []domain.Check{{Op: "gt", Value: 0}}},
+		{
+			ID:     "wildcard_when",
+			Field:  "total",
+			When:   &domain.Condition{Field: "items[*].type", Op: "eq", Value: "digital"},
+			Checks: []domain.Check{{Op: "gt", Value: 0}},
+		},
+	}
+
+	plan := Plan(rules, nil)
+	if len(plan.SQLRules) != 1 || plan.SQLRules[0].ID != "nested" {
+		t.Errorf("expected only 'nested' to be pushed down, got %+v", plan.SQLRules)
+	}
+	if len(plan.MemoryRules) != 2 {
+		t.Errorf("expected 2 memory rules, got %d", len(plan.MemoryRules))
+	}
+}
+
+func TestPlan_CompoundConditions(t *testing.T) {
+	safe := domain.Rule{
+		ID:    "safe",
+		Field: "tax",
+		When: &domain.Condition{Any: []domain.Condition{
+			{Field: "country", Op: "eq", Value: "US"},
+			{Not: &domain.Condition{Field: "channel", Op: "eq", Value: "wholesale"}},
+		}},
+		Checks: []domain.Check{{Op: "gt", Value: 0}},
+	}
+	unsafe := safe
+	unsafe.ID = "unsafe"
+	unsafe.When = &domain.Condition{All: []domain.Condition{
+		{Field: "country", Op: "eq", Value: "US"},
+		{Not: &domain.Condition{Field: "email", Op: "regex", Value: "@corp$"}},
+	}}
+
+	plan := Plan([]domain.Rule{safe, unsafe}, nil)
+	if len(plan.SQLRules) != 1 || plan.SQLRules[0].ID != "safe" {
+		t.Errorf("expected only 'safe' to be pushed down, got %+v", plan.SQLRules)
+	}
+}
+
+func TestPlan_CrossFieldChecks(t *testing.T) {
+	rules := []domain.Rule{
+		{ID: "column_compare", Field: "end", Checks: []domain.Check{{Op: "gt", ValueField: "start"}}},
+		{ID: "enum_against_field", Field: "status", Checks: []domain.Check{{Op: "enum", ValueField: "allowed"}}},
+		{ID: "wildcard_operand", Field: "total", Checks: []domain.Check{{Op: "gte", ValueField: "items[*].price"}}},
+	}
+
+	plan := Plan(rules, nil)
+	if len(plan.SQLRules) != 1 || plan.SQLRules[0].ID != "column_compare" {
+		t.Errorf("expected only 'column_compare' to be pushed down, got %+v", plan.SQLRules)
+	}
+}
+
+func TestPlan_Expressions(t *testing.T) {
+	rules := []domain.Rule{
+		{ID: "flat", Field: "line_total", Checks: []domain.Check{{Op: "expr", Value: "quantity * unit_price == $ ± 0.01"}}},
+		{ID: "nested", Field: "total", Checks: []domain.Check{{Op: "expr", Value: "$ >= customer.credit"}}},
+		{ID: "invalid", Field: "total", Checks: []domain.Check{{Op: "expr", Value: "$ >"}}},
+		{ID: "value_field", Field: "total", Checks: []domain.Check{{Op: "expr", Value: "$ > 0", ValueField: "rule"}}},
+	}
+
+	plan := Plan(rules, nil)
+	if len(plan.SQLRules) != 1 || plan.SQLRules[0].ID != "flat" {
+		t.Errorf("expected only 'flat' to be pushed down, got %+v", plan.SQLRules)
+	}
+}
+
+func TestPlan_DateChecks(t *testing.T) {
+	rules := []domain.Rule{
+		{ID: "past", Field: "created_at", Checks: []domain.Check{{Op: "not_future"}, {Op: "after", Value: "2020-01-01"}}},
+		{ID: "epoch", Field: "paid_at", Checks: []domain.Check{{Op: "within_days", Value: 30, Format: "epoch_millis"}}},
+		{ID: "layout", Field: "shipped", Checks: []domain.Check{{Op: "before", Value: "now", Format: "02.01.2006"}}},
+		{ID: "zoned", Field: "created_at", Checks: []domain.Check{{Op: "before", Value: "now", Timezone: "Europe/Berlin"}}},
+		{ID: "cross", Field: "shipped_at", Checks: []domain.Check{{Op: "after", ValueField: "created_at"}}},
+	}
+
+	plan := Plan(rules, nil)
+	if len(plan.SQLRules) != 2 || plan.SQLRules[0].ID != "past" || plan.SQLRules[1].ID != "epoch" {
+		t.Errorf("expected 'past' and 'epoch' to be pushed down, got %+v", plan.SQLRules)
+	}
+}
+
+func TestPlan_ProfileChange(t *testing.T) {
+	rules := []domain.Rule{
+		{ID: "distinct_ok", Field: "country", Checks: []domain.Check{{Op: domain.OpDistinctCount, Value: 1}}},
+		{ID: "distinct_kept", Field: "country", Checks: []domain.Check{{Op: domain.OpProfileChange, Stat: "distinct"}}},
+	}
+
+	plan := Plan(rules, nil)
+	if len(plan.SQLRules) != 1 || len(plan.MemoryRules) != 1 || plan.MemoryRules[0].ID != "distinct_kept" {
+		t.Errorf("expected profile_change to stay in memory, got %+v", plan)
+	}
+}
+
+func TestPlan_Shift(t *testing.T) {
+	rules := []domain.Rule{
+		{ID: "psi", Field: "price", Checks: []domain.Check{{Op: domain.OpPSI}}},
+		{ID: "share", Field: "country", Checks: []domain.Check{{Op: domain.OpShareDelta, Ref: &domain.Reference{Table: "countries", Column: "code"}}}},
+	}
+
+	plan := Plan(rules, nil)
+	if len(plan.SQLRules) != 0 || len(plan.MemoryRules) != 2 {
+		t.Errorf("expected shift checks to stay in memory, got %+v", plan)
+	}
+}
+
+func TestPlan_MaxAge(t *testing.T) {
+	rules := []domain.Rule{
+		{ID: "fresh", Field: "updated_at", Checks: []domain.Check{{Op: domain.OpMaxAge, Value: "2h"}}},
+		{ID: "epoch", Field: "paid_at", Checks: []domain.Check{{Op: domain.OpMaxAge, Value: "2h", Format: "epoch_millis"}}},
+		{ID: "zoned", Field: "updated_at", Checks: []domain.Check{{Op: domain.OpMaxAge, Value: "2h", Timezone: "Europe/Berlin"}}},
+	}
+
+	plan := Plan(rules, nil)
+	if len(plan.SQLRules) != 1 || plan.SQLRules[0].ID != "fresh" {
+		t.Errorf("expected formatted and zoned max_age to stay in memory, got %+v", plan)
+	}
+}
+
+func TestPlan_LibraryChecks(t *testing.T) {
+	rules := []domain.Rule{
+		{ID: "flat", Field: "sku", Checks: []domain.Check{{Op: domain.OpStartsWith, Value: "X-"}, {Op: domain.OpMaxLength, Value: 12}}},
+		{ID: "when", Field: "total", When: &domain.Condition{Field: "note", Op: domain.OpIsEmpty}, Checks: []domain.Check{{Op: "gt", Value: 0}}},
+		{ID: "nested", Field: "payload.sku", Checks: []domain.Check{{Op: domain.OpContains, Value: "-"}}},
+		{ID: "unbounded", Field: "price", Checks: []domain.Check{{Op: domain.OpBetween}}},
+		{ID: "cross", Field: "price", Checks: []domain.Check{{Op: domain.OpMultipleOf, ValueField: "step"}}},
+	}
+
+	plan := Plan(rules, nil)
+	if len(plan.SQLRules) != 2 || plan.SQLRules[0].ID != "flat" || plan.SQLRules[1].ID != "when" {
+		t.Errorf("expected 'flat' and 'when' to be pushed down, got %+v", plan.SQLRules)
+	}
+}
+
+func TestIsSchemaPushdownSafe(t *testing.T) {
+	if !IsSchemaPushdownSafe(domain.Schema{"amount": "decimal:10,2", "id": "uuid"}) {
+		t.Error("expected plain columns to be pushed down")
+	}
+	if IsSchemaPushdownSafe(domain.Schema{"payload.amount": "number"}) {
+		t.Error("expected nested fields to stay in memory")
+	}
+	if IsSchemaPushdownSafe(domain.Schema{"amount": "decimal:x"}) {
+		t.Error("expected malformed types to stay in memory")
+	}
+}
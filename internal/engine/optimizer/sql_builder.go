@@ -2,45 +2,29 @@ package optimizer
 
 import (
 	"fmt"
+	"slices"
+	"sort"
 	"strings"
 
 	"github.com/singh-anurag-7991/data-guard/internal/domain"
+	"github.com/singh-anurag-7991/data-guard/internal/expr"
+	"github.com/singh-anurag-7991/data-guard/internal/fieldpath"
+	"github.com/singh-anurag-7991/data-guard/internal/operators"
+	"github.com/singh-anurag-7991/data-guard/internal/schema"
 )
 
 // BuildFailureQuery constructs a SQL query to find records that FAIL the rules.
 // Logic: If Rule is "amount > 0", Failure is "amount <= 0 OR amount IS NULL".
-func BuildFailureQuery(tableName string, rules []domain.Rule) (string, []interface{}) {
+func BuildFailureQuery(tableName string, rules []domain.Rule, ops *operators.Registry) (string, []interface{}) {
 	if len(rules) == 0 {
 		return "", nil
 	}
 
 	var whereClauses []string
 	var args []interface{}
-	argCounter := 1
 
 	for _, rule := range rules {
-		ruleConditions := []string{}
-
-		for _, check := range rule.Checks {
-			cond, val := invertCheckToSQL(rule.Field, check)
-			if cond != "" {
-				// Only append arg if val is not nil (some ops like IS NULL don't need args)
-				if val != nil {
-					ruleConditions = append(ruleConditions, fmt.Sprintf("%s $%d", cond, argCounter))
-					args = append(args, val)
-					argCounter++
-				} else {
-					ruleConditions = append(ruleConditions, cond)
-				}
-			}
-		}
-
-		// A rule fails if ANY of its inverted checks match (wait, rules usually imply AND? No, a single check failure fails the rule)
-		// Actually, standard rule: all checks must pass. So if ANY check fails, rule fails.
-		// So we OR the inverted conditions.
-		if len(ruleConditions) > 0 {
-			// Wrap in parens: (amount <= 0 OR amount IS NULL)
-			clause := fmt.Sprintf("(%s)", strings.Join(ruleConditions, " OR "))
+		if clause := ruleFailureClause(tableName, rule, ops, &args); clause != "" {
 			whereClauses = append(whereClauses, clause)
 		}
 	}
@@ -55,17 +39,294 @@ func BuildFailureQuery(tableName string, rules []domain.Rule) (string, []interfa
 	return query, args
 }
 
+// BuildRuleFailureQuery constructs a SQL query returning the key columns and the field value
+// of every row failing a single rule, so each failure can be attributed to a record.
+// Without key columns the physical row id (ctid) identifies the row.
+func BuildRuleFailureQuery(tableName string, keyFields []string, rule domain.Rule, ops *operators.Registry) (string, []interface{}) {
+	var args []interface{}
+	clause := ruleFailureClause(tableName, rule, ops, &args)
+	if clause == "" {
+		return "", nil
+	}
+
+	columns := append([]string{}, keyFields...)
+	if len(columns) == 0 {
+		columns = append(columns, "ctid::text AS "+RowIDColumn)
+	}
+	// Operand, key and When fields are needed to re-check failing rows in memory
+	selected := map[string]bool{}
+	extra := []string{rule.Field}
+	for _, check := range rule.Checks {
+		extra = append(extra, check.ValueField)
+		if check.IsDataset() {
+			extra = append(extra, check.KeyFields(rule.Field)...)
+		}
+		if src, ok := check.Value.(string); ok && check.Op == "expr" {
+			if prog, err := expr.Compile(src); err == nil {
+				extra = append(extra, prog.Fields()...)
+			}
+		}
+	}
+	if rule.When != nil {
+		extra = append(extra, rule.When.Fields()...)
+	}
+	for _, field := range extra {
+		if field != "" && !selected[field] {
+			selected[field] = true
+			columns = append(columns, selectColumn(field))
+		}
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s", strings.Join(columns, ", "), tableName, clause)
+	return query, args
+}
+
+// BuildRuleCountQuery constructs a SQL query counting the rows a rule applies to
+// (all rows unless it has a When condition). The count is returned in column "n".
+func BuildRuleCountQuery(tableName string, rule domain.Rule) (string, []interface{}) {
+	query := fmt.Sprintf("SELECT COUNT(*) AS n FROM %s", tableName)
+	if rule.When == nil {
+		return query, nil
+	}
+
+	var args []interface{}
+	if cond := whenToSQL(*rule.When, &args); cond != "" {
+		query += " WHERE " + cond
+	}
+	return query, args
+}
+
+// BuildAggregateQuery constructs a SQL query computing every aggregate check of a rule over the
+// rows it applies to, in one row with column "a<i>" for rule.Checks[i]. Returns "" without aggregates.
+// Aggregates are cast to float8 so they scan as plain numbers.
+func BuildAggregateQuery(tableName string, rule domain.Rule) (string, []interface{}) {
+	field := columnExpr(rule.Field, domain.Check{})
+	var columns []string
+	for i, check := range rule.Checks {
+		var agg string
+		switch check.Op {
+		case domain.OpRowCount:
+			agg = "COUNT(*)"
+		case domain.OpSum:
+			agg = fmt.Sprintf("COALESCE(SUM((%s)::numeric), 0)::float8", field)
+		case domain.OpAvg, domain.OpMin, domain.OpMax:
+			agg = fmt.Sprintf("%s((%s)::numeric)::float8", strings.ToUpper(check.Op), field)
+		case domain.OpNullRatio:
+			agg = fmt.Sprintf("COALESCE((COUNT(*) - COUNT(%s))::float8 / NULLIF(COUNT(*), 0), 0)", field)
+		case domain.OpDistinctCount:
+			agg = fmt.Sprintf("COUNT(DISTINCT %s)", field)
+		case domain.OpMaxAge:
+			agg = fmt.Sprintf("EXTRACT(EPOCH FROM MAX((%s)::timestamptz))::float8", field) // Age is measured in Go, against the run time
+
+		default:
+			continue
+		}
+		columns = append(columns, fmt.Sprintf("%s AS a%d", agg, i))
+	}
+	if len(columns) == 0 {
+		return "", nil
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s", strings.Join(columns, ", "), tableName)
+	var args []interface{}
+	if rule.When != nil {
+		if cond := whenToSQL(*rule.When, &args); cond != "" {
+			query += " WHERE " + cond
+		}
+	}
+	return query, args
+}
+
+// RowIDColumn is the alias of the ctid fallback key selected by BuildRuleFailureQuery
+const RowIDColumn = "_row_id"
+
+// ruleFailureClause builds the WHERE clause matching rows that fail the rule, appending
+// placeholder values to args. Checks of operators in ops use their SQL translation.
+// Returns "" if no check translates to SQL.
+func ruleFailureClause(tableName string, rule domain.Rule, ops *operators.Registry, args *[]interface{}) string {
+	// A rule only applies to rows matching its When condition
+	// (bound first so placeholders read left to right)
+	whenClause := ""
+	mark := len(*args)
+	if rule.When != nil {
+		whenClause = whenToSQL(*rule.When, args)
+	}
+
+	ruleConditions := []string{}
+	for _, check := range rule.Checks {
+		if check.IsAggregate() {
+			continue // Asserted by BuildAggregateQuery, no row fails on its own
+		}
+		if check.IsDataset() {
+			ruleConditions = append(ruleConditions, duplicateKeysToSQL(tableName, rule, check, args))
+			continue
+		}
+		if check.Op == domain.OpExistsIn {
+			if cond := antiJoinToSQL(tableName, rule.Field, check); cond != "" {
+				ruleConditions = append(ruleConditions, cond)
+			}
+			continue
+		}
+		if check.Op == "expr" {
+			if cond, ok := exprToSQL(rule.Field, check, args); ok {
+				// Fail unless the expression is true (NULL fails, as in memory)
+				ruleConditions = append(ruleConditions, fmt.Sprintf("NOT COALESCE(%s, FALSE)", cond))
+			}
+			continue
+		}
+		if op, ok := ops.Get(check.Op); ok && op.SQL != nil {
+			if cond := registeredCheckToSQL(rule.Field, check, op, args); cond != "" {
+				ruleConditions = append(ruleConditions, cond)
+			}
+			continue
+		}
+		if cond, ok := libraryCheckToSQL(rule.Field, check, true, args); ok {
+			ruleConditions = append(ruleConditions, cond)
+			continue
+		}
+		cond, val := invertCheckToSQL(rule.Field, check)
+		if cond == "" {
+			continue
+		}
+		cond = bindArg(cond, val, args)
+		if nulls := nullFailureToSQL(rule.Field, check); nulls != "" {
+			cond = fmt.Sprintf("(%s OR %s)", cond, nulls)
+		}
+		ruleConditions = append(ruleConditions, cond)
+	}
+
+	if len(ruleConditions) == 0 {
+		*args = (*args)[:mark] // Drop the When argument, nothing to filter on
+		return ""
+	}
+
+	// Standard rule: all checks must pass. So if ANY check fails, rule fails.
+	// So we OR the inverted conditions: (amount <= 0 OR amount IS NULL)
+	clause := fmt.Sprintf("(%s)", strings.Join(ruleConditions, " OR "))
+	if whenClause != "" {
+		clause = fmt.Sprintf("(%s AND %s)", whenClause, clause)
+	}
+	return clause
+}
+
+// bindArg completes a condition with the next placeholder when it takes a value
+func bindArg(cond string, val interface{}, args *[]interface{}) string {
+	// Only append arg if val is not nil (some ops like IS NULL don't need args)
+	if val == nil {
+		return cond
+	}
+	*args = append(*args, val)
+	return fmt.Sprintf("%s $%d", cond, len(*args))
+}
+
+// whenToSQL translates a condition tree, mirroring Executor.evaluateNode: every part that is
+// set must hold. NOT treats NULL as false so rows the executor would include are not lost.
+// Returns "" for an empty tree.
+func whenToSQL(cond domain.Condition, args *[]interface{}) string {
+	var parts []string
+
+	if cond.IsLeaf() {
+		check := domain.Check{Op: cond.Op, Value: cond.Value, ValueField: cond.ValueField}
+		if sql, ok := libraryCheckToSQL(cond.Field, check, false, args); ok {
+			parts = append(parts, sql)
+		} else if sql, val := conditionToSQL(cond.Field, check); sql != "" {
+			parts = append(parts, bindArg(sql, val, args))
+		}
+	}
+
+	for _, sub := range cond.All {
+		if sql := whenToSQL(sub, args); sql != "" {
+			parts = append(parts, sql)
+		}
+	}
+
+	if len(cond.Any) > 0 {
+		var alternatives []string
+		for _, sub := range cond.Any {
+			sql := whenToSQL(sub, args)
+			if sql == "" {
+				sql = "TRUE" // An empty alternative always holds
+			}
+			alternatives = append(alternatives, sql)
+		}
+		parts = append(parts, fmt.Sprintf("(%s)", strings.Join(alternatives, " OR ")))
+	}
+
+	if cond.Not != nil {
+		if sql := whenToSQL(*cond.Not, args); sql != "" {
+			parts = append(parts, fmt.Sprintf("NOT COALESCE(%s, FALSE)", sql))
+		} else {
+			parts = append(parts, "FALSE") // NOT of an always-true condition
+		}
+	}
+
+	switch len(parts) {
+	case 0:
+		return ""
+	case 1:
+		return parts[0]
+	default:
+		return fmt.Sprintf("(%s)", strings.Join(parts, " AND "))
+	}
+}
+
+// conditionToSQL returns the condition as-is (what makes it apply)
+func conditionToSQL(field string, check domain.Check) (string, interface{}) {
+	if check.ValueField != "" {
+		return columnComparisonToSQL(field, check, false), nil
+	}
+	field = columnExpr(field, check)
+	switch check.Op {
+	case "not_null":
+		return fmt.Sprintf("%s IS NOT NULL", field), nil
+	case "eq":
+		if check.Value == nil {
+			return fmt.Sprintf("%s IS NULL", field), nil
+		}
+		return fmt.Sprintf("%s =", field), check.Value
+	case "neq":
+		if check.Value == nil {
+			return fmt.Sprintf("%s IS NOT NULL", field), nil
+		}
+		// A null value differs from every other, as in memory
+		return fmt.Sprintf("%s IS DISTINCT FROM", field), check.Value
+	case "gt":
+		return fmt.Sprintf("%s >", field), check.Value
+	case "lt":
+		return fmt.Sprintf("%s <", field), check.Value
+	case "gte":
+		return fmt.Sprintf("%s >=", field), check.Value
+	case "lte":
+		return fmt.Sprintf("%s <=", field), check.Value
+	default:
+		return "", nil
+	}
+}
+
 // invertCheckToSQL returns the INVERTED condition (what makes it fail)
 func invertCheckToSQL(field string, check domain.Check) (string, interface{}) {
+	if check.ValueField != "" {
+		return columnComparisonToSQL(field, check, true), nil
+	}
+	if isDateOp(check.Op) {
+		return invertDateCheckToSQL(field, check)
+	}
+	field = columnExpr(field, check)
 	switch check.Op {
 	case "not_null":
 		// Fail if IS NULL
 		return fmt.Sprintf("%s IS NULL", field), nil
 	case "eq":
-		// Fail if !=
-		return fmt.Sprintf("%s !=", field), check.Value
+		// Fail if different, null included
+		if check.Value == nil {
+			return fmt.Sprintf("%s IS NOT NULL", field), nil
+		}
+		return fmt.Sprintf("%s IS DISTINCT FROM", field), check.Value
 	case "neq":
 		// Fail if =
+		if check.Value == nil {
+			return fmt.Sprintf("%s IS NULL", field), nil
+		}
 		return fmt.Sprintf("%s =", field), check.Value
 	case "gt":
 		// Fail if <=
@@ -83,3 +344,351 @@ func invertCheckToSQL(field string, check domain.Check) (string, interface{}) {
 		return "", nil
 	}
 }
+
+// nullFailureToSQL returns the condition fetching the rows a comparison fails for a null value or
+// operand, which its inverted condition leaves out since NULL <= 0 is NULL; "" for other checks
+func nullFailureToSQL(field string, check domain.Check) string {
+	switch check.Op {
+	case "gt", "lt", "gte", "lte", domain.OpBefore, domain.OpAfter, domain.OpWithinDays, domain.OpNotFuture:
+	default:
+		return ""
+	}
+	cond := fmt.Sprintf("%s IS NULL", columnExpr(field, domain.Check{}))
+	if check.ValueField != "" {
+		cond += fmt.Sprintf(" OR %s IS NULL", columnExpr(check.ValueField, domain.Check{}))
+	}
+	return cond
+}
+
+// registeredCheckToSQL binds the failing condition of a registered operator. Unless the operator
+// is null-safe, rows where the field is null are fetched too, as they fail in memory.
+func registeredCheckToSQL(field string, check domain.Check, op operators.Operator, args *[]interface{}) string {
+	column := columnExpr(field, check)
+	cond, val := op.SQL(column, check)
+	if cond == "" {
+		return ""
+	}
+	cond = bindArg(cond, val, args)
+	if !op.NullSafe {
+		cond = fmt.Sprintf("(%s OR %s IS NULL)", cond, column)
+	}
+	return cond
+}
+
+// isDateOp reports whether op compares timestamps
+func isDateOp(op string) bool {
+	switch op {
+	case domain.OpBefore, domain.OpAfter, domain.OpWithinDays, domain.OpNotFuture:
+		return true
+	}
+	return false
+}
+
+// invertDateCheckToSQL returns the failing condition of a date operator, reading the field as
+// timestamptz. Thresholds are resolved in Go so they honor the check's format and timezone;
+// values without an offset follow the database's TimeZone, so checks with a timezone or a
+// custom layout are not translated ("").
+func invertDateCheckToSQL(field string, check domain.Check) (string, interface{}) {
+	if check.Timezone != "" {
+		return "", nil
+	}
+	expr := columnExpr(field, domain.Check{})
+	switch check.Format {
+	case "", operators.FormatRFC3339:
+		expr = fmt.Sprintf("(%s)::timestamptz", expr)
+	case operators.FormatEpochSeconds:
+		expr = fmt.Sprintf("to_timestamp((%s)::float8)", expr)
+	case operators.FormatEpochMillis:
+		expr = fmt.Sprintf("to_timestamp((%s)::float8 / 1000)", expr)
+	default:
+		return "", nil
+	}
+
+	switch check.Op {
+	case domain.OpBefore, domain.OpAfter:
+		op := ">=" // Fail unless before
+		if check.Op == domain.OpAfter {
+			op = "<="
+		}
+		if check.Value == "now" {
+			return fmt.Sprintf("%s %s now()", expr, op), nil
+		}
+		limit, ok := operators.Threshold(check)
+		if !ok {
+			return "", nil
+		}
+		return fmt.Sprintf("%s %s", expr, op), limit
+	case domain.OpNotFuture:
+		return fmt.Sprintf("%s > now()", expr), nil
+	case domain.OpWithinDays:
+		days, ok := operators.ToFloat(check.Value)
+		if !ok || days < 0 {
+			return "", nil
+		}
+		return fmt.Sprintf("%s NOT BETWEEN now() - interval '1 day' * %v AND now()", expr, days), nil
+	}
+	return "", nil
+}
+
+// exprToSQL translates an "expr" check. Only plain columns can be referenced, since nested
+// JSONB lookups would need a type for every use. Arguments are only bound on success.
+func exprToSQL(field string, check domain.Check, args *[]interface{}) (string, bool) {
+	src, ok := check.Value.(string)
+	if !ok || check.ValueField != "" {
+		return "", false
+	}
+	prog, err := expr.Compile(src)
+	if err != nil {
+		return "", false
+	}
+
+	column := func(path string) (string, bool) {
+		if path == "$" {
+			path = field
+		}
+		p, err := fieldpath.Parse(path)
+		if err != nil || p.IsNested() || p.HasWildcard() {
+			return "", false
+		}
+		return path, true
+	}
+
+	var bound []interface{}
+	bind := func(val interface{}) string {
+		bound = append(bound, val)
+		return fmt.Sprintf("$%d", len(*args)+len(bound))
+	}
+
+	sql, ok := prog.SQL(column, bind)
+	if !ok {
+		return "", false
+	}
+	*args = append(*args, bound...)
+	return sql, true
+}
+
+// duplicateKeysToSQL matches rows whose key is shared with another row the rule applies to.
+// Rows with a NULL key part never match, as in memory. Limits (max_duplicates) are applied
+// in memory on the fetched rows, which hold every duplicated key.
+func duplicateKeysToSQL(tableName string, rule domain.Rule, check domain.Check, args *[]interface{}) string {
+	fields := check.KeyFields(rule.Field)
+	columns := make([]string, len(fields))
+	for i, field := range fields {
+		columns[i] = columnExpr(field, domain.Check{})
+	}
+	keys := strings.Join(columns, ", ")
+
+	duplicated := fmt.Sprintf("SELECT %s FROM %s", keys, tableName)
+	if rule.When != nil {
+		if cond := whenToSQL(*rule.When, args); cond != "" {
+			duplicated += " WHERE " + cond
+		}
+	}
+	return fmt.Sprintf("(%s) IN (%s GROUP BY %s HAVING COUNT(*) > 1)", keys, duplicated, keys)
+}
+
+// antiJoinToSQL matches rows whose value is missing from the referenced table.
+// The row's column is qualified so it cannot be shadowed by a column of the reference.
+func antiJoinToSQL(tableName, field string, check domain.Check) string {
+	if check.Ref == nil || check.Ref.Table == "" || check.Ref.Column == "" {
+		return ""
+	}
+	column := tableName + "." + columnExpr(field, domain.Check{})
+	refColumn := "ref." + check.Ref.Column
+	if column != tableName+"."+field {
+		refColumn += "::text" // Nested paths extract text
+	}
+	return fmt.Sprintf("(%s IS NOT NULL AND NOT EXISTS (SELECT 1 FROM %s ref WHERE %s = %s))", column, check.Ref.Table, refColumn, column)
+}
+
+// BuildReferenceQuery constructs a SQL query returning, in column "v", which of the text values
+// bound to $1 exist in the referenced column
+func BuildReferenceQuery(ref domain.Reference) string {
+	return fmt.Sprintf("SELECT DISTINCT (%s)::text AS v FROM %s WHERE (%s)::text = ANY($1)", ref.Column, ref.Table, ref.Column)
+}
+
+// BuildReferenceValuesQuery constructs a SQL query returning, in column "v", non-null values of
+// the referenced column, at most as many as bound to $1
+func BuildReferenceValuesQuery(ref domain.Reference) string {
+	return fmt.Sprintf("SELECT %s AS v FROM %s WHERE %s IS NOT NULL LIMIT $1", ref.Column, ref.Table, ref.Column)
+}
+
+// comparisonOps maps comparison operators to their SQL operator and its inverse
+// (null fields are equal to each other and differ from any value, as in memory)
+var comparisonOps = map[string][2]string{
+	"eq":  {"IS NOT DISTINCT FROM", "IS DISTINCT FROM"},
+	"neq": {"IS DISTINCT FROM", "IS NOT DISTINCT FROM"},
+	"gt":  {">", "<="},
+	"lt":  {"<", ">="},
+	"gte": {">=", "<"},
+	"lte": {"<=", ">"},
+}
+
+// columnComparisonToSQL compares two columns for cross-field checks ("ship_date >= order_date")
+func columnComparisonToSQL(field string, check domain.Check, invert bool) string {
+	ops, ok := comparisonOps[check.Op]
+	if !ok {
+		return ""
+	}
+	op := ops[0]
+	if invert {
+		op = ops[1]
+	}
+	return fmt.Sprintf("%s %s %s", columnExpr(field, check), op, columnExpr(check.ValueField, check))
+}
+
+// columnExpr maps a field path to a SQL expression. Plain fields are columns; nested paths
+// ("payload.customer.zip", "items[0].price") navigate a JSONB column with -> / ->> and are
+// cast to match the compared value. Wildcard paths never reach here (see isSQLPushdownSafe).
+func columnExpr(field string, check domain.Check) string {
+	p, err := fieldpath.Parse(field)
+	if err != nil || !p.IsNested() {
+		return field
+	}
+
+	var b strings.Builder
+	b.WriteString(p[0].Key)
+	for i, seg := range p[1:] {
+		arrow := "->"
+		if i == len(p)-2 {
+			arrow = "->>" // Last step extracts text
+		}
+		if seg.Key != "" {
+			fmt.Fprintf(&b, "%s'%s'", arrow, strings.ReplaceAll(seg.Key, "'", "''"))
+		} else {
+			fmt.Fprintf(&b, "%s%d", arrow, seg.Index)
+		}
+	}
+	expr := b.String()
+
+	switch check.Op {
+	case "gt", "lt", "gte", "lte":
+		return fmt.Sprintf("(%s)::numeric", expr)
+	}
+	if _, ok := check.Value.(bool); ok {
+		return fmt.Sprintf("(%s)::boolean", expr)
+	}
+	if _, ok := operators.ToFloat(check.Value); ok {
+		return fmt.Sprintf("(%s)::numeric", expr)
+	}
+	return expr
+}
+
+// selectColumn selects a field path, aliased to the path itself when nested so
+// the returned row resolves it as a plain key
+func selectColumn(field string) string {
+	expr := columnExpr(field, domain.Check{})
+	if expr == field {
+		return field
+	}
+	return fmt.Sprintf(`%s AS "%s"`, expr, strings.ReplaceAll(field, `"`, `""`))
+}
+
+// SQL types pgx returns as the Go values each schema type accepts
+const (
+	numericTypes = "'smallint', 'integer', 'bigint', 'real', 'double precision', 'numeric'"
+	textTypes    = "'text', 'character varying', 'character'"
+	timeTypes    = "'timestamp with time zone', 'timestamp without time zone', 'date'"
+)
+
+// BuildSchemaFailureQuery constructs a SQL query returning the key columns and schema fields of
+// every row that may fail the schema. The translation errs on the side of fetching: values whose
+// validity SQL cannot decide (e.g. timestamps stored as text) are fetched and checked in memory.
+// Returns "" when no field has a checkable type.
+func BuildSchemaFailureQuery(tableName string, keyFields []string, s domain.Schema) (string, []interface{}) {
+	fields := make([]string, 0, len(s))
+	for field := range s {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields) // Stable queries
+
+	var args []interface{}
+	var conditions []string
+	for _, field := range fields {
+		t, err := schema.Parse(s[field])
+		if err != nil || !t.Known() {
+			continue
+		}
+		valid := schemaTypeToSQL(field, t, &args)
+		if t.Nullable {
+			conditions = append(conditions, fmt.Sprintf("%s IS NOT NULL AND NOT COALESCE(%s, FALSE)", field, valid))
+		} else {
+			conditions = append(conditions, fmt.Sprintf("%s IS NULL OR NOT COALESCE(%s, FALSE)", field, valid))
+		}
+	}
+	if len(conditions) == 0 {
+		return "", nil
+	}
+
+	columns := append([]string{}, keyFields...)
+	if len(columns) == 0 {
+		columns = append(columns, "ctid::text AS "+RowIDColumn)
+	}
+	for _, field := range fields {
+		if !slices.Contains(columns, field) {
+			columns = append(columns, field)
+		}
+	}
+	return fmt.Sprintf("SELECT %s FROM %s WHERE (%s)", strings.Join(columns, ", "), tableName, strings.Join(conditions, ") OR (")), args
+}
+
+// schemaTypeToSQL returns a condition holding only for values of column that have the type.
+// Casts go through text so they parse whatever the column type, and CASE keeps them from
+// running on rows of another type.
+func schemaTypeToSQL(column string, t schema.Type, args *[]interface{}) string {
+	typeIn := func(types string) string {
+		return fmt.Sprintf("pg_typeof(%s)::text IN (%s)", column, types)
+	}
+	text := fmt.Sprintf("(%s)::text", column)
+	number := fmt.Sprintf("(%s)::text::numeric", column)
+	matches := func(pattern string) string {
+		return fmt.Sprintf("CASE WHEN %s THEN %s ~ '%s' ELSE FALSE END", typeIn(textTypes), text, pattern)
+	}
+	inList := func(values []string) string {
+		*args = append(*args, values)
+		return fmt.Sprintf("CASE WHEN %s THEN %s = ANY($%d) ELSE FALSE END", typeIn(textTypes), text, len(*args))
+	}
+
+	switch t.Name {
+	case schema.String:
+		return typeIn(textTypes)
+	case schema.Number:
+		return typeIn(numericTypes)
+	case schema.Boolean:
+		return typeIn("'boolean'")
+	case schema.Integer:
+		conds := []string{fmt.Sprintf("%s = trunc(%s)", number, number)}
+		if t.Min != nil {
+			conds = append(conds, fmt.Sprintf("%s >= %v", number, *t.Min))
+		}
+		if t.Max != nil {
+			conds = append(conds, fmt.Sprintf("%s <= %v", number, *t.Max))
+		}
+		return fmt.Sprintf("CASE WHEN %s THEN %s ELSE FALSE END", typeIn(numericTypes), strings.Join(conds, " AND "))
+	case schema.Decimal:
+		return fmt.Sprintf("CASE WHEN %s THEN %s ~ '%s' AND %s ~ '[0-9]' ELSE FALSE END",
+			typeIn(numericTypes+", "+textTypes), text, t.DecimalPattern(), text)
+	case schema.UUID:
+		return fmt.Sprintf("(%s OR %s)", typeIn("'uuid'"), matches(schema.UUIDPattern))
+	case schema.Email:
+		return matches(schema.EmailPattern)
+	case schema.URL:
+		return matches(schema.URLPattern)
+	case schema.IP:
+		// IPv6 text is left to the executor
+		return fmt.Sprintf("(%s OR %s)", typeIn("'inet', 'cidr'"), matches(schema.IPv4Pattern))
+	case schema.Country:
+		return inList(schema.Countries)
+	case schema.Currency:
+		return inList(schema.Currencies)
+	case schema.Enum:
+		return inList(t.Values)
+	case schema.Timestamp, schema.Date:
+		if t.Format == operators.FormatEpochSeconds || t.Format == operators.FormatEpochMillis {
+			return typeIn(numericTypes)
+		}
+		return typeIn(timeTypes)
+	default: // Time
+		return typeIn("'time without time zone'")
+	}
+}
@@ -1,7 +1,9 @@
 package optimizer
 
 import (
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/singh-anurag-7991/data-guard/internal/domain"
 )
@@ -25,9 +27,9 @@ func TestBuildFailureQuery(t *testing.T) {
 		},
 	}
 
-	query, args := BuildFailureQuery("orders", rules)
+	query, args := BuildFailureQuery("orders", rules, nil)
 
-	// Expected: SELECT * FROM orders WHERE (amount IS NULL OR amount <= $1) OR (status != $2)
+	// Expected: SELECT * FROM orders WHERE (amount IS NULL OR (amount <= $1 OR amount IS NULL)) OR (status IS DISTINCT FROM $2)
 	// Note: The order of map iteration in `Plan` wasn't map based, but `rules` is a slice, so order is preserved.
 
 	// Check Args
@@ -44,9 +46,9 @@ func TestBuildFailureQuery(t *testing.T) {
 	// Check Query Structure (Basic substring check to avoid whitespace brittleness)
 	expectedFragments := []string{
 		"SELECT * FROM orders WHERE",
-		"(amount IS NULL OR amount <= $1)",
+		"(amount IS NULL OR (amount <= $1 OR amount IS NULL))",
 		"OR",
-		"(status != $2)",
+		"(status IS DISTINCT FROM $2)",
 	}
 
 	for _, frag := range expectedFragments {
@@ -59,3 +61,340 @@ func TestBuildFailureQuery(t *testing.T) {
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(substr) == 0 || (len(s) > 0 && len(substr) > 0 && (s[0:len(substr)] == substr || contains(s[1:], substr))))
 }
+
+func TestBuildRuleFailureQuery(t *testing.T) {
+	rule := domain.Rule{
+		ID:     "credit_positive",
+		Field:  "amount",
+		When:   &domain.Condition{Field: "type", Op: "eq", Value: "credit"},
+		Checks: []domain.Check{{Op: "gt", Value: 0}},
+	}
+
+	query, args := BuildRuleFailureQuery("orders", []string{"id"}, rule, nil)
+	want := "SELECT id, amount, type FROM orders WHERE (type = $1 AND ((amount <= $2 OR amount IS NULL)))"
+	if query != want {
+		t.Errorf("expected %q, got %q", want, query)
+	}
+	if len(args) != 2 || args[0] != "credit" || args[1] != 0 {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	// Without a key the physical row id is selected instead
+	query, _ = BuildRuleFailureQuery("orders", nil, rule, nil)
+	if !contains(query, "SELECT ctid::text AS _row_id, amount, type FROM orders") {
+		t.Errorf("expected ctid fallback, got %s", query)
+	}
+}
+
+func TestBuildFailureQuery_NestedPaths(t *testing.T) {
+	rules := []domain.Rule{
+		{ID: "zip", Field: "payload.customer.address.zip", Checks: []domain.Check{{Op: "not_null"}}},
+		{ID: "first_price", Field: "payload.items[0].price", Checks: []domain.Check{{Op: "gt", Value: 0}}},
+		{ID: "vip", Field: "payload.customer.vip", Checks: []domain.Check{{Op: "eq", Value: true}}},
+	}
+
+	query, _ := BuildFailureQuery("events", rules, nil)
+	expectedFragments := []string{
+		"(payload->'customer'->'address'->>'zip' IS NULL)",
+		"((payload->'items'->0->>'price')::numeric <= $1 OR payload->'items'->0->>'price' IS NULL)",
+		"((payload->'customer'->>'vip')::boolean IS DISTINCT FROM $2)",
+	}
+	for _, frag := range expectedFragments {
+		if !contains(query, frag) {
+			t.Errorf("query missing fragment '%s'. Got: %s", frag, query)
+		}
+	}
+
+	q, _ := BuildRuleFailureQuery("events", []string{"id"}, rules[0], nil)
+	if !contains(q, `SELECT id, payload->'customer'->'address'->>'zip' AS "payload.customer.address.zip" FROM events`) {
+		t.Errorf("expected nested field aliased to its path, got %s", q)
+	}
+}
+
+func TestBuildFailureQuery_CompoundWhen(t *testing.T) {
+	rule := domain.Rule{
+		ID:    "us_retail_tax",
+		Field: "tax",
+		When: &domain.Condition{All: []domain.Condition{
+			{Field: "country", Op: "eq", Value: "US"},
+			{Not: &domain.Condition{Field: "channel", Op: "eq", Value: "wholesale"}},
+			{Any: []domain.Condition{
+				{Field: "total", Op: "gt", Value: 100},
+				{Field: "vip", Op: "eq", Value: true},
+			}},
+		}},
+		Checks: []domain.Check{{Op: "gt", Value: 0}},
+	}
+
+	query, args := BuildFailureQuery("orders", []domain.Rule{rule}, nil)
+	want := "SELECT * FROM orders WHERE ((country = $1 AND NOT COALESCE(channel = $2, FALSE) AND (total > $3 OR vip = $4)) AND ((tax <= $5 OR tax IS NULL)))"
+	if query != want {
+		t.Errorf("expected %q, got %q", want, query)
+	}
+	if len(args) != 5 {
+		t.Errorf("expected 5 args, got %v", args)
+	}
+
+	q, _ := BuildRuleFailureQuery("orders", []string{"id"}, rule, nil)
+	if !contains(q, "SELECT id, tax, country, channel, total, vip FROM orders") {
+		t.Errorf("expected every condition field to be selected, got %s", q)
+	}
+}
+
+func TestBuildFailureQuery_CrossField(t *testing.T) {
+	rule := domain.Rule{
+		ID:     "ship_after_order",
+		Field:  "ship_date",
+		When:   &domain.Condition{Field: "end_ts", Op: "gt", ValueField: "start_ts"},
+		Checks: []domain.Check{{Op: "gte", ValueField: "order_date"}, {Op: "not_null"}},
+	}
+
+	query, args := BuildFailureQuery("orders", []domain.Rule{rule}, nil)
+	want := "SELECT * FROM orders WHERE (end_ts > start_ts AND ((ship_date < order_date OR ship_date IS NULL OR order_date IS NULL) OR ship_date IS NULL))"
+	if query != want {
+		t.Errorf("expected %q, got %q", want, query)
+	}
+	if len(args) != 0 {
+		t.Errorf("column comparisons take no args, got %v", args)
+	}
+
+	q, _ := BuildRuleFailureQuery("orders", []string{"id"}, rule, nil)
+	if !contains(q, "SELECT id, ship_date, order_date, end_ts, start_ts FROM orders") {
+		t.Errorf("expected operand columns to be selected, got %s", q)
+	}
+}
+
+func TestBuildFailureQuery_DateChecks(t *testing.T) {
+	rule := domain.Rule{
+		ID:    "recent",
+		Field: "created_at",
+		Checks: []domain.Check{
+			{Op: "after", Value: "2024-01-01"},
+			{Op: "before", Value: "now"},
+			{Op: "not_future"},
+		},
+	}
+
+	query, args := BuildFailureQuery("orders", []domain.Rule{rule}, nil)
+	want := "SELECT * FROM orders WHERE (((created_at)::timestamptz <= $1 OR created_at IS NULL) OR ((created_at)::timestamptz >= now() OR created_at IS NULL) OR ((created_at)::timestamptz > now() OR created_at IS NULL))"
+	if query != want {
+		t.Errorf("expected %q, got %q", want, query)
+	}
+	if len(args) != 1 || !args[0].(time.Time).Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected the threshold as a time, got %v", args)
+	}
+
+	rule = domain.Rule{ID: "paid", Field: "payload.paid_at", Checks: []domain.Check{{Op: "within_days", Value: 7, Format: "epoch_seconds"}}}
+	query, _ = BuildFailureQuery("orders", []domain.Rule{rule}, nil)
+	want = "SELECT * FROM orders WHERE ((to_timestamp((payload->>'paid_at')::float8) NOT BETWEEN now() - interval '1 day' * 7 AND now() OR payload->>'paid_at' IS NULL))"
+	if query != want {
+		t.Errorf("expected %q, got %q", want, query)
+	}
+}
+
+func TestBuildFailureQuery_Expression(t *testing.T) {
+	rule := domain.Rule{
+		ID:     "country_code",
+		Field:  "country",
+		When:   &domain.Condition{Field: "region", Op: "eq", Value: "na"},
+		Checks: []domain.Check{{Op: "expr", Value: `lower($) != "xx" && len($) == 2`}},
+	}
+
+	query, args := BuildFailureQuery("orders", []domain.Rule{rule}, nil)
+	want := "SELECT * FROM orders WHERE (region = $1 AND (NOT COALESCE(((LOWER(country) IS DISTINCT FROM $2) AND (LENGTH(country) IS NOT DISTINCT FROM 2)), FALSE)))"
+	if query != want {
+		t.Errorf("expected %q, got %q", want, query)
+	}
+	if len(args) != 2 || args[0] != "na" || args[1] != "xx" {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	q, _ := BuildRuleFailureQuery("orders", []string{"id"}, domain.Rule{
+		ID: "total", Field: "total", Checks: []domain.Check{{Op: "expr", Value: "quantity * price == $"}},
+	}, nil)
+	if !contains(q, "SELECT id, total, quantity, price FROM orders") {
+		t.Errorf("expected expression fields to be selected, got %s", q)
+	}
+}
+
+func TestBuildRuleFailureQuery_Duplicates(t *testing.T) {
+	rule := domain.Rule{
+		ID:     "unique_line",
+		When:   &domain.Condition{Field: "status", Op: "eq", Value: "paid"},
+		Checks: []domain.Check{{Op: domain.OpUnique, Fields: []string{"order_id", "line"}}},
+	}
+
+	query, args := BuildRuleFailureQuery("orders", []string{"id"}, rule, nil)
+	want := "SELECT id, order_id, line, status FROM orders WHERE (status = $1 AND ((order_id, line) IN (SELECT order_id, line FROM orders WHERE status = $2 GROUP BY order_id, line HAVING COUNT(*) > 1)))"
+	if query != want {
+		t.Errorf("expected %q, got %q", want, query)
+	}
+	if len(args) != 2 || args[0] != "paid" || args[1] != "paid" {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	plan := Plan([]domain.Rule{rule, {ID: "mixed", Field: "order_id", Checks: []domain.Check{{Op: "not_null"}, {Op: domain.OpUnique}}}}, nil)
+	if len(plan.SQLRules) != 1 || plan.SQLRules[0].ID != "unique_line" {
+		t.Errorf("expected only the pure dataset rule to be pushed down, got %+v", plan.SQLRules)
+	}
+}
+
+func TestBuildAggregateQuery(t *testing.T) {
+	max := 0.05
+	rule := domain.Rule{
+		ID:    "revenue_health",
+		Field: "revenue",
+		When:  &domain.Condition{Field: "region", Op: "eq", Value: "eu"},
+		Checks: []domain.Check{
+			{Op: domain.OpRowCount},
+			{Op: domain.OpSum},
+			{Op: domain.OpAvg},
+			{Op: domain.OpNullRatio, Max: &max},
+			{Op: domain.OpDistinctCount},
+			{Op: domain.OpUnique},
+		},
+	}
+
+	query, args := BuildAggregateQuery("orders", rule)
+	want := "SELECT COUNT(*) AS a0, COALESCE(SUM((revenue)::numeric), 0)::float8 AS a1, AVG((revenue)::numeric)::float8 AS a2, " +
+		"COALESCE((COUNT(*) - COUNT(revenue))::float8 / NULLIF(COUNT(*), 0), 0) AS a3, COUNT(DISTINCT revenue) AS a4 FROM orders WHERE region = $1"
+	if query != want {
+		t.Errorf("expected %q, got %q", want, query)
+	}
+	if len(args) != 1 || args[0] != "eu" {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	if q, _ := BuildAggregateQuery("orders", domain.Rule{Field: "updated_at", Checks: []domain.Check{{Op: domain.OpMaxAge, Value: "2h"}}}); q != "SELECT EXTRACT(EPOCH FROM MAX((updated_at)::timestamptz))::float8 AS a0 FROM orders" {
+		t.Errorf("unexpected max_age query: %s", q)
+	}
+	if q, _ := BuildAggregateQuery("orders", domain.Rule{Field: "revenue", Checks: []domain.Check{{Op: domain.OpUnique}}}); q != "" {
+		t.Errorf("expected no query without aggregates, got %s", q)
+	}
+	if q, _ := BuildRuleFailureQuery("orders", nil, domain.Rule{Field: "revenue", Checks: []domain.Check{{Op: domain.OpRowCount}}}, nil); q != "" {
+		t.Errorf("expected no failure query for aggregates, got %s", q)
+	}
+}
+
+func TestBuildRuleFailureQuery_References(t *testing.T) {
+	rule := domain.Rule{
+		ID:     "customer_exists",
+		Field:  "customer_id",
+		Checks: []domain.Check{{Op: domain.OpExistsIn, Ref: &domain.Reference{Table: "customers", Column: "id"}}},
+	}
+
+	query, _ := BuildRuleFailureQuery("orders", []string{"id"}, rule, nil)
+	want := "SELECT id, customer_id FROM orders WHERE ((orders.customer_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM customers ref WHERE ref.id = orders.customer_id)))"
+	if query != want {
+		t.Errorf("expected %q, got %q", want, query)
+	}
+
+	lookup := domain.Rule{ID: "country", Field: "country", Checks: []domain.Check{{Op: domain.OpExistsIn, Ref: &domain.Reference{Lookup: "countries"}}}}
+	if plan := Plan([]domain.Rule{rule, lookup}, nil); len(plan.SQLRules) != 1 || plan.SQLRules[0].ID != "customer_exists" {
+		t.Errorf("expected only the table reference to be pushed down, got %+v", plan.SQLRules)
+	}
+
+	if q := BuildReferenceQuery(*rule.Checks[0].Ref); q != "SELECT DISTINCT (id)::text AS v FROM customers WHERE (id)::text = ANY($1)" {
+		t.Errorf("unexpected reference query %s", q)
+	}
+	if q := BuildReferenceValuesQuery(*rule.Checks[0].Ref); q != "SELECT id AS v FROM customers WHERE id IS NOT NULL LIMIT $1" {
+		t.Errorf("unexpected reference values query %s", q)
+	}
+}
+
+func TestBuildSchemaFailureQuery(t *testing.T) {
+	schema := domain.Schema{
+		"quantity": "integer:1..100",
+		"country":  "country",
+		"status":   "enum:active|inactive",
+		"payload":  "object", // Not checked
+	}
+
+	query, args := BuildSchemaFailureQuery("orders", []string{"id"}, schema)
+	want := "SELECT id, country, payload, quantity, status FROM orders WHERE " +
+		"(country IS NULL OR NOT COALESCE(CASE WHEN pg_typeof(country)::text IN ('text', 'character varying', 'character') THEN (country)::text = ANY($1) ELSE FALSE END, FALSE)) OR " +
+		"(quantity IS NULL OR NOT COALESCE(CASE WHEN pg_typeof(quantity)::text IN ('smallint', 'integer', 'bigint', 'real', 'double precision', 'numeric') " +
+		"THEN (quantity)::text::numeric = trunc((quantity)::text::numeric) AND (quantity)::text::numeric >= 1 AND (quantity)::text::numeric <= 100 ELSE FALSE END, FALSE)) OR " +
+		"(status IS NULL OR NOT COALESCE(CASE WHEN pg_typeof(status)::text IN ('text', 'character varying', 'character') THEN (status)::text = ANY($2) ELSE FALSE END, FALSE))"
+	if query != want {
+		t.Errorf("expected %q, got %q", want, query)
+	}
+	if len(args) != 2 || len(args[1].([]string)) != 2 {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	query, _ = BuildSchemaFailureQuery("orders", nil, domain.Schema{"active": "nullable boolean"})
+	if want := "SELECT ctid::text AS _row_id, active FROM orders WHERE (active IS NOT NULL AND NOT COALESCE(pg_typeof(active)::text IN ('boolean'), FALSE))"; query != want {
+		t.Errorf("expected %q, got %q", want, query)
+	}
+
+	if q, _ := BuildSchemaFailureQuery("orders", nil, domain.Schema{"payload": "object"}); q != "" {
+		t.Errorf("expected no query without checkable types, got %s", q)
+	}
+}
+
+func TestBuildRuleFailureQuery_Library(t *testing.T) {
+	num := func(f float64) *float64 { return &f }
+	const numeric = "pg_typeof(v)::text IN ('smallint', 'integer', 'bigint', 'real', 'double precision', 'numeric')"
+	const text = "pg_typeof(v)::text IN ('text', 'character varying', 'character')"
+	tests := []struct {
+		name     string
+		check    domain.Check
+		wantCond string
+		wantArgs []interface{}
+	}{
+		{"between", domain.Check{Op: domain.OpBetween, Min: num(1), Max: num(2.5)},
+			"NOT COALESCE(CASE WHEN " + numeric + " THEN (v)::text::numeric >= 1 AND (v)::text::numeric <= 2.5 ELSE FALSE END, FALSE)", nil},
+		{"is_integer", domain.Check{Op: domain.OpIsInteger},
+			"NOT COALESCE(CASE WHEN " + numeric + " THEN (v)::text::numeric = trunc((v)::text::numeric) ELSE FALSE END, FALSE)", nil},
+		{"multiple_of", domain.Check{Op: domain.OpMultipleOf, Value: 0.05},
+			"NOT COALESCE(CASE WHEN " + numeric + " THEN mod((v)::text::numeric, 0.05) = 0 ELSE FALSE END, FALSE)", nil},
+		{"precision", domain.Check{Op: domain.OpPrecision, Value: 2},
+			"NOT COALESCE(CASE WHEN " + numeric + " THEN (v)::text::numeric = round((v)::text::numeric, 2) ELSE FALSE END, FALSE)", nil},
+		{"min_length", domain.Check{Op: domain.OpMinLength, Value: 3},
+			"NOT COALESCE(CASE WHEN " + text + " THEN char_length((v)::text) >= 3 ELSE FALSE END, FALSE)", nil},
+		{"max_length", domain.Check{Op: domain.OpMaxLength, Value: 3},
+			"NOT COALESCE(CASE WHEN " + text + " THEN char_length((v)::text) <= 3 ELSE FALSE END, FALSE)", nil},
+		{"contains", domain.Check{Op: domain.OpContains, Value: "@"},
+			"NOT COALESCE(CASE WHEN " + text + " THEN strpos((v)::text, $1::text) > 0 ELSE FALSE END, FALSE)", []interface{}{"@"}},
+		{"starts_with", domain.Check{Op: domain.OpStartsWith, Value: "ab"},
+			"NOT COALESCE(CASE WHEN " + text + " THEN starts_with((v)::text, $1::text) ELSE FALSE END, FALSE)", []interface{}{"ab"}},
+		{"ends_with", domain.Check{Op: domain.OpEndsWith, Value: "ab"},
+			"NOT COALESCE(CASE WHEN " + text + " THEN right((v)::text, char_length($1::text)) = $1::text ELSE FALSE END, FALSE)", []interface{}{"ab"}},
+		{"is_empty", domain.Check{Op: domain.OpIsEmpty},
+			"NOT (v IS NULL OR COALESCE(CASE WHEN " + text + " THEN (v)::text = '' ELSE FALSE END, FALSE))", nil},
+		{"is_null", domain.Check{Op: domain.OpIsNull}, "v IS NOT NULL", nil},
+		{"not_in", domain.Check{Op: domain.OpNotIn, Value: []interface{}{"a", 1.5, true, nil}},
+			"COALESCE(CASE WHEN " + numeric + " THEN (v)::text::numeric = ANY($1::text[]::numeric[]) " +
+				"WHEN " + text + " THEN (v)::text = ANY($2::text[]) " +
+				"WHEN pg_typeof(v)::text IN ('boolean') THEN (v)::text::boolean = ANY($3::boolean[]) ELSE FALSE END, FALSE)",
+			[]interface{}{[]string{"1.5"}, []string{"a"}, []bool{true}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := domain.Rule{ID: "r", Field: "v", Checks: []domain.Check{tt.check}}
+			query, args := BuildRuleFailureQuery("t", []string{"id"}, rule, nil)
+			if want := "SELECT id, v FROM t WHERE (" + tt.wantCond + ")"; query != want {
+				t.Errorf("expected %q, got %q", want, query)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("expected args %v, got %v", tt.wantArgs, args)
+			}
+		})
+	}
+}
+
+func TestBuildRuleCountQuery_LibraryWhen(t *testing.T) {
+	rule := domain.Rule{
+		ID:     "r",
+		Field:  "v",
+		When:   &domain.Condition{Field: "sku", Op: domain.OpStartsWith, Value: "X-"},
+		Checks: []domain.Check{{Op: "gt", Value: 0}},
+	}
+	query, args := BuildRuleCountQuery("t", rule)
+	want := "SELECT COUNT(*) AS n FROM t WHERE COALESCE(CASE WHEN pg_typeof(sku)::text IN ('text', 'character varying', 'character') THEN starts_with((sku)::text, $1::text) ELSE FALSE END, FALSE)"
+	if query != want || len(args) != 1 || args[0] != "X-" {
+		t.Errorf("expected %q with [X-], got %q with %v", want, query, args)
+	}
+}
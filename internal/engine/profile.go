@@ -0,0 +1,127 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/singh-anurag-7991/data-guard/internal/domain"
+	"github.com/singh-anurag-7991/data-guard/internal/fieldpath"
+	"github.com/singh-anurag-7991/data-guard/internal/profile"
+)
+
+// ProfileSource looks up the profile stored by the last profiled run of a source
+type ProfileSource interface {
+	LastProfile(ctx context.Context, sourceID string) ([]domain.FieldProfile, error)
+}
+
+// profileChange profiles the rule's field for a profile_change check and compares a statistic
+// with before, the field's profile in the last profiled run
+type profileChange struct {
+	field   string
+	builder *profile.Builder
+	before  *domain.FieldProfile // nil when the field was never profiled
+	err     error                // the last profile could not be loaded
+}
+
+func newProfileChange(field string) *profileChange {
+	return &profileChange{field: field, builder: profile.NewBuilder(field)}
+}
+
+func (p *profileChange) observe(records []domain.Record, index int) {
+	val, _ := fieldpath.Get(records[index], p.field)
+	p.builder.Add(val)
+}
+
+// resolveProfiles gives the profile_change checks the last profile of the source
+func (c *collector) resolveProfiles(ctx context.Context, sourceID string) {
+	var checks []*profileChange
+	for _, states := range c.datasets {
+		for _, state := range states {
+			if p, ok := state.(*profileChange); ok {
+				checks = append(checks, p)
+			}
+		}
+	}
+	if len(checks) == 0 {
+		return
+	}
+
+	var last []domain.FieldProfile
+	err := fmt.Errorf("no profile source configured")
+	if c.opts.Profiles != nil {
+		last, err = c.opts.Profiles.LastProfile(ctx, sourceID)
+	}
+	for _, p := range checks {
+		p.err = err
+		for i := range last {
+			if last[i].Field == p.field {
+				p.before = &last[i]
+			}
+		}
+	}
+}
+
+// assert checks the relative change of the check's statistic against its bounds. The first
+// profiled run of a field has nothing to compare with and passes.
+func (p *profileChange) assert(rule domain.Rule, check domain.Check) (domain.ErrorDetail, bool) {
+	detail := domain.ErrorDetail{RuleID: rule.ID, Field: rule.Field}
+	if !profile.IsStat(check.Stat) {
+		detail.Value = check.Stat
+		detail.Reason = fmt.Sprintf("profile_change expects a stat: %s or a quantile like p50", strings.Join(profile.Stats, ", "))
+		return detail, false
+	}
+	if p.err != nil {
+		detail.Reason = fmt.Sprintf("last profile unavailable: %v", p.err)
+		return detail, false
+	}
+
+	label := fmt.Sprintf("%s of %s", check.Stat, rule.Field)
+	now, ok := profile.Stat(p.builder.Profile(), check.Stat)
+	if !ok {
+		detail.Reason = fmt.Sprintf("%s is undefined", label)
+		return detail, false
+	}
+	detail.Value = formatAggregate(now)
+	if p.before == nil {
+		return detail, true
+	}
+	before, ok := profile.Stat(*p.before, check.Stat)
+	if !ok {
+		return detail, true
+	}
+
+	var change float64
+	switch {
+	case now == before:
+	case before == 0:
+		change = math.Copysign(math.Inf(1), now)
+	default:
+		change = (now - before) / math.Abs(before)
+	}
+
+	moved := fmt.Sprintf("%s changed by %+.1f%% (%v -> %v)", label, change*100, formatAggregate(before), formatAggregate(now))
+	switch {
+	case check.Min != nil && change < *check.Min:
+		detail.Reason = fmt.Sprintf("%s, below the minimum %+.1f%%", moved, *check.Min*100)
+	case check.Max != nil && change > *check.Max:
+		detail.Reason = fmt.Sprintf("%s, above the maximum %+.1f%%", moved, *check.Max*100)
+	default:
+		return detail, true
+	}
+	return detail, false
+}
+
+// profileFields lists the fields of profile_change checks, profiled besides top-level fields
+func profileFields(rules []domain.Rule) []string {
+	var fields []string
+	for _, rule := range rules {
+		for _, check := range rule.Checks {
+			if check.Op == domain.OpProfileChange {
+				fields = append(fields, rule.Field)
+			}
+		}
+	}
+	return fields
+}
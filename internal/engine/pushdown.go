@@ -0,0 +1,214 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/singh-anurag-7991/data-guard/internal/domain"
+	"github.com/singh-anurag-7991/data-guard/internal/engine/optimizer"
+)
+
+// TableQuerier runs SQL against the database holding a table source (satisfied by *postgres.Client)
+type TableQuerier interface {
+	FetchRows(ctx context.Context, query string, args ...interface{}) ([]domain.Record, error)
+	PrimaryKey(ctx context.Context, table string) ([]string, error)
+}
+
+// errSchemaFailed stops a pushdown once a row fails the schema: the rule queries would check
+// that row too, so the table is validated in memory instead
+var errSchemaFailed = errors.New("rows fail the schema")
+
+// ValidateTable validates a database table.
+// If every rule and the schema can be pushed down, each rule runs as its own failure query so only
+// failing rows leave the database; otherwise, or when rules are given and rows fail the schema,
+// the table is fetched and validated in memory.
+// Failures are identified by opts.KeyFields, defaulting to the table's primary key.
+// Names that cannot be put in SQL fail with optimizer.ErrInvalidIdentifier before any query runs.
+func (e *Executor) ValidateTable(ctx context.Context, db TableQuerier, sourceID, table string, schema domain.Schema, rules []domain.Rule, opts ValidateOptions) (domain.ValidationResult, error) {
+	if err := optimizer.CheckTable(table, opts.KeyFields, schema, rules); err != nil {
+		return domain.ValidationResult{}, err
+	}
+	if len(opts.KeyFields) == 0 {
+		pk, err := db.PrimaryKey(ctx, table)
+		if err != nil {
+			return domain.ValidationResult{}, fmt.Errorf("failed to resolve primary key of %s: %w", table, err)
+		}
+		opts.KeyFields = pk
+	}
+	if opts.References == nil {
+		opts.References = TableReferences(db)
+	}
+
+	plan := optimizer.Plan(rules, e.ops)
+	// Extra columns are only seen in full rows, and profiles need every value. SQL compares
+	// text columns as text, so numeric strings are read in memory.
+	if !opts.StrictSchema && !opts.Profile && !opts.NumericStrings && optimizer.IsSchemaPushdownSafe(schema) && len(plan.MemoryRules) == 0 {
+		result, err := e.pushdown(ctx, db, sourceID, table, schema, rules, opts)
+		if !errors.Is(err, errSchemaFailed) {
+			return result, err
+		}
+	}
+
+	records, err := db.FetchRows(ctx, fmt.Sprintf("SELECT * FROM %s", table))
+	if err != nil {
+		return domain.ValidationResult{}, err
+	}
+	return e.ValidateContext(ctx, sourceID, schema, rules, records, opts), nil
+}
+
+// pushdown validates the schema and pushdown-safe rules with one failure query each.
+// Failing rows are re-checked in memory so reasons match the memory path. Rules skip rows
+// failing the schema, which the rule queries cannot tell apart, so pushdown returns
+// errSchemaFailed when rules are given and a row fails the schema.
+// Rows with a null key are identified by their index among the rows of their query.
+func (e *Executor) pushdown(ctx context.Context, db TableQuerier, sourceID, table string, schema domain.Schema, rules []domain.Rule, opts ValidateOptions) (domain.ValidationResult, error) {
+	if opts.MaxDuration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.MaxDuration)
+		defer cancel()
+	}
+
+	keyFields := opts.KeyFields
+	if len(keyFields) == 0 {
+		keyFields = []string{optimizer.RowIDColumn}
+	}
+
+	total, err := countRows(ctx, db, table, domain.Rule{})
+	if err != nil {
+		return domain.ValidationResult{}, err
+	}
+
+	result := domain.ValidationResult{
+		SourceID:       sourceID,
+		Status:         domain.StatusPass,
+		RecordsChecked: total,
+		Errors:         []domain.ErrorDetail{},
+		Timestamp:      time.Now(),
+	}
+	c := newCollector(&result, rules, opts)
+	c.keyFields = keyFields
+
+	if query, args := optimizer.BuildSchemaFailureQuery(table, opts.KeyFields, schema); query != "" {
+		rows, err := db.FetchRows(ctx, query, args...)
+		if err != nil {
+			return domain.ValidationResult{}, fmt.Errorf("schema failure query: %w", err)
+		}
+		for j, row := range rows {
+			c.recordID = recordKey(row, keyFields, j)
+			failures, _ := validateSchema(row, schema, false, opts.NumericStrings)
+			if len(failures) > 0 && len(rules) > 0 {
+				return domain.ValidationResult{}, errSchemaFailed
+			}
+			for _, detail := range failures {
+				c.schemaFailed = true
+				c.fail(detail, domain.SeverityError)
+				if c.stopped {
+					break
+				}
+			}
+			if c.stopped {
+				break
+			}
+		}
+	}
+
+	for i, rule := range rules {
+		if c.stopped {
+			break
+		}
+		if err := ctx.Err(); err != nil {
+			c.truncate(contextReason(err))
+			break
+		}
+
+		evaluated := total
+		if rule.When != nil {
+			if evaluated, err = countRows(ctx, db, table, rule); err != nil {
+				return domain.ValidationResult{}, err
+			}
+		}
+
+		query, args := optimizer.BuildRuleFailureQuery(table, opts.KeyFields, rule, e.ops)
+		if query != "" {
+			rows, err := db.FetchRows(ctx, query, args...)
+			if err != nil {
+				return domain.ValidationResult{}, fmt.Errorf("failure query for rule %s: %w", rule.ID, err)
+			}
+			c.records = rows
+			c.refs = map[referenceID]referenceSet{} // Resolved for the rows of this rule only
+			c.resolveReferences(ctx, []domain.Rule{rule}, rows)
+			for j, row := range rows {
+				c.index = j
+				c.recordID = recordKey(row, keyFields, j)
+				e.applyRule(c, i, rule, row)
+				if c.stopped {
+					break
+				}
+			}
+		}
+
+		// Only rows with duplicated keys were fetched, so duplicates are found among them;
+		// aggregates are computed by the database
+		if rule.IsDataset() && !c.stopped {
+			if err := loadAggregates(ctx, db, table, rule, c.datasets[i]); err != nil {
+				return domain.ValidationResult{}, err
+			}
+			c.rules[i].Evaluated = evaluated
+			c.finishDataset(i, rule)
+		}
+
+		// Only failing rows were fetched, the rest of the summary comes from the counts
+		summary := &c.rules[i]
+		summary.Evaluated = evaluated
+		summary.Skipped = total - evaluated
+		summary.Passed = evaluated - summary.Failed
+
+		if c.stopped {
+			break
+		}
+	}
+
+	result.Status = c.status(rules)
+	result.RuleSummaries = c.summaries()
+	return result, nil
+}
+
+// loadAggregates runs the aggregate query of a dataset rule and loads the results into its states
+func loadAggregates(ctx context.Context, db TableQuerier, table string, rule domain.Rule, states []datasetState) error {
+	query, args := optimizer.BuildAggregateQuery(table, rule)
+	if query == "" {
+		return nil
+	}
+	rows, err := db.FetchRows(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("aggregate query for rule %s: %w", rule.ID, err)
+	}
+	if len(rows) == 0 {
+		return fmt.Errorf("aggregate query for rule %s returned no row", rule.ID)
+	}
+	for j, state := range states {
+		if agg, ok := state.(*aggregator); ok {
+			agg.load(rows[0][fmt.Sprintf("a%d", j)])
+		}
+	}
+	return nil
+}
+
+// countRows counts the rows of table the rule applies to
+func countRows(ctx context.Context, db TableQuerier, table string, rule domain.Rule) (int, error) {
+	query, args := optimizer.BuildRuleCountQuery(table, rule)
+	rows, err := db.FetchRows(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("count query failed: %w", err)
+	}
+	if len(rows) == 0 {
+		return 0, nil
+	}
+	n, ok := rows[0]["n"].(int64)
+	if !ok {
+		return 0, fmt.Errorf("unexpected count type %T", rows[0]["n"])
+	}
+	return int(n), nil
+}
@@ -0,0 +1,221 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/singh-anurag-7991/data-guard/internal/domain"
+	"github.com/singh-anurag-7991/data-guard/internal/engine/optimizer"
+	"github.com/singh-anurag-7991/data-guard/internal/operators"
+)
+
+// fakeTable answers the queries issued by ValidateTable from canned rows
+type fakeTable struct {
+	pk         []string
+	total      int64
+	failing    []domain.Record
+	aggregates domain.Record // row returned by aggregate queries
+	queries    []string
+}
+
+func (f *fakeTable) FetchRows(ctx context.Context, query string, args ...interface{}) ([]domain.Record, error) {
+	f.queries = append(f.queries, query)
+	if strings.Contains(query, " AS a0") {
+		return []domain.Record{f.aggregates}, nil
+	}
+	if strings.HasPrefix(query, "SELECT COUNT(*)") {
+		return []domain.Record{{"n": f.total}}, nil
+	}
+	return f.failing, nil
+}
+
+func (f *fakeTable) PrimaryKey(ctx context.Context, table string) ([]string, error) {
+	return f.pk, nil
+}
+
+func TestExecutor_ValidateTablePushdown(t *testing.T) {
+	e := NewExecutor()
+	db := &fakeTable{
+		pk:      []string{"id"},
+		total:   10,
+		failing: []domain.Record{{"id": int64(7), "amount": int64(-3)}, {"id": nil, "amount": int64(-1)}},
+	}
+	rules := []domain.Rule{
+		{ID: "positive", Field: "amount", Checks: []domain.Check{{Op: "gt", Value: 0}}},
+	}
+
+	res, err := e.ValidateTable(context.Background(), db, "orders", "orders", nil, rules, ValidateOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if res.Status != "FAIL" || res.RecordsChecked != 10 {
+		t.Errorf("expected FAIL over 10 records, got %s over %d", res.Status, res.RecordsChecked)
+	}
+	// A null key falls back to the index of the row among the failing rows
+	if len(res.Errors) != 2 || res.Errors[0].RecordID != "7" || res.Errors[1].RecordID != "#1" {
+		t.Errorf("expected errors keyed by primary key 7 and row #1, got %+v", res.Errors)
+	}
+	if s := res.RuleSummaries[0]; s.Evaluated != 10 || s.Failed != 2 || s.Passed != 8 {
+		t.Errorf("unexpected summary: %+v", s)
+	}
+	if !strings.Contains(db.queries[1], "SELECT id, amount FROM orders WHERE") {
+		t.Errorf("expected keyed failure query, got %s", db.queries[1])
+	}
+}
+
+func TestExecutor_ValidateTableCustomOperator(t *testing.T) {
+	ops := operators.NewRegistry()
+	_ = ops.Register(evenOperator)
+	e := NewExecutorWithRegistry(ops)
+	db := &fakeTable{
+		pk:      []string{"id"},
+		total:   10,
+		failing: []domain.Record{{"id": int64(3), "n": int64(3)}, {"id": int64(5), "n": nil}},
+	}
+	rules := []domain.Rule{{ID: "even", Field: "n", Checks: []domain.Check{{Op: "even"}}}}
+
+	res, err := e.ValidateTable(context.Background(), db, "numbers", "numbers", nil, rules, ValidateOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "SELECT id, n FROM numbers WHERE ((n % 2 <> $1 OR n IS NULL))"; db.queries[1] != want {
+		t.Errorf("expected failure query %q, got %q", want, db.queries[1])
+	}
+	if s := res.RuleSummaries[0]; s.Failed != 2 || s.Passed != 8 {
+		t.Errorf("unexpected summary: %+v", s)
+	}
+}
+
+func TestExecutor_ValidateTableDuplicates(t *testing.T) {
+	e := NewExecutor()
+	db := &fakeTable{
+		pk:    []string{"id"},
+		total: 10,
+		failing: []domain.Record{
+			{"id": int64(3), "email": "a@x.io"},
+			{"id": int64(8), "email": "a@x.io"},
+		},
+	}
+	rules := []domain.Rule{
+		{ID: "unique_email", Field: "email", Checks: []domain.Check{{Op: domain.OpUnique}}},
+	}
+
+	res, err := e.ValidateTable(context.Background(), db, "users", "users", nil, rules, ValidateOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(res.Errors) != 2 || res.Errors[1].RecordID != "8" || res.Errors[1].Reason != "duplicate email a@x.io shared by 2 records (3, 8)" {
+		t.Errorf("expected both rows sharing the email keyed by primary key, got %+v", res.Errors)
+	}
+	if s := res.RuleSummaries[0]; s.Evaluated != 10 || s.Failed != 2 || s.Passed != 8 {
+		t.Errorf("unexpected summary: %+v", s)
+	}
+	if !strings.Contains(db.queries[1], "GROUP BY email HAVING COUNT(*) > 1") {
+		t.Errorf("expected a GROUP BY failure query, got %s", db.queries[1])
+	}
+}
+
+func TestExecutor_ValidateTableAggregates(t *testing.T) {
+	e := NewExecutor()
+	db := &fakeTable{
+		pk:         []string{"id"},
+		total:      12,
+		aggregates: domain.Record{"a0": int64(12), "a1": 0.25},
+	}
+	min, maxRatio := 10000.0, 0.1
+	rules := []domain.Rule{{
+		ID:    "feed_volume",
+		Field: "revenue",
+		Checks: []domain.Check{
+			{Op: domain.OpRowCount, Min: &min},
+			{Op: domain.OpNullRatio, Max: &maxRatio},
+		},
+		Tolerance: 0.5, // ignored for aggregates
+	}}
+
+	res, err := e.ValidateTable(context.Background(), db, "feed", "feed", nil, rules, ValidateOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if res.Status != domain.StatusFail || len(res.Errors) != 2 {
+		t.Fatalf("expected both aggregates to fail the run, got %s with %+v", res.Status, res.Errors)
+	}
+	if res.Errors[0].Reason != "row_count is 12, below the minimum 10000" || res.Errors[0].RecordID != "" {
+		t.Errorf("unexpected row count failure: %+v", res.Errors[0])
+	}
+	if res.Errors[1].Reason != "null_ratio of revenue is 0.25, above the maximum 0.1" {
+		t.Errorf("unexpected null ratio failure: %+v", res.Errors[1])
+	}
+	if s := res.RuleSummaries[0]; s.Evaluated != 12 || s.Failed != 12 {
+		t.Errorf("unexpected summary: %+v", s)
+	}
+	if len(db.queries) != 2 {
+		t.Errorf("expected a count and an aggregate query only, got %v", db.queries)
+	}
+}
+
+func TestExecutor_ValidateTableSchema(t *testing.T) {
+	e := NewExecutor()
+	db := &fakeTable{
+		pk:    []string{"id"},
+		total: 10,
+		// The failure query may return rows that are valid after all
+		failing: []domain.Record{
+			{"id": int64(1), "quantity": 2.5, "email": "a@x.io"},
+			{"id": int64(2), "quantity": int64(5), "email": "b@x.io"},
+		},
+	}
+	schema := domain.Schema{"quantity": "integer:1..100", "email": "email"}
+
+	res, err := e.ValidateTable(context.Background(), db, "orders", "orders", schema, nil, ValidateOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Status != domain.StatusFail || len(res.Errors) != 1 || res.Errors[0].RecordID != "1" || res.Errors[0].Reason != "expected integer:1..100" {
+		t.Errorf("expected row 1 to fail the schema, got %s %+v", res.Status, res.Errors)
+	}
+	if len(db.queries) != 2 || !strings.Contains(db.queries[1], "SELECT id, email, quantity FROM orders WHERE") {
+		t.Errorf("expected the schema to be pushed down, got %v", db.queries)
+	}
+
+	// Rules skip rows failing the schema, so those tables are validated in memory
+	db.queries = nil
+	rules := []domain.Rule{{ID: "max_qty", Field: "quantity", Checks: []domain.Check{{Op: "lt", Value: 2}}}}
+	res, err = e.ValidateTable(context.Background(), db, "orders", "orders", schema, rules, ValidateOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.Errors) != 2 || res.Errors[0].RecordID != "1" || res.Errors[1].RecordID != "2" || res.Errors[1].RuleID != "max_qty" {
+		t.Errorf("expected row 1 to fail the schema only and row 2 the rule, got %+v", res.Errors)
+	}
+	if len(db.queries) != 3 || db.queries[2] != "SELECT * FROM orders" {
+		t.Errorf("expected the table to be validated in memory, got %v", db.queries)
+	}
+
+	db.queries = nil
+	if _, err := e.ValidateTable(context.Background(), db, "orders", "orders", domain.Schema{"payload.qty": "integer"}, nil, ValidateOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(db.queries) != 1 || db.queries[0] != "SELECT * FROM orders" {
+		t.Errorf("expected nested schema fields to be checked in memory, got %v", db.queries)
+	}
+}
+
+func TestExecutor_ValidateTableIdentifiers(t *testing.T) {
+	e := NewExecutor()
+	db := &fakeTable{pk: []string{"id"}}
+	rules := []domain.Rule{{ID: "r", Field: "name; DROP TABLE users; --", Checks: []domain.Check{{Op: "not_null"}}}}
+
+	_, err := e.ValidateTable(context.Background(), db, "orders", "orders", nil, rules, ValidateOptions{})
+	if !errors.Is(err, optimizer.ErrInvalidIdentifier) {
+		t.Errorf("expected ErrInvalidIdentifier, got %v", err)
+	}
+	if len(db.queries) != 0 {
+		t.Errorf("expected no query to run, got %v", db.queries)
+	}
+}
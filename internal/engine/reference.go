@@ -0,0 +1,155 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/singh-anurag-7991/data-guard/internal/domain"
+	"github.com/singh-anurag-7991/data-guard/internal/engine/optimizer"
+	"github.com/singh-anurag-7991/data-guard/internal/fieldpath"
+	"github.com/singh-anurag-7991/data-guard/internal/operators"
+)
+
+// ReferenceResolver finds which values exist in the reference set of an exists_in check.
+// Values are compared by their ReferenceKey.
+type ReferenceResolver interface {
+	ExistingKeys(ctx context.Context, ref domain.Reference, keys []string) ([]string, error)
+}
+
+// ReferenceKey is the text form values are matched by, so the JSON number 42 matches the
+// integer 42 of a table. It is the Postgres text form of integers, strings and booleans;
+// numbers are written exactly, so bigint keys beyond float64 precision stay apart.
+func ReferenceKey(v interface{}) string {
+	if d, ok := operators.FormatDecimal(v); ok {
+		return d
+	}
+	if f, ok := operators.ToFloat(v); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64) // Infinities and NaN
+	}
+	switch v := v.(type) {
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// TableReferences resolves references to tables of db
+func TableReferences(db TableQuerier) ReferenceResolver {
+	return tableReferences{db: db}
+}
+
+type tableReferences struct {
+	db TableQuerier
+}
+
+func (t tableReferences) ExistingKeys(ctx context.Context, ref domain.Reference, keys []string) ([]string, error) {
+	if ref.Table == "" || ref.Column == "" {
+		return nil, fmt.Errorf("only table references can be resolved against the database")
+	}
+	if err := optimizer.CheckReference(ref); err != nil {
+		return nil, err
+	}
+	rows, err := t.db.FetchRows(ctx, optimizer.BuildReferenceQuery(ref), keys)
+	if err != nil {
+		return nil, err
+	}
+	existing := make([]string, 0, len(rows))
+	for _, row := range rows {
+		existing = append(existing, ReferenceKey(row["v"]))
+	}
+	return existing, nil
+}
+
+// referenceID identifies the reference set of the exists_in checks on one field
+type referenceID struct {
+	field string
+	ref   domain.Reference
+}
+
+// referenceSet holds the values of a field found in a reference
+type referenceSet struct {
+	keys map[string]struct{}
+	err  error
+}
+
+// resolveReferences looks up, for every exists_in check, which values of the records exist in
+// its reference. Only the values present in records are resolved, never the whole reference.
+func (c *collector) resolveReferences(ctx context.Context, rules []domain.Rule, records []domain.Record) {
+	for _, rule := range rules {
+		for _, check := range rule.Checks {
+			if check.Op != domain.OpExistsIn || check.Ref == nil {
+				continue
+			}
+			id := referenceID{rule.Field, *check.Ref}
+			if _, done := c.refs[id]; done {
+				continue
+			}
+			if err := optimizer.CheckReference(id.ref); err != nil {
+				c.refs[id] = referenceSet{err: err} // Never sent to the resolver
+				continue
+			}
+			c.refs[id] = c.resolveReference(ctx, id, records)
+		}
+	}
+}
+
+func (c *collector) resolveReference(ctx context.Context, id referenceID, records []domain.Record) referenceSet {
+	if c.opts.References == nil {
+		return referenceSet{err: fmt.Errorf("no reference resolver configured")}
+	}
+
+	seen := map[string]bool{}
+	var keys []string
+	for _, record := range records {
+		for _, m := range fieldpath.Resolve(record, id.field) {
+			if m.Value == nil {
+				continue
+			}
+			if key := ReferenceKey(m.Value); !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+
+	set := referenceSet{keys: map[string]struct{}{}}
+	if len(keys) == 0 {
+		return set
+	}
+	existing, err := c.opts.References.ExistingKeys(ctx, id.ref, keys)
+	if err != nil {
+		return referenceSet{err: err}
+	}
+	for _, key := range existing {
+		set.keys[key] = struct{}{}
+	}
+	return set
+}
+
+// referenceOperator checks values of field against the reference sets resolved for the run
+func (c *collector) referenceOperator(field string) operators.OperatorFunc {
+	return func(value interface{}, check domain.Check) (bool, string) {
+		if check.Ref == nil {
+			return false, "exists_in needs a ref"
+		}
+		if value == nil {
+			return true, "" // Like a nullable foreign key
+		}
+
+		set, ok := c.refs[referenceID{field, *check.Ref}]
+		if !ok {
+			return false, fmt.Sprintf("reference %s was not resolved", check.Ref)
+		}
+		if set.err != nil {
+			return false, fmt.Sprintf("reference %s unavailable: %v", check.Ref, set.err)
+		}
+		if _, found := set.keys[ReferenceKey(value)]; !found {
+			return false, fmt.Sprintf("value %v not found in %s", value, check.Ref)
+		}
+		return true, ""
+	}
+}
@@ -0,0 +1,151 @@
+package engine
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/singh-anurag-7991/data-guard/internal/domain"
+	"github.com/singh-anurag-7991/data-guard/internal/fieldpath"
+	"github.com/singh-anurag-7991/data-guard/internal/operators"
+	"github.com/singh-anurag-7991/data-guard/internal/schema"
+)
+
+// validateSchema checks every field of the schema, returning all failures ordered by field and
+// the schema fields that failed. In strict mode fields the schema does not declare fail too;
+// with numericStrings, numeric strings are numbers and integers.
+func validateSchema(record domain.Record, s domain.Schema, strict, numericStrings bool) ([]domain.ErrorDetail, map[string]bool) {
+	var failures []domain.ErrorDetail
+	failed := map[string]bool{}
+
+	fields := make([]string, 0, len(s))
+	for field := range s {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	for _, field := range fields {
+		t, err := schema.Parse(s[field])
+		if err != nil {
+			failed[field] = true
+			failures = append(failures, domain.ErrorDetail{Field: field, Reason: fmt.Sprintf("invalid schema type %s: %v", s[field], err)})
+			continue
+		}
+		numeric := numericStrings && (t.Name == schema.Number || t.Name == schema.Integer)
+		// Wildcard paths check every element
+		for _, m := range fieldpath.Resolve(record, field) {
+			if n, ok := operators.NumericString(m.Value); ok && numeric {
+				m.Value = n
+			}
+			switch {
+			case !m.Found && t.Optional:
+				continue
+			case !m.Found:
+				failures = append(failures, domain.ErrorDetail{Field: m.Path, Reason: "field missing"})
+			case !t.Valid(m.Value):
+				failures = append(failures, domain.ErrorDetail{Field: m.Path, Reason: "expected " + t.Spec})
+			default:
+				continue
+			}
+			failed[field] = true
+		}
+	}
+
+	if strict {
+		for _, field := range unexpectedFields(record, s) {
+			failed[field] = true
+			failures = append(failures, domain.ErrorDetail{Field: field, Reason: "unexpected field"})
+		}
+	}
+	return failures, failed
+}
+
+var indexPattern = regexp.MustCompile(`\[\d+\]`)
+
+// unexpectedFields lists the fields of record the schema does not declare. Objects and arrays
+// holding declared fields are searched; the content of declared fields is not.
+func unexpectedFields(record domain.Record, s domain.Schema) []string {
+	var extra []string
+	var visit func(val interface{}, path string)
+	visit = func(val interface{}, path string) {
+		if path != "" {
+			wildcard := indexPattern.ReplaceAllString(path, "[*]")
+			if _, ok := s[path]; ok {
+				return
+			}
+			if _, ok := s[wildcard]; ok {
+				return
+			}
+			if !declaresChildren(s, path) && !declaresChildren(s, wildcard) {
+				extra = append(extra, path)
+				return
+			}
+		}
+
+		switch v := val.(type) {
+		case map[string]interface{}:
+			keys := make([]string, 0, len(v))
+			for k := range v {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				child := k
+				if path != "" {
+					child = path + "." + k
+				}
+				visit(v[k], child)
+			}
+		case []interface{}:
+			for i, item := range v {
+				visit(item, fmt.Sprintf("%s[%d]", path, i))
+			}
+		}
+	}
+	visit(map[string]interface{}(record), "")
+	return extra
+}
+
+// declaresChildren reports whether the schema declares a field nested in path
+func declaresChildren(s domain.Schema, path string) bool {
+	for field := range s {
+		if strings.HasPrefix(field, path+".") || strings.HasPrefix(field, path+"[") {
+			return true
+		}
+	}
+	return false
+}
+
+// usesFailedField reports whether the rule reads a field (or a parent or child of one) that
+// failed the schema
+func usesFailedField(rule domain.Rule, failed map[string]bool) bool {
+	if len(failed) == 0 {
+		return false
+	}
+	fields := []string{rule.Field}
+	for _, check := range rule.Checks {
+		fields = append(fields, check.ValueField)
+		fields = append(fields, check.Fields...)
+	}
+	if rule.When != nil {
+		fields = append(fields, rule.When.Fields()...)
+	}
+
+	for _, field := range fields {
+		if field == "" {
+			continue
+		}
+		for bad := range failed {
+			if overlaps(field, bad) || overlaps(bad, field) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// overlaps reports whether a is b or nested in it
+func overlaps(a, b string) bool {
+	return a == b || strings.HasPrefix(a, b+".") || strings.HasPrefix(a, b+"[")
+}
@@ -0,0 +1,189 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/singh-anurag-7991/data-guard/internal/domain"
+	"github.com/singh-anurag-7991/data-guard/internal/engine/optimizer"
+	"github.com/singh-anurag-7991/data-guard/internal/fieldpath"
+	"github.com/singh-anurag-7991/data-guard/internal/shift"
+)
+
+// BaselineResolver fetches the values of a reference, the baseline of distribution shift
+// checks. ReferenceResolvers implementing it serve both kinds of checks.
+type BaselineResolver interface {
+	ReferenceValues(ctx context.Context, ref domain.Reference) ([]interface{}, error)
+}
+
+// ErrNoReferenceData is returned for references to sources that never had data ingested
+var ErrNoReferenceData = errors.New("no dataset ingested")
+
+// MaxBaselineValues caps how many values of a table column make a baseline
+const MaxBaselineValues = 100000
+
+// Thresholds of shift checks without bounds
+var defaultShiftBounds = map[string][2]float64{
+	domain.OpPSI:        {math.Inf(-1), 0.25}, // a significant shift by the usual reading
+	domain.OpKS:         {math.Inf(-1), 0.1},
+	domain.OpChiSquare:  {0.01, math.Inf(1)}, // p-value
+	domain.OpShareDelta: {math.Inf(-1), 0.1},
+}
+
+// shiftState collects the values of the rule's field for a shift check
+type shiftState struct {
+	field     string
+	current   *shift.Distribution
+	baseline  *shift.Distribution
+	ref       domain.Reference
+	implicit  bool     // ref is the source's previous dataset, which the first run has none of
+	err       error    // the baseline could not be resolved
+	statistic *float64 // computed by assert
+}
+
+func newShiftState(field string) *shiftState {
+	return &shiftState{field: field, current: shift.NewDistribution()}
+}
+
+func (s *shiftState) observe(records []domain.Record, index int) {
+	for _, m := range fieldpath.Resolve(records[index], s.field) {
+		s.current.Add(m.Value)
+	}
+}
+
+// resolveBaselines loads the baseline of every shift check, once per reference
+func (c *collector) resolveBaselines(ctx context.Context, sourceID string, rules []domain.Rule) {
+	type resolved struct {
+		dist *shift.Distribution
+		err  error
+	}
+	cache := map[domain.Reference]resolved{}
+
+	for i, rule := range rules {
+		if !rule.IsDataset() {
+			continue // Shift checks mixed with record checks fail per record
+		}
+		for j, check := range rule.Checks {
+			s, ok := c.datasets[i][j].(*shiftState)
+			if !ok {
+				continue
+			}
+			if check.Ref != nil {
+				s.ref = *check.Ref
+			} else {
+				s.ref, s.implicit = domain.Reference{Source: sourceID, Column: rule.Field}, true
+			}
+
+			r, done := cache[s.ref]
+			if !done {
+				r.dist, r.err = c.resolveBaseline(ctx, s.ref)
+				cache[s.ref] = r
+			}
+			s.baseline, s.err = r.dist, r.err
+		}
+	}
+}
+
+func (c *collector) resolveBaseline(ctx context.Context, ref domain.Reference) (*shift.Distribution, error) {
+	if err := optimizer.CheckReference(ref); err != nil {
+		return nil, err
+	}
+	resolver, ok := c.opts.References.(BaselineResolver)
+	if !ok {
+		return nil, fmt.Errorf("no baseline resolver configured")
+	}
+	values, err := resolver.ReferenceValues(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	dist := shift.NewDistribution()
+	for _, v := range values {
+		dist.Add(v)
+	}
+	return dist, nil
+}
+
+// assert computes the check's statistic and checks it against its bounds, or the defaults
+func (s *shiftState) assert(rule domain.Rule, check domain.Check) (domain.ErrorDetail, bool) {
+	detail := domain.ErrorDetail{RuleID: rule.ID, Field: rule.Field}
+	noBaseline := s.err != nil || s.baseline.Len() == 0
+	switch {
+	case noBaseline && s.implicit && (s.err == nil || errors.Is(s.err, ErrNoReferenceData)):
+		return detail, true // Nothing ingested before, nothing to compare
+	case s.err != nil:
+		detail.Reason = fmt.Sprintf("baseline %s unavailable: %v", s.ref, s.err)
+		return detail, false
+	case noBaseline:
+		detail.Reason = fmt.Sprintf("baseline %s has no values", s.ref)
+		return detail, false
+	case s.current.Len() == 0:
+		detail.Reason = fmt.Sprintf("no values of %s to compare with %s", rule.Field, s.ref)
+		return detail, false
+	}
+
+	label := fmt.Sprintf("%s of %s", check.Op, rule.Field)
+	var val float64
+	var note string
+	switch check.Op {
+	case domain.OpPSI:
+		val = shift.PSI(s.baseline, s.current)
+	case domain.OpKS:
+		var ok bool
+		if val, ok = shift.KS(s.baseline, s.current); !ok {
+			detail.Reason = fmt.Sprintf("%s needs numeric values", label)
+			return detail, false
+		}
+	case domain.OpChiSquare:
+		stat, df, p := shift.ChiSquare(s.baseline, s.current)
+		val = p
+		label = fmt.Sprintf("chi_square p-value of %s", rule.Field)
+		note = fmt.Sprintf(" (statistic %v, %d degrees of freedom)", formatAggregate(stat), df)
+	case domain.OpShareDelta:
+		var category string
+		val, category = shift.ShareDelta(s.baseline, s.current)
+		note = fmt.Sprintf(" (share of %q)", category)
+	}
+	s.statistic = &val
+	detail.Value = formatAggregate(val)
+
+	bounds := defaultShiftBounds[check.Op]
+	if check.Min != nil || check.Max != nil {
+		bounds = [2]float64{math.Inf(-1), math.Inf(1)}
+		if check.Min != nil {
+			bounds[0] = *check.Min
+		}
+		if check.Max != nil {
+			bounds[1] = *check.Max
+		}
+	}
+	switch {
+	case val < bounds[0]:
+		detail.Reason = fmt.Sprintf("%s is %v%s against %s, below the minimum %v", label, formatAggregate(val), note, s.ref, bounds[0])
+	case val > bounds[1]:
+		detail.Reason = fmt.Sprintf("%s is %v%s against %s, above the maximum %v", label, formatAggregate(val), note, s.ref, bounds[1])
+	default:
+		return detail, true
+	}
+	return detail, false
+}
+
+// ReferenceValues returns up to MaxBaselineValues non-null values of a table column
+func (t tableReferences) ReferenceValues(ctx context.Context, ref domain.Reference) ([]interface{}, error) {
+	if ref.Table == "" || ref.Column == "" {
+		return nil, fmt.Errorf("only table references can be resolved against the database")
+	}
+	if err := optimizer.CheckReference(ref); err != nil {
+		return nil, err
+	}
+	rows, err := t.db.FetchRows(ctx, optimizer.BuildReferenceValuesQuery(ref), MaxBaselineValues)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]interface{}, len(rows))
+	for i, row := range rows {
+		values[i] = row["v"]
+	}
+	return values, nil
+}
@@ -0,0 +1,261 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/singh-anurag-7991/data-guard/internal/fieldpath"
+	"github.com/singh-anurag-7991/data-guard/internal/operators"
+)
+
+// MaxStringLen caps strings built during evaluation
+const MaxStringLen = 1 << 16
+
+// Env is what an expression can see while it runs
+type Env struct {
+	Record map[string]interface{} // fields, resolved by path
+	Value  interface{}            // "$", the value of the rule's field
+	Now    time.Time              // "now()", fixed for the whole run
+}
+
+// Eval runs the program. Values are numbers (float64), strings, booleans, times or nil;
+// arithmetic on nil yields nil and comparisons with nil are false (except == / != null).
+func (p *Program) Eval(env Env) (interface{}, error) {
+	return eval(p.root, env)
+}
+
+// EvalBool runs the program and requires a boolean result; nil counts as false
+func (p *Program) EvalBool(env Env) (bool, error) {
+	v, err := p.Eval(env)
+	if err != nil {
+		return false, err
+	}
+	switch b := v.(type) {
+	case bool:
+		return b, nil
+	case nil:
+		return false, nil
+	default:
+		return false, fmt.Errorf("expression returned %T, not a boolean", v)
+	}
+}
+
+func eval(n node, env Env) (interface{}, error) {
+	switch n := n.(type) {
+	case literal:
+		return n.val, nil
+
+	case fieldRef:
+		v, _ := fieldpath.Get(env.Record, n.path)
+		return normalize(v), nil
+
+	case currentValue:
+		return normalize(env.Value), nil
+
+	case unaryOp:
+		x, err := eval(n.x, env)
+		if err != nil || x == nil {
+			return nil, err
+		}
+		if n.op == "!" {
+			b, ok := x.(bool)
+			if !ok {
+				return nil, fmt.Errorf("! expects a boolean, got %T", x)
+			}
+			return !b, nil
+		}
+		f, ok := x.(float64)
+		if !ok {
+			return nil, fmt.Errorf("- expects a number, got %T", x)
+		}
+		return -f, nil
+
+	case binaryOp:
+		return evalBinary(n, env)
+
+	case approxEq:
+		l, err := evalNumber(n.l, env)
+		if err != nil {
+			return nil, err
+		}
+		r, err := evalNumber(n.r, env)
+		if err != nil {
+			return nil, err
+		}
+		tol, err := evalNumber(n.tol, env)
+		if err != nil {
+			return nil, err
+		}
+		if l == nil || r == nil || tol == nil {
+			return false, nil
+		}
+		return math.Abs(*l-*r) <= *tol, nil
+
+	case call:
+		args := make([]interface{}, len(n.args))
+		for i, a := range n.args {
+			v, err := eval(a, env)
+			if err != nil {
+				return nil, err
+			}
+			args[i] = v
+		}
+		return functions[n.fn].eval(args, env)
+	}
+	return nil, fmt.Errorf("unknown node %T", n)
+}
+
+func evalBinary(n binaryOp, env Env) (interface{}, error) {
+	l, err := eval(n.l, env)
+	if err != nil {
+		return nil, err
+	}
+
+	// Logic short-circuits, nil counts as false
+	switch n.op {
+	case "&&", "||":
+		lb, ok := l.(bool)
+		if l != nil && !ok {
+			return nil, fmt.Errorf("%s expects booleans, got %T", n.op, l)
+		}
+		if n.op == "&&" && !lb || n.op == "||" && lb {
+			return lb, nil
+		}
+		r, err := eval(n.r, env)
+		if err != nil {
+			return nil, err
+		}
+		rb, ok := r.(bool)
+		if r != nil && !ok {
+			return nil, fmt.Errorf("%s expects booleans, got %T", n.op, r)
+		}
+		return rb, nil
+	}
+
+	r, err := eval(n.r, env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return equal(l, r), nil
+	case "!=":
+		return !equal(l, r), nil
+	case "<", "<=", ">", ">=":
+		c, ok := compare(l, r)
+		if !ok {
+			return false, nil
+		}
+		switch n.op {
+		case "<":
+			return c < 0, nil
+		case "<=":
+			return c <= 0, nil
+		case ">":
+			return c > 0, nil
+		default:
+			return c >= 0, nil
+		}
+	}
+
+	// Arithmetic
+	if l == nil || r == nil {
+		return nil, nil
+	}
+	lf, lok := l.(float64)
+	rf, rok := r.(float64)
+	if !lok || !rok {
+		return nil, fmt.Errorf("%s expects numbers, got %T and %T", n.op, l, r)
+	}
+	switch n.op {
+	case "+":
+		return lf + rf, nil
+	case "-":
+		return lf - rf, nil
+	case "*":
+		return lf * rf, nil
+	case "/":
+		if rf == 0 {
+			return nil, nil // Division by zero is null, not a crash
+		}
+		return lf / rf, nil
+	case "%":
+		if rf == 0 {
+			return nil, nil
+		}
+		return math.Mod(lf, rf), nil
+	}
+	return nil, fmt.Errorf("unknown operator %s", n.op)
+}
+
+func evalNumber(n node, env Env) (*float64, error) {
+	v, err := eval(n, env)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	f, ok := v.(float64)
+	if !ok {
+		return nil, fmt.Errorf("expected a number, got %T", v)
+	}
+	return &f, nil
+}
+
+// normalize maps record values onto the expression types
+func normalize(v interface{}) interface{} {
+	if f, ok := operators.ToFloat(v); ok {
+		return f
+	}
+	return v
+}
+
+func equal(l, r interface{}) bool {
+	if l == nil || r == nil {
+		return l == nil && r == nil
+	}
+	if c, ok := compare(l, r); ok {
+		return c == 0
+	}
+	tl, tr := reflect.TypeOf(l), reflect.TypeOf(r)
+	if tl != tr || !tl.Comparable() {
+		return false // Comparing arrays or objects would panic
+	}
+	return l == r
+}
+
+// compare orders two values of the same type; ok is false for nil or mixed types
+func compare(l, r interface{}) (int, bool) {
+	switch lv := l.(type) {
+	case float64:
+		if rv, ok := r.(float64); ok {
+			return cmp(lv < rv, lv > rv), true
+		}
+	case string:
+		if rv, ok := r.(string); ok {
+			return strings.Compare(lv, rv), true
+		}
+	case time.Time:
+		if rv, ok := r.(time.Time); ok {
+			return lv.Compare(rv), true
+		}
+	case bool:
+		if rv, ok := r.(bool); ok && lv == rv {
+			return 0, true
+		}
+	}
+	return 0, false
+}
+
+func cmp(less, greater bool) int {
+	switch {
+	case less:
+		return -1
+	case greater:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,477 @@
+// Package expr implements the small, side-effect free expression language behind the "expr" operator.
+//
+// Expressions combine record fields with literals, arithmetic (+ - * / %), comparisons
+// (== != < <= > >=), logic (&& || ! or and/or/not) and a fixed set of functions:
+//
+//	quantity * unit_price == line_total ± 0.01
+//	lower(trim(country)) == "us" && coalesce(discount, 0) <= subtotal
+//	days_between(date(order_date), date(ship_date)) <= 5
+//
+// Fields are referenced by path ("customer.address.zip", "items[0].price") and "$" is the
+// value of the rule's own field. Expressions have no loops and are size-limited at compile
+// time, so evaluation cost is bounded by the expression length.
+package expr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Compile-time limits that bound the cost of evaluating an expression
+const (
+	MaxSourceLen = 4096
+	MaxNodes     = 256
+	MaxDepth     = 32
+)
+
+// Program is a compiled expression, safe for concurrent use
+type Program struct {
+	src  string
+	root node
+}
+
+// String returns the source of the program
+func (p *Program) String() string {
+	return p.src
+}
+
+// Fields lists the field paths the expression reads, without duplicates ("$" is not included)
+func (p *Program) Fields() []string {
+	seen := map[string]bool{}
+	var fields []string
+	var walk func(node)
+	walk = func(n node) {
+		switch n := n.(type) {
+		case fieldRef:
+			if !seen[n.path] {
+				seen[n.path] = true
+				fields = append(fields, n.path)
+			}
+		case unaryOp:
+			walk(n.x)
+		case binaryOp:
+			walk(n.l)
+			walk(n.r)
+		case approxEq:
+			walk(n.l)
+			walk(n.r)
+			walk(n.tol)
+		case call:
+			for _, a := range n.args {
+				walk(a)
+			}
+		}
+	}
+	walk(p.root)
+	return fields
+}
+
+// --- AST ---
+
+type node interface{}
+
+type literal struct{ val interface{} }
+
+type fieldRef struct{ path string }
+
+type currentValue struct{}
+
+type unaryOp struct {
+	op string
+	x  node
+}
+
+type binaryOp struct {
+	op   string
+	l, r node
+}
+
+// approxEq is "l == r ± tol"
+type approxEq struct{ l, r, tol node }
+
+type call struct {
+	fn   string
+	args []node
+}
+
+// Compile parses src into a Program
+func Compile(src string) (*Program, error) {
+	if len(src) > MaxSourceLen {
+		return nil, fmt.Errorf("expression longer than %d characters", MaxSourceLen)
+	}
+
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr(0)
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %q at offset %d", p.peek().text, p.peek().pos)
+	}
+	return &Program{src: src, root: root}, nil
+}
+
+// --- Lexer ---
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokString
+	tokIdent
+	tokOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// Multi-character operators first so they win over their prefixes
+var operatorTokens = []string{"==", "!=", "<=", ">=", "&&", "||", "±", "+", "-", "*", "/", "%", "<", ">", "!", "(", ")", ","}
+
+func lex(src string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(src) {
+		r, size := utf8.DecodeRuneInString(src[i:])
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+
+		case r >= '0' && r <= '9' || r == '.' && i+1 < len(src) && src[i+1] >= '0' && src[i+1] <= '9':
+			start := i
+			for i < len(src) && (src[i] >= '0' && src[i] <= '9' || src[i] == '.' || src[i] == 'e' || src[i] == 'E' ||
+				(src[i] == '-' || src[i] == '+') && (src[i-1] == 'e' || src[i-1] == 'E')) {
+				i++
+			}
+			tokens = append(tokens, token{tokNumber, src[start:i], start})
+
+		case r == '"' || r == '\'':
+			start := i
+			var b strings.Builder
+			i++
+			for {
+				if i >= len(src) {
+					return nil, fmt.Errorf("unterminated string at offset %d", start)
+				}
+				if src[i] == '\\' && i+1 < len(src) {
+					b.WriteByte(src[i+1])
+					i += 2
+					continue
+				}
+				if rune(src[i]) == r {
+					i++
+					break
+				}
+				b.WriteByte(src[i])
+				i++
+			}
+			tokens = append(tokens, token{tokString, b.String(), start})
+
+		case r == '$':
+			tokens = append(tokens, token{tokIdent, "$", i})
+			i++
+
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(src) {
+				c, size := utf8.DecodeRuneInString(src[i:])
+				if c == '_' || c == '.' || c == '[' || c == ']' || c >= '0' && c <= '9' || unicode.IsLetter(c) {
+					i += size
+					continue
+				}
+				break
+			}
+			tokens = append(tokens, token{tokIdent, src[start:i], start})
+
+		default:
+			matched := false
+			for _, op := range operatorTokens {
+				if strings.HasPrefix(src[i:], op) {
+					tokens = append(tokens, token{tokOp, op, i})
+					i += len(op)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("unexpected character %q at offset %d", r, i)
+			}
+		}
+	}
+	return append(tokens, token{tokEOF, "", len(src)}), nil
+}
+
+// --- Parser (precedence climbing, lowest first: or, and, not, comparison, additive, multiplicative, unary) ---
+
+type parser struct {
+	tokens []token
+	pos    int
+	nodes  int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+// accept consumes the next token if it is one of the given operators or keywords
+func (p *parser) accept(texts ...string) (string, bool) {
+	t := p.peek()
+	if t.kind != tokOp && t.kind != tokIdent {
+		return "", false
+	}
+	for _, text := range texts {
+		if t.text == text {
+			p.pos++
+			return text, true
+		}
+	}
+	return "", false
+}
+
+func (p *parser) count(depth int) error {
+	p.nodes++
+	if p.nodes > MaxNodes {
+		return fmt.Errorf("expression has more than %d nodes", MaxNodes)
+	}
+	if depth > MaxDepth {
+		return fmt.Errorf("expression nested deeper than %d", MaxDepth)
+	}
+	return nil
+}
+
+func (p *parser) parseOr(depth int) (node, error) {
+	left, err := p.parseAnd(depth)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.accept("||", "or"); !ok {
+			return left, nil
+		}
+		right, err := p.parseAnd(depth)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.count(depth); err != nil {
+			return nil, err
+		}
+		left = binaryOp{"||", left, right}
+	}
+}
+
+func (p *parser) parseAnd(depth int) (node, error) {
+	left, err := p.parseNot(depth)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.accept("&&", "and"); !ok {
+			return left, nil
+		}
+		right, err := p.parseNot(depth)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.count(depth); err != nil {
+			return nil, err
+		}
+		left = binaryOp{"&&", left, right}
+	}
+}
+
+func (p *parser) parseNot(depth int) (node, error) {
+	if _, ok := p.accept("!", "not"); ok {
+		if err := p.count(depth + 1); err != nil {
+			return nil, err
+		}
+		x, err := p.parseNot(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return unaryOp{"!", x}, nil
+	}
+	return p.parseComparison(depth)
+}
+
+func (p *parser) parseComparison(depth int) (node, error) {
+	left, err := p.parseAdditive(depth)
+	if err != nil {
+		return nil, err
+	}
+	op, ok := p.accept("==", "!=", "<=", ">=", "<", ">")
+	if !ok {
+		return left, nil
+	}
+	right, err := p.parseAdditive(depth)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.count(depth); err != nil {
+		return nil, err
+	}
+
+	if op == "==" {
+		if _, ok := p.accept("±"); ok {
+			tol, err := p.parseAdditive(depth)
+			if err != nil {
+				return nil, err
+			}
+			return approxEq{left, right, tol}, nil
+		}
+	}
+	return binaryOp{op, left, right}, nil
+}
+
+func (p *parser) parseAdditive(depth int) (node, error) {
+	left, err := p.parseMultiplicative(depth)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept("+", "-")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseMultiplicative(depth)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.count(depth); err != nil {
+			return nil, err
+		}
+		left = binaryOp{op, left, right}
+	}
+}
+
+func (p *parser) parseMultiplicative(depth int) (node, error) {
+	left, err := p.parseUnary(depth)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept("*", "/", "%")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseUnary(depth)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.count(depth); err != nil {
+			return nil, err
+		}
+		left = binaryOp{op, left, right}
+	}
+}
+
+func (p *parser) parseUnary(depth int) (node, error) {
+	if _, ok := p.accept("-"); ok {
+		if err := p.count(depth + 1); err != nil {
+			return nil, err
+		}
+		x, err := p.parseUnary(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return unaryOp{"-", x}, nil
+	}
+	return p.parsePrimary(depth)
+}
+
+func (p *parser) parsePrimary(depth int) (node, error) {
+	if err := p.count(depth); err != nil {
+		return nil, err
+	}
+
+	t := p.next()
+	switch t.kind {
+	case tokNumber:
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at offset %d", t.text, t.pos)
+		}
+		return literal{f}, nil
+
+	case tokString:
+		return literal{t.text}, nil
+
+	case tokIdent:
+		switch t.text {
+		case "true":
+			return literal{true}, nil
+		case "false":
+			return literal{false}, nil
+		case "null":
+			return literal{nil}, nil
+		case "$":
+			return currentValue{}, nil
+		}
+
+		if _, ok := p.accept("("); !ok {
+			return fieldRef{t.text}, nil
+		}
+
+		fn, ok := functions[t.text]
+		if !ok {
+			return nil, fmt.Errorf("unknown function %q at offset %d", t.text, t.pos)
+		}
+		var args []node
+		if _, ok := p.accept(")"); !ok {
+			for {
+				arg, err := p.parseOr(depth + 1)
+				if err != nil {
+					return nil, err
+				}
+				args = append(args, arg)
+				if _, ok := p.accept(","); ok {
+					continue
+				}
+				if _, ok := p.accept(")"); !ok {
+					return nil, fmt.Errorf("expected ')' after arguments of %s", t.text)
+				}
+				break
+			}
+		}
+		if len(args) < fn.minArgs || (fn.maxArgs >= 0 && len(args) > fn.maxArgs) {
+			return nil, fmt.Errorf("wrong number of arguments for %s: %d", t.text, len(args))
+		}
+		return call{t.text, args}, nil
+
+	case tokOp:
+		if t.text == "(" {
+			x, err := p.parseOr(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := p.accept(")"); !ok {
+				return nil, fmt.Errorf("expected ')' at offset %d", p.peek().pos)
+			}
+			return x, nil
+		}
+	}
+
+	if t.kind == tokEOF {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	return nil, fmt.Errorf("unexpected %q at offset %d", t.text, t.pos)
+}
@@ -0,0 +1,144 @@
+package expr
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEval(t *testing.T) {
+	record := map[string]interface{}{
+		"quantity":   3,
+		"unit_price": 2.5,
+		"line_total": 7.505,
+		"country":    " US ",
+		"discount":   nil,
+		"subtotal":   20.0,
+		"order_date": "2024-01-01",
+		"ship_date":  "2024-01-04T12:00:00Z",
+		"customer":   map[string]interface{}{"tier": "gold"},
+		"items":      []interface{}{map[string]interface{}{"sku": "A-1"}},
+	}
+
+	tests := []struct {
+		src  string
+		want bool
+	}{
+		{"quantity * unit_price == line_total ± 0.01", true},
+		{"quantity * unit_price == line_total ± 0.001", false},
+		{"approx(quantity * unit_price, line_total, 0.01)", true},
+		{`lower(trim(country)) == "us" && coalesce(discount, 0) <= subtotal`, true},
+		{"discount == null and is_null(discount)", true},
+		{"discount > 0", false}, // comparisons with null are false
+		{"not (discount > 0)", true},
+		{"days_between(date(order_date), date(ship_date)) <= 5", true},
+		{"year(date(order_date)) == 2024 && month(date(ship_date)) == 1", true},
+		{"date(ship_date) < now()", true},
+		{`customer.tier == "gold" and starts_with(items[0].sku, "A-")`, true},
+		{"$ > 2", true},
+		{"max(1, quantity, 2) == 3 && min(subtotal, 5) == 5", true},
+		{"round(line_total, 2) == 7.5 || round(line_total, 2) == 7.51", true},
+		{"subtotal / 0 == null", true},
+		{"quantity / 2 == 1.5 && quantity % 0 == null", true},
+		{"items == items || customer == customer", false}, // arrays and objects are never equal
+		{"größe == null", true},
+		{`len(concat(country, "x", 1)) == 6`, true},
+		{`contains(country, "U") && ends_with(trim(country), "S")`, true},
+	}
+
+	env := Env{Record: record, Value: 3, Now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			prog, err := Compile(tt.src)
+			if err != nil {
+				t.Fatalf("compile failed: %v", err)
+			}
+			got, err := prog.EvalBool(env)
+			if err != nil {
+				t.Fatalf("eval failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestEval_Errors(t *testing.T) {
+	env := Env{Record: map[string]interface{}{"name": "bob", "n": 1}}
+	for _, src := range []string{`name * 2 > 1`, `n + 1`, `upper(n) == "1"`} {
+		prog, err := Compile(src)
+		if err != nil {
+			t.Fatalf("compile %q failed: %v", src, err)
+		}
+		if _, err := prog.EvalBool(env); err == nil {
+			t.Errorf("expected %q to fail at runtime", src)
+		}
+	}
+}
+
+func TestCompile_Rejects(t *testing.T) {
+	tests := []string{
+		"",
+		"a +",
+		"(a == 1",
+		"unknown_fn(a)",
+		"round(a, 1, 2)",
+		`"unterminated`,
+		"a # b",
+		strings.Repeat("a + ", 300) + "a", // too many nodes
+		strings.Repeat("(", 40) + strings.Repeat(")", 40), // too deep
+		strings.Repeat("x", MaxSourceLen+1),
+	}
+	for _, src := range tests {
+		if _, err := Compile(src); err == nil {
+			t.Errorf("expected %.30q to be rejected", src)
+		}
+	}
+}
+
+func TestSQL(t *testing.T) {
+	column := func(path string) (string, bool) {
+		if path == "$" {
+			return "line_total", true
+		}
+		if strings.Contains(path, ".") {
+			return "", false
+		}
+		return path, true
+	}
+
+	var args []interface{}
+	bind := func(v interface{}) string {
+		args = append(args, v)
+		return "$" + string(rune('0'+len(args)))
+	}
+
+	prog, _ := Compile(`quantity * unit_price == $ ± 0.01 && lower(country) != "us"`)
+	sql, ok := prog.SQL(column, bind)
+	if !ok {
+		t.Fatalf("expected expression to translate")
+	}
+	want := "(COALESCE(ABS((quantity * unit_price) - line_total) <= 0.01, FALSE) AND (LOWER(country) IS DISTINCT FROM $1))"
+	if sql != want {
+		t.Errorf("expected %q, got %q", want, sql)
+	}
+	if len(args) != 1 || args[0] != "us" {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	prog, _ = Compile("quantity / 2 > quantity % 3")
+	if sql, _ := prog.SQL(column, bind); sql != "(((quantity)::numeric / NULLIF((2)::numeric, 0)) > ((quantity)::numeric % NULLIF((3)::numeric, 0)))" {
+		t.Errorf("expected decimal division that is null on zero, got %q", sql)
+	}
+
+	prog, _ = Compile("round(quantity, 1) == round(quantity)")
+	if sql, _ := prog.SQL(column, bind); sql != "(ROUND((quantity)::numeric, TRUNC(1)::int) IS NOT DISTINCT FROM ROUND((quantity)::numeric))" {
+		t.Errorf("expected rounding half away from zero, got %q", sql)
+	}
+
+	prog, _ = Compile(`customer.tier == "gold"`)
+	if _, ok := prog.SQL(column, bind); ok {
+		t.Errorf("expected unmapped field to block translation")
+	}
+}
@@ -0,0 +1,257 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// function is a built-in. Every built-in is pure; nil arguments generally yield nil.
+type function struct {
+	minArgs int
+	maxArgs int // -1 for variadic
+	eval    func(args []interface{}, env Env) (interface{}, error)
+	sql     func(args []string) string // nil when there is no SQL equivalent
+}
+
+var functions map[string]function
+
+func init() {
+	functions = map[string]function{
+		// Numbers
+		"abs":   {1, 1, numeric1(math.Abs), sqlFunc("ABS")},
+		"floor": {1, 1, numeric1(math.Floor), sqlFunc("FLOOR")},
+		"ceil":  {1, 1, numeric1(math.Ceil), sqlFunc("CEIL")},
+		// ROUND of numeric rounds half away from zero like math.Round, of float8 half to even
+		"round": {1, 2, roundFn, func(a []string) string {
+			if len(a) == 1 {
+				return fmt.Sprintf("ROUND((%s)::numeric)", a[0])
+			}
+			return fmt.Sprintf("ROUND((%s)::numeric, TRUNC(%s)::int)", a[0], a[1])
+		}},
+		"min":    {1, -1, extremum(-1), sqlFunc("LEAST")},
+		"max":    {1, -1, extremum(1), sqlFunc("GREATEST")},
+		"approx": {3, 3, approxFn, func(a []string) string { return approxSQL(a[0], a[1], a[2]) }},
+
+		// Strings
+		"len":         {1, 1, lenFn, sqlFunc("LENGTH")},
+		"lower":       {1, 1, string1(strings.ToLower), sqlFunc("LOWER")},
+		"upper":       {1, 1, string1(strings.ToUpper), sqlFunc("UPPER")},
+		"trim":        {1, 1, string1(strings.TrimSpace), sqlFunc("BTRIM")},
+		"contains":    {2, 2, string2(strings.Contains), func(a []string) string { return fmt.Sprintf("(POSITION(%s IN %s) > 0)", a[1], a[0]) }},
+		"starts_with": {2, 2, string2(strings.HasPrefix), sqlFunc("STARTS_WITH")},
+		"ends_with":   {2, 2, string2(strings.HasSuffix), func(a []string) string { return fmt.Sprintf("(RIGHT(%s, LENGTH(%s)) = %s)", a[0], a[1], a[1]) }},
+		"concat":      {1, -1, concatFn, sqlFunc("CONCAT")},
+
+		// Nulls
+		"coalesce": {1, -1, coalesceFn, sqlFunc("COALESCE")},
+		"is_null":  {1, 1, func(a []interface{}, _ Env) (interface{}, error) { return a[0] == nil, nil }, func(a []string) string { return fmt.Sprintf("(%s IS NULL)", a[0]) }},
+
+		// Dates
+		"now":          {0, 0, func(_ []interface{}, env Env) (interface{}, error) { return env.Now, nil }, func([]string) string { return "NOW()" }},
+		"date":         {1, 1, dateFn, func(a []string) string { return fmt.Sprintf("(%s)::timestamptz", a[0]) }},
+		"days_between": {2, 2, daysBetweenFn, func(a []string) string { return fmt.Sprintf("(EXTRACT(EPOCH FROM (%s) - (%s)) / 86400)", a[1], a[0]) }},
+		"year":         {1, 1, datePart(func(t time.Time) int { return t.Year() }), datePartSQL("YEAR")},
+		"month":        {1, 1, datePart(func(t time.Time) int { return int(t.Month()) }), datePartSQL("MONTH")},
+		"day":          {1, 1, datePart(func(t time.Time) int { return t.Day() }), datePartSQL("DAY")},
+	}
+}
+
+func sqlFunc(name string) func([]string) string {
+	return func(a []string) string {
+		return fmt.Sprintf("%s(%s)", name, strings.Join(a, ", "))
+	}
+}
+
+func datePartSQL(part string) func([]string) string {
+	return func(a []string) string {
+		return fmt.Sprintf("EXTRACT(%s FROM %s)", part, a[0])
+	}
+}
+
+func numeric1(fn func(float64) float64) func([]interface{}, Env) (interface{}, error) {
+	return func(a []interface{}, _ Env) (interface{}, error) {
+		if a[0] == nil {
+			return nil, nil
+		}
+		f, ok := a[0].(float64)
+		if !ok {
+			return nil, fmt.Errorf("expected a number, got %T", a[0])
+		}
+		return fn(f), nil
+	}
+}
+
+func roundFn(a []interface{}, env Env) (interface{}, error) {
+	if len(a) == 1 {
+		return numeric1(math.Round)(a, env)
+	}
+	if a[0] == nil || a[1] == nil {
+		return nil, nil
+	}
+	f, ok1 := a[0].(float64)
+	places, ok2 := a[1].(float64)
+	if !ok1 || !ok2 {
+		return nil, fmt.Errorf("round expects numbers")
+	}
+	scale := math.Pow(10, math.Trunc(places))
+	return math.Round(f*scale) / scale, nil
+}
+
+// extremum ignores nil arguments, like LEAST/GREATEST
+func extremum(sign int) func([]interface{}, Env) (interface{}, error) {
+	return func(a []interface{}, _ Env) (interface{}, error) {
+		var best interface{}
+		for _, v := range a {
+			if v == nil {
+				continue
+			}
+			if best == nil {
+				best = v
+				continue
+			}
+			c, ok := compare(v, best)
+			if !ok {
+				return nil, fmt.Errorf("cannot compare %T and %T", v, best)
+			}
+			if c == sign {
+				best = v
+			}
+		}
+		return best, nil
+	}
+}
+
+// approxSQL is false rather than NULL when an operand is null, like approxFn
+func approxSQL(x, y, tol string) string {
+	return fmt.Sprintf("COALESCE(ABS(%s - %s) <= %s, FALSE)", x, y, tol)
+}
+
+func approxFn(a []interface{}, _ Env) (interface{}, error) {
+	if a[0] == nil || a[1] == nil || a[2] == nil {
+		return false, nil
+	}
+	x, ok1 := a[0].(float64)
+	y, ok2 := a[1].(float64)
+	tol, ok3 := a[2].(float64)
+	if !ok1 || !ok2 || !ok3 {
+		return nil, fmt.Errorf("approx expects numbers")
+	}
+	return math.Abs(x-y) <= tol, nil
+}
+
+func lenFn(a []interface{}, _ Env) (interface{}, error) {
+	if a[0] == nil {
+		return nil, nil
+	}
+	s, ok := a[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("len expects a string, got %T", a[0])
+	}
+	return float64(utf8.RuneCountInString(s)), nil
+}
+
+func string1(fn func(string) string) func([]interface{}, Env) (interface{}, error) {
+	return func(a []interface{}, _ Env) (interface{}, error) {
+		if a[0] == nil {
+			return nil, nil
+		}
+		s, ok := a[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("expected a string, got %T", a[0])
+		}
+		return fn(s), nil
+	}
+}
+
+func string2(fn func(string, string) bool) func([]interface{}, Env) (interface{}, error) {
+	return func(a []interface{}, _ Env) (interface{}, error) {
+		if a[0] == nil || a[1] == nil {
+			return nil, nil
+		}
+		s, ok1 := a[0].(string)
+		t, ok2 := a[1].(string)
+		if !ok1 || !ok2 {
+			return nil, fmt.Errorf("expected strings, got %T and %T", a[0], a[1])
+		}
+		return fn(s, t), nil
+	}
+}
+
+// concatFn treats nil as "", like CONCAT
+func concatFn(a []interface{}, _ Env) (interface{}, error) {
+	var b strings.Builder
+	for _, v := range a {
+		switch v := v.(type) {
+		case nil:
+		case string:
+			b.WriteString(v)
+		case float64:
+			b.WriteString(strconv.FormatFloat(v, 'f', -1, 64))
+		default:
+			fmt.Fprint(&b, v)
+		}
+		if b.Len() > MaxStringLen {
+			return nil, fmt.Errorf("concat result longer than %d bytes", MaxStringLen)
+		}
+	}
+	return b.String(), nil
+}
+
+func coalesceFn(a []interface{}, _ Env) (interface{}, error) {
+	for _, v := range a {
+		if v != nil {
+			return v, nil
+		}
+	}
+	return nil, nil
+}
+
+// Layouts accepted by date(), tried in order
+var dateLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02"}
+
+func dateFn(a []interface{}, _ Env) (interface{}, error) {
+	switch v := a[0].(type) {
+	case nil:
+		return nil, nil
+	case time.Time:
+		return v, nil
+	case string:
+		for _, layout := range dateLayouts {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t, nil
+			}
+		}
+		return nil, fmt.Errorf("cannot parse %q as a date", v)
+	default:
+		return nil, fmt.Errorf("date expects a string, got %T", v)
+	}
+}
+
+func daysBetweenFn(a []interface{}, _ Env) (interface{}, error) {
+	if a[0] == nil || a[1] == nil {
+		return nil, nil
+	}
+	from, ok1 := a[0].(time.Time)
+	to, ok2 := a[1].(time.Time)
+	if !ok1 || !ok2 {
+		return nil, fmt.Errorf("days_between expects dates, use date()")
+	}
+	return to.Sub(from).Hours() / 24, nil
+}
+
+func datePart(fn func(time.Time) int) func([]interface{}, Env) (interface{}, error) {
+	return func(a []interface{}, _ Env) (interface{}, error) {
+		if a[0] == nil {
+			return nil, nil
+		}
+		t, ok := a[0].(time.Time)
+		if !ok {
+			return nil, fmt.Errorf("expected a date, got %T", a[0])
+		}
+		return float64(fn(t)), nil
+	}
+}
@@ -0,0 +1,101 @@
+package expr
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// SQL translates the program into a SQL boolean expression.
+// column maps a field path ("$" for the rule's own field) to a column expression and bind
+// registers a string argument, returning its placeholder. ok is false when a function has no
+// SQL equivalent or a field cannot be mapped; callers then keep the rule in memory.
+func (p *Program) SQL(column func(path string) (string, bool), bind func(val interface{}) string) (string, bool) {
+	return toSQL(p.root, column, bind)
+}
+
+func toSQL(n node, column func(string) (string, bool), bind func(interface{}) string) (string, bool) {
+	switch n := n.(type) {
+	case literal:
+		switch v := n.val.(type) {
+		case nil:
+			return "NULL", true
+		case bool:
+			if v {
+				return "TRUE", true
+			}
+			return "FALSE", true
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64), true
+		default:
+			return bind(v), true
+		}
+
+	case fieldRef:
+		return column(n.path)
+
+	case currentValue:
+		return column("$")
+
+	case unaryOp:
+		x, ok := toSQL(n.x, column, bind)
+		if !ok {
+			return "", false
+		}
+		if n.op == "!" {
+			return fmt.Sprintf("(NOT %s)", x), true
+		}
+		return fmt.Sprintf("(-%s)", x), true
+
+	case binaryOp:
+		l, ok := toSQL(n.l, column, bind)
+		if !ok {
+			return "", false
+		}
+		r, ok := toSQL(n.r, column, bind)
+		if !ok {
+			return "", false
+		}
+		op := n.op
+		switch op {
+		case "/", "%":
+			// As in Eval: decimal division, and null rather than an error on zero
+			return fmt.Sprintf("((%s)::numeric %s NULLIF((%s)::numeric, 0))", l, op, r), true
+		case "&&":
+			op = "AND"
+		case "||":
+			op = "OR"
+		case "==":
+			op = "IS NOT DISTINCT FROM" // null == null holds, as in Eval
+		case "!=":
+			op = "IS DISTINCT FROM"
+		}
+		return fmt.Sprintf("(%s %s %s)", l, op, r), true
+
+	case approxEq:
+		args := make([]string, 3)
+		for i, x := range []node{n.l, n.r, n.tol} {
+			s, ok := toSQL(x, column, bind)
+			if !ok {
+				return "", false
+			}
+			args[i] = s
+		}
+		return approxSQL(args[0], args[1], args[2]), true
+
+	case call:
+		fn := functions[n.fn]
+		if fn.sql == nil {
+			return "", false
+		}
+		args := make([]string, len(n.args))
+		for i, a := range n.args {
+			s, ok := toSQL(a, column, bind)
+			if !ok {
+				return "", false
+			}
+			args[i] = s
+		}
+		return fn.sql(args), true
+	}
+	return "", false
+}
@@ -0,0 +1,184 @@
+// Package fieldpath resolves rule field paths against nested records.
+//
+// Segments are separated by ".", array elements are addressed with "[n]" and every element
+// with "[*]", e.g. "customer.address.zip", "items[0].sku" or "items[*].price".
+// A record key that literally equals the whole path always wins, so flat keys containing
+// dots keep working.
+package fieldpath
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Segment is one step of a path: an object key, an array index or an array wildcard
+type Segment struct {
+	Key      string // object key, empty for array segments
+	Index    int    // array index when Key is empty and Wildcard is false
+	Wildcard bool   // every array element
+}
+
+// Path is a parsed field path
+type Path []Segment
+
+// Match is a single value a path resolved to
+type Match struct {
+	Path  string      // concrete path, wildcards replaced by element indexes
+	Value interface{} // nil when not found
+	Found bool        // false when the path does not exist in the record
+}
+
+// Parse parses a path expression. Paths come from requests, so nothing is cached by them.
+func Parse(path string) (Path, error) {
+	if path == "" {
+		return nil, fmt.Errorf("empty path")
+	}
+
+	var p Path
+	for _, part := range strings.Split(path, ".") {
+		key := part
+		brackets := ""
+		if i := strings.IndexByte(part, '['); i >= 0 {
+			key, brackets = part[:i], part[i:]
+		}
+		if key == "" && (brackets == "" || len(p) == 0) {
+			return nil, fmt.Errorf("invalid path %q: empty segment", path)
+		}
+		if key != "" {
+			p = append(p, Segment{Key: key})
+		}
+
+		for brackets != "" {
+			end := strings.IndexByte(brackets, ']')
+			if brackets[0] != '[' || end < 0 {
+				return nil, fmt.Errorf("invalid path %q: malformed index", path)
+			}
+			idx := brackets[1:end]
+			if idx == "*" {
+				p = append(p, Segment{Wildcard: true})
+			} else {
+				n, err := strconv.Atoi(idx)
+				if err != nil || n < 0 {
+					return nil, fmt.Errorf("invalid path %q: bad index %q", path, idx)
+				}
+				p = append(p, Segment{Index: n})
+			}
+			brackets = brackets[end+1:]
+		}
+	}
+
+	return p, nil
+}
+
+// HasWildcard reports whether the path can resolve to several values
+func (p Path) HasWildcard() bool {
+	for _, s := range p {
+		if s.Wildcard {
+			return true
+		}
+	}
+	return false
+}
+
+// IsNested reports whether the path goes below a top-level key
+func (p Path) IsNested() bool {
+	return len(p) > 1
+}
+
+// HasWildcard parses path and reports whether it contains "[*]".
+// Unparseable paths are treated as flat keys.
+func HasWildcard(path string) bool {
+	p, err := Parse(path)
+	return err == nil && p.HasWildcard()
+}
+
+// Resolve returns every value path points to in record.
+// A path without wildcards yields exactly one Match. A wildcard yields one Match per element
+// (none for an empty array); a missing array yields a single Match with Found false.
+func Resolve(record map[string]interface{}, path string) []Match {
+	if val, ok := record[path]; ok {
+		return []Match{{Path: path, Value: val, Found: true}}
+	}
+
+	p, err := Parse(path)
+	if err != nil {
+		// Not a path expression, so a plain (missing) key
+		return []Match{{Path: path}}
+	}
+
+	var matches []Match
+	walk(record, p, "", &matches)
+	return matches
+}
+
+// Get returns the single value path points to, or the first one for wildcard paths
+func Get(record map[string]interface{}, path string) (interface{}, bool) {
+	matches := Resolve(record, path)
+	if len(matches) == 0 {
+		return nil, false
+	}
+	return matches[0].Value, matches[0].Found
+}
+
+func walk(node interface{}, p Path, prefix string, matches *[]Match) {
+	if len(p) == 0 {
+		*matches = append(*matches, Match{Path: prefix, Value: node, Found: true})
+		return
+	}
+
+	seg := p[0]
+	switch {
+	case seg.Key != "":
+		next := seg.Key
+		if prefix != "" {
+			next = prefix + "." + seg.Key
+		}
+		obj, ok := node.(map[string]interface{})
+		if !ok {
+			*matches = append(*matches, Match{Path: next + p[1:].String()})
+			return
+		}
+		val, ok := obj[seg.Key]
+		if !ok {
+			*matches = append(*matches, Match{Path: next + p[1:].String()})
+			return
+		}
+		walk(val, p[1:], next, matches)
+
+	case seg.Wildcard:
+		arr, ok := node.([]interface{})
+		if !ok {
+			*matches = append(*matches, Match{Path: prefix + p.String()})
+			return
+		}
+		for i, elem := range arr {
+			walk(elem, p[1:], fmt.Sprintf("%s[%d]", prefix, i), matches)
+		}
+
+	default:
+		next := fmt.Sprintf("%s[%d]", prefix, seg.Index)
+		arr, ok := node.([]interface{})
+		if !ok || seg.Index >= len(arr) {
+			*matches = append(*matches, Match{Path: next + p[1:].String()})
+			return
+		}
+		walk(arr[seg.Index], p[1:], next, matches)
+	}
+}
+
+// String renders the path back to its expression form (relative paths start with "." or "[")
+func (p Path) String() string {
+	var b strings.Builder
+	for _, s := range p {
+		switch {
+		case s.Key != "":
+			b.WriteString("." + s.Key)
+		case s.Wildcard:
+			b.WriteString("[*]")
+		default:
+			fmt.Fprintf(&b, "[%d]", s.Index)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,67 @@
+package fieldpath
+
+import (
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	record := map[string]interface{}{
+		"customer": map[string]interface{}{
+			"address": map[string]interface{}{"zip": "10001"},
+		},
+		"items": []interface{}{
+			map[string]interface{}{"price": 10.0},
+			map[string]interface{}{"sku": "B"},
+		},
+		"empty":    []interface{}{},
+		"flat.key": "literal",
+	}
+
+	tests := []struct {
+		name string
+		path string
+		want []Match
+	}{
+		{"nested_object", "customer.address.zip", []Match{{Path: "customer.address.zip", Value: "10001", Found: true}}},
+		{"missing_nested", "customer.phone", []Match{{Path: "customer.phone"}}},
+		{"array_index", "items[0].price", []Match{{Path: "items[0].price", Value: 10.0, Found: true}}},
+		{"index_out_of_range", "items[5].price", []Match{{Path: "items[5].price"}}},
+		{"wildcard", "items[*].price", []Match{
+			{Path: "items[0].price", Value: 10.0, Found: true},
+			{Path: "items[1].price"},
+		}},
+		{"wildcard_empty_array", "empty[*].x", nil},
+		{"wildcard_missing_array", "orders[*].id", []Match{{Path: "orders[*].id"}}},
+		{"literal_key_wins", "flat.key", []Match{{Path: "flat.key", Value: "literal", Found: true}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Resolve(record, tt.path)
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %d matches, got %+v", len(tt.want), got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("match %d: expected %+v, got %+v", i, tt.want[i], got[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	valid := []string{"a", "a.b", "a[0]", "a[*].b", "a[0][1].c"}
+	for _, p := range valid {
+		if _, err := Parse(p); err != nil {
+			t.Errorf("expected %q to parse, got %v", p, err)
+		}
+	}
+
+	invalid := []string{"", "a..b", "[0]", "a[x]", "a[-1]", "a[0"}
+	for _, p := range invalid {
+		if _, err := Parse(p); err == nil {
+			t.Errorf("expected %q to be rejected", p)
+		}
+	}
+}
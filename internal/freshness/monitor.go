@@ -0,0 +1,152 @@
+// Package freshness watches that sources keep delivering data. A source that has not been
+// ingested within its expected interval gets a FAIL run (and an alert) even though no data arrived.
+package freshness
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/singh-anurag-7991/data-guard/internal/domain"
+	"github.com/singh-anurag-7991/data-guard/internal/storage"
+)
+
+// RuleID is the rule id of the error recorded in overdue runs
+const RuleID = "freshness"
+
+// historyLimit is how many recent runs are searched for the last ingest
+const historyLimit = 50
+
+// Expectation is how often a source is expected to deliver data
+type Expectation struct {
+	SourceID string
+	Interval time.Duration
+}
+
+// ParseExpectations reads expectations written as "orders=1h,payments=15m"
+func ParseExpectations(s string) ([]Expectation, error) {
+	var expectations []Expectation
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		source, interval, ok := strings.Cut(part, "=")
+		if !ok || source == "" {
+			return nil, fmt.Errorf("invalid expectation %q, want source=interval", part)
+		}
+		d, err := time.ParseDuration(interval)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid interval for %s: %q", source, interval)
+		}
+		expectations = append(expectations, Expectation{SourceID: source, Interval: d})
+	}
+	return expectations, nil
+}
+
+// Alerter raises alerts for runs (satisfied by *alerting.Manager)
+type Alerter interface {
+	ProcessResult(res domain.ValidationResult) error
+}
+
+// Monitor records overdue runs for sources that stopped delivering data
+type Monitor struct {
+	repo         storage.Provider
+	alerts       Alerter // may be nil
+	expectations []Expectation
+	started      time.Time // sources never ingested are measured from here
+}
+
+func NewMonitor(repo storage.Provider, alerts Alerter, expectations []Expectation) *Monitor {
+	return &Monitor{
+		repo:         repo,
+		alerts:       alerts,
+		expectations: expectations,
+		started:      time.Now(),
+	}
+}
+
+// Run checks every interval until ctx is done
+func (m *Monitor) Run(ctx context.Context, every time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if _, err := m.Check(ctx, now); err != nil {
+				slog.Error("Freshness check failed", "error", err)
+			}
+		}
+	}
+}
+
+// Check records a FAIL run for every overdue source and returns them.
+// While a source stays overdue it is reported again once per interval, not on every check.
+func (m *Monitor) Check(ctx context.Context, now time.Time) ([]domain.ValidationResult, error) {
+	var overdue []domain.ValidationResult
+	for _, exp := range m.expectations {
+		runs, err := m.repo.GetRecentRuns(ctx, exp.SourceID, historyLimit)
+		if err != nil {
+			return overdue, fmt.Errorf("failed to fetch runs of %s: %w", exp.SourceID, err)
+		}
+
+		// Runs are newest first
+		var lastIngest, lastOverdue time.Time
+		for _, r := range runs {
+			if r.Trigger == domain.TriggerFreshness {
+				if lastOverdue.IsZero() {
+					lastOverdue = r.Timestamp
+				}
+			} else if lastIngest.IsZero() {
+				lastIngest = r.Timestamp
+				break
+			}
+		}
+
+		since := lastIngest
+		if since.IsZero() {
+			since = m.started
+		}
+		if now.Sub(since) <= exp.Interval {
+			continue
+		}
+		if lastOverdue.After(since) && now.Sub(lastOverdue) < exp.Interval {
+			continue // Already reported for this interval
+		}
+
+		res := overdueResult(exp, lastIngest, now.Sub(since).Truncate(time.Second), now)
+		if err := m.repo.SaveResult(ctx, res); err != nil {
+			return overdue, fmt.Errorf("failed to save overdue run of %s: %w", exp.SourceID, err)
+		}
+		if m.alerts != nil {
+			if err := m.alerts.ProcessResult(res); err != nil {
+				slog.Error("Failed to alert on overdue source", "source_id", exp.SourceID, "error", err)
+			}
+		}
+		overdue = append(overdue, res)
+	}
+	return overdue, nil
+}
+
+func overdueResult(exp Expectation, lastIngest time.Time, late time.Duration, now time.Time) domain.ValidationResult {
+	reason := fmt.Sprintf("no data ingested for %s (expected every %s)", late, exp.Interval)
+	if lastIngest.IsZero() {
+		reason = fmt.Sprintf("no data ingested since monitoring started %s ago (expected every %s)", late, exp.Interval)
+	}
+	return domain.ValidationResult{
+		SourceID:    exp.SourceID,
+		Status:      domain.StatusFail,
+		Trigger:     domain.TriggerFreshness,
+		RulesFailed: 1,
+		Errors: []domain.ErrorDetail{{
+			RuleID:   RuleID,
+			Reason:   reason,
+			Severity: domain.SeverityError,
+		}},
+		Timestamp: now,
+	}
+}
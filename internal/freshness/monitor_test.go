@@ -0,0 +1,79 @@
+package freshness
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/singh-anurag-7991/data-guard/internal/domain"
+	"github.com/singh-anurag-7991/data-guard/internal/storage"
+)
+
+type recordingAlerter struct {
+	results []domain.ValidationResult
+}
+
+func (r *recordingAlerter) ProcessResult(res domain.ValidationResult) error {
+	r.results = append(r.results, res)
+	return nil
+}
+
+func TestParseExpectations(t *testing.T) {
+	got, err := ParseExpectations(" orders=1h, payments=15m ,")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 2 || got[0] != (Expectation{"orders", time.Hour}) || got[1] != (Expectation{"payments", 15 * time.Minute}) {
+		t.Errorf("unexpected expectations: %+v", got)
+	}
+
+	for _, bad := range []string{"orders", "orders=soon", "=1h", "orders=-1h"} {
+		if _, err := ParseExpectations(bad); err == nil {
+			t.Errorf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestMonitor_Check(t *testing.T) {
+	ctx := context.Background()
+	repo := storage.NewMemoryStore()
+	alerts := &recordingAlerter{}
+	m := NewMonitor(repo, alerts, []Expectation{{"orders", time.Hour}, {"payments", time.Hour}})
+
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	m.started = start
+	_ = repo.SaveResult(ctx, domain.ValidationResult{SourceID: "orders", Status: domain.StatusPass, Timestamp: start})
+
+	// Within the interval nothing is overdue
+	if overdue, _ := m.Check(ctx, start.Add(30*time.Minute)); len(overdue) != 0 {
+		t.Fatalf("expected nothing overdue yet, got %+v", overdue)
+	}
+
+	// Both sources lapse: orders since its last run, payments since monitoring started
+	overdue, err := m.Check(ctx, start.Add(90*time.Minute))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(overdue) != 2 || len(alerts.results) != 2 {
+		t.Fatalf("expected both sources overdue and alerted, got %+v", overdue)
+	}
+	if r := overdue[0]; r.Status != domain.StatusFail || r.Trigger != domain.TriggerFreshness ||
+		r.Errors[0].Reason != "no data ingested for 1h30m0s (expected every 1h0m0s)" {
+		t.Errorf("unexpected overdue run: %+v", r)
+	}
+
+	// Still overdue a minute later, but already reported for this interval
+	if overdue, _ := m.Check(ctx, start.Add(91*time.Minute)); len(overdue) != 0 {
+		t.Errorf("expected no repeat within the interval, got %+v", overdue)
+	}
+	if overdue, _ := m.Check(ctx, start.Add(151*time.Minute)); len(overdue) != 2 {
+		t.Errorf("expected a reminder after another interval, got %+v", overdue)
+	}
+
+	// Data arrives again
+	_ = repo.SaveResult(ctx, domain.ValidationResult{SourceID: "orders", Status: domain.StatusPass, Timestamp: start.Add(160 * time.Minute)})
+	overdue, _ = m.Check(ctx, start.Add(170*time.Minute))
+	if len(overdue) != 0 {
+		t.Errorf("expected orders to be fresh again and payments already reported, got %+v", overdue)
+	}
+}
@@ -85,6 +85,30 @@ func (c *Client) FetchRows(ctx context.Context, query string, args ...interface{
 	return records, nil
 }
 
+// PrimaryKey returns the primary key columns of a table in key order (empty if it has none)
+func (c *Client) PrimaryKey(ctx context.Context, table string) ([]string, error) {
+	rows, err := c.pool.Query(ctx, `
+		SELECT a.attname
+		FROM pg_index i
+		JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = ANY(i.indkey)
+		WHERE i.indrelid = $1::regclass AND i.indisprimary
+		ORDER BY array_position(i.indkey::int2[], a.attnum)`, table)
+	if err != nil {
+		return nil, fmt.Errorf("primary key query failed: %w", err)
+	}
+	defer rows.Close()
+
+	var columns []string
+	for rows.Next() {
+		var col string
+		if err := rows.Scan(&col); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		columns = append(columns, col)
+	}
+	return columns, rows.Err()
+}
+
 // ValidateViaSQL executes a generated failure query and returns the FAILING records
 func (c *Client) ValidateViaSQL(ctx context.Context, query string, args []interface{}) ([]domain.Record, error) {
 	return c.FetchRows(ctx, query, args...)
@@ -0,0 +1,286 @@
+package operators
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"reflect"
+	"regexp"
+	"strconv"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Numbers arrive as int and float64 from Go callers and encoding/json, json.Number from
+// decoders using UseNumber, and as int16, int32, int64, float32, float64 or pgtype.Numeric
+// from pgx. Every one of them is a number here, compared by its exact decimal value: float64
+// values count as the decimal they print as, so 0.1 equals a numeric 0.1.
+
+// number is a numeric value: f, exact unless rat holds the exact value
+type number struct {
+	f   float64
+	rat *big.Rat
+}
+
+// maxSafeInt is the largest magnitude below which every integer is exact as a float64
+const maxSafeInt = 1 << 53
+
+func toNumber(i interface{}) (number, bool) {
+	switch v := i.(type) {
+	case int:
+		return intNumber(int64(v)), true
+	case int8:
+		return intNumber(int64(v)), true
+	case int16:
+		return intNumber(int64(v)), true
+	case int32:
+		return intNumber(int64(v)), true
+	case int64:
+		return intNumber(v), true
+	case uint:
+		return uintNumber(uint64(v)), true
+	case uint8:
+		return uintNumber(uint64(v)), true
+	case uint16:
+		return uintNumber(uint64(v)), true
+	case uint32:
+		return uintNumber(uint64(v)), true
+	case uint64:
+		return uintNumber(v), true
+	case float32:
+		// The float64 nearest to what the float32 prints as, so float32(0.1) is 0.1
+		f, _ := strconv.ParseFloat(strconv.FormatFloat(float64(v), 'g', -1, 32), 64)
+		return number{f: f}, true
+	case float64:
+		return number{f: v}, true
+	case json.Number:
+		return parseNumber(string(v))
+	case pgtype.Numeric:
+		return numericNumber(v)
+	}
+	return number{}, false
+}
+
+func intNumber(i int64) number {
+	if i > -maxSafeInt && i < maxSafeInt {
+		return number{f: float64(i)}
+	}
+	return number{f: float64(i), rat: new(big.Rat).SetInt64(i)}
+}
+
+func uintNumber(u uint64) number {
+	if u < maxSafeInt {
+		return number{f: float64(u)}
+	}
+	return number{f: float64(u), rat: new(big.Rat).SetUint64(u)}
+}
+
+// numberPattern is the decimal syntax of numeric strings and json.Number: no hex, underscores,
+// infinities or NaN, and exponents of at most three digits
+var numberPattern = regexp.MustCompile(`^[+-]?([0-9]+\.?[0-9]*|\.[0-9]+)([eE][+-]?[0-9]{1,3})?$`)
+
+func parseNumber(s string) (number, bool) {
+	if !numberPattern.MatchString(s) {
+		return number{}, false
+	}
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return number{}, false
+	}
+	return ratNumber(r), true
+}
+
+func numericNumber(n pgtype.Numeric) (number, bool) {
+	switch {
+	case !n.Valid:
+		return number{}, false
+	case n.NaN:
+		return number{f: math.NaN()}, true
+	case n.InfinityModifier == pgtype.Infinity:
+		return number{f: math.Inf(1)}, true
+	case n.InfinityModifier == pgtype.NegativeInfinity:
+		return number{f: math.Inf(-1)}, true
+	case n.Int == nil:
+		return number{}, true
+	}
+	r := new(big.Rat).SetInt(n.Int)
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(n.Exp))), nil)
+	if n.Exp < 0 {
+		r.Quo(r, new(big.Rat).SetInt(scale))
+	} else {
+		r.Mul(r, new(big.Rat).SetInt(scale))
+	}
+	return ratNumber(r), true
+}
+
+// ratNumber keeps the rational only when no float64 prints as it
+func ratNumber(r *big.Rat) number {
+	f, _ := r.Float64()
+	if d := decimal(f); d != nil && d.Cmp(r) == 0 {
+		return number{f: f}
+	}
+	return number{f: f, rat: r}
+}
+
+func abs(i int32) int32 {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
+
+// exact returns the decimal value, nil for infinities and NaN
+func (n number) exact() *big.Rat {
+	if n.rat != nil {
+		return n.rat
+	}
+	return decimal(n.f)
+}
+
+// cmp orders two numbers; ok is false when either is NaN
+func (n number) cmp(o number) (int, bool) {
+	if math.IsNaN(n.f) || math.IsNaN(o.f) {
+		return 0, false
+	}
+	if (n.rat == nil && o.rat == nil) || math.IsInf(n.f, 0) || math.IsInf(o.f, 0) {
+		switch {
+		case n.f < o.f:
+			return -1, true
+		case n.f > o.f:
+			return 1, true
+		}
+		return 0, true
+	}
+	return n.exact().Cmp(o.exact()), true
+}
+
+// isInt reports whether n has no fractional part; infinities and NaN are not integers
+func (n number) isInt() bool {
+	if n.rat != nil {
+		return n.rat.IsInt()
+	}
+	return !math.IsInf(n.f, 0) && n.f == math.Trunc(n.f)
+}
+
+// places counts the digits after the decimal point, trailing zeros aside
+func (n number) places() int {
+	if n.rat == nil {
+		return decimalPlaces(n.f)
+	}
+	r := new(big.Rat).Set(n.rat)
+	ten := big.NewRat(10, 1)
+	places := 0
+	for !r.IsInt() {
+		r.Mul(r, ten)
+		places++
+	}
+	return places
+}
+
+func (n number) String() string {
+	if n.rat == nil {
+		return strconv.FormatFloat(n.f, 'g', -1, 64)
+	}
+	return n.rat.FloatString(n.places())
+}
+
+// decimal returns the decimal a float64 prints as, so 0.1 is exactly one tenth; nil for
+// infinities and NaN
+func decimal(f float64) *big.Rat {
+	r, ok := new(big.Rat).SetString(strconv.FormatFloat(f, 'g', -1, 64))
+	if !ok {
+		return nil
+	}
+	return r
+}
+
+// decimalPlaces counts the digits after the decimal point of the shortest decimal form of f
+func decimalPlaces(f float64) int {
+	s := strconv.FormatFloat(f, 'f', -1, 64)
+	for i := range s {
+		if s[i] == '.' {
+			return len(s) - i - 1
+		}
+	}
+	return 0
+}
+
+// ToFloat converts a number of any of the types above to float64, rounding exact decimals
+func ToFloat(i interface{}) (float64, bool) {
+	n, ok := toNumber(i)
+	return n.f, ok
+}
+
+// FormatDecimal writes a number in plain decimal notation ("1250000", "0.001"), exactly;
+// ok is false for infinities and NaN
+func FormatDecimal(i interface{}) (string, bool) {
+	n, ok := toNumber(i)
+	if !ok || math.IsNaN(n.f) || math.IsInf(n.f, 0) {
+		return "", false
+	}
+	if n.rat == nil {
+		return strconv.FormatFloat(n.f, 'f', -1, 64), true
+	}
+	return n.rat.FloatString(n.places()), true
+}
+
+// NumericString returns a string in decimal syntax ("12.50", "-3", "1e6") as a json.Number,
+// which every operator reads as a number
+func NumericString(i interface{}) (json.Number, bool) {
+	s, ok := i.(string)
+	if !ok || !numberPattern.MatchString(s) {
+		return "", false
+	}
+	return json.Number(s), true
+}
+
+// Compare orders two numbers by their exact decimal values; ok is false unless both are
+// numbers (numeric strings too, with numericStrings)
+func Compare(a, b interface{}, numericStrings bool) (int, bool) {
+	x, ok := readNumber(a, numericStrings)
+	if !ok {
+		return 0, false
+	}
+	y, ok := readNumber(b, numericStrings)
+	if !ok {
+		return 0, false
+	}
+	return x.cmp(y)
+}
+
+// Equal compares numbers by value whatever their type (numeric strings too, with
+// numericStrings), other values with ==. Arrays and objects never equal anything.
+func Equal(a, b interface{}, numericStrings bool) bool {
+	if c, ok := Compare(a, b, numericStrings); ok {
+		return c == 0
+	}
+	_, aNumber := readNumber(a, numericStrings)
+	_, bNumber := readNumber(b, numericStrings)
+	if aNumber || bNumber {
+		return false // 1 is not "1" unless numeric strings are numbers
+	}
+	ta, tb := reflect.TypeOf(a), reflect.TypeOf(b)
+	if ta != tb || (ta != nil && !ta.Comparable()) {
+		return false // Comparing JSON arrays or objects would panic
+	}
+	return a == b
+}
+
+// IsInteger reports whether a number has no fractional part, by its exact decimal value, so
+// "1.0000000000000000001" is not an integer; ok is false unless i is a number (numeric
+// strings too, with numericStrings)
+func IsInteger(i interface{}, numericStrings bool) (isInt bool, ok bool) {
+	n, ok := readNumber(i, numericStrings)
+	if !ok {
+		return false, false
+	}
+	return n.isInt(), true
+}
+
+// readNumber reads a number, or with numericStrings also a numeric string
+func readNumber(i interface{}, numericStrings bool) (number, bool) {
+	if s, ok := i.(string); ok && numericStrings {
+		return parseNumber(s)
+	}
+	return toNumber(i)
+}
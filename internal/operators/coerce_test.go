@@ -0,0 +1,143 @@
+package operators
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func numeric(t *testing.T, s string) pgtype.Numeric {
+	t.Helper()
+	var n pgtype.Numeric
+	if err := n.Scan(s); err != nil {
+		t.Fatalf("invalid numeric %s: %v", s, err)
+	}
+	return n
+}
+
+func TestToFloat(t *testing.T) {
+	tests := []struct {
+		name string
+		val  interface{}
+		want float64
+		ok   bool
+	}{
+		{"int", 3, 3, true},
+		{"int16", int16(-7), -7, true},
+		{"int32", int32(42), 42, true},
+		{"uint64", uint64(9), 9, true},
+		{"float32", float32(0.1), 0.1, true},
+		{"json_number", json.Number("12.50"), 12.5, true},
+		{"numeric", numeric(t, "1234.5678"), 1234.5678, true},
+		{"invalid_numeric", pgtype.Numeric{}, 0, false},
+		{"string", "12", 0, false},
+		{"malformed_json_number", json.Number("0x10"), 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ToFloat(tt.val)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("ToFloat(%#v) = %v, %v; want %v, %v", tt.val, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name           string
+		a, b           interface{}
+		numericStrings bool
+		want           int
+		ok             bool
+	}{
+		{"int_float", 100, 100.0, false, 0, true},
+		{"numeric_float", numeric(t, "0.10"), 0.1, false, 0, true},
+		{"numeric_beyond_float", numeric(t, "0.1000000000000000000001"), 0.1, false, 1, true},
+		{"large_int64", int64(1<<53 + 1), float64(1 << 53), false, 1, true},
+		{"json_number_int32", json.Number("-3"), int32(-2), false, -1, true},
+		{"string", "5", 5, false, 0, false},
+		{"numeric_string", "5.0", 5, true, 0, true},
+		{"malformed_string", "5,0", 5, true, 0, false},
+		{"nan", numeric(t, "NaN"), 1, false, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Compare(tt.a, tt.b, tt.numericStrings)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("Compare(%#v, %#v) = %v, %v; want %v, %v", tt.a, tt.b, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestEqual(t *testing.T) {
+	tests := []struct {
+		name           string
+		a, b           interface{}
+		numericStrings bool
+		want           bool
+	}{
+		{"int_float", 100, 100.0, false, true},
+		{"int32_json_number", int32(7), json.Number("7.00"), false, true},
+		{"number_string", 1, "1", false, false},
+		{"number_numeric_string", 1, "1", true, true},
+		{"strings", "abc", "abc", true, true},
+		{"string_numbers", "007", "7", false, false},
+		{"bools", true, true, false, true},
+		{"nulls", nil, nil, false, true},
+		{"arrays", []interface{}{1}, []interface{}{1}, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Equal(tt.a, tt.b, tt.numericStrings); got != tt.want {
+				t.Errorf("Equal(%#v, %#v) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatDecimal(t *testing.T) {
+	tests := []struct {
+		val  interface{}
+		want string
+	}{
+		{1e21, "1000000000000000000000"},
+		{numeric(t, "12.50"), "12.5"},
+		{numeric(t, "12345678901234567890.123"), "12345678901234567890.123"},
+		{json.Number("-1e-3"), "-0.001"},
+	}
+
+	for _, tt := range tests {
+		if got, ok := FormatDecimal(tt.val); !ok || got != tt.want {
+			t.Errorf("FormatDecimal(%#v) = %q, want %q", tt.val, got, tt.want)
+		}
+	}
+}
+
+func TestIsInteger(t *testing.T) {
+	tests := []struct {
+		val            interface{}
+		numericStrings bool
+		want, ok       bool
+	}{
+		{int64(9007199254740993), false, true, true},
+		{2.0, false, true, true},
+		{json.Number("1.0000000000000000001"), false, false, true},
+		{numeric(t, "12345678901234567890.000"), false, true, true},
+		{math.Inf(1), false, false, true},
+		{"3", true, true, true},
+		{"3", false, false, false},
+	}
+
+	for _, tt := range tests {
+		if got, ok := IsInteger(tt.val, tt.numericStrings); got != tt.want || ok != tt.ok {
+			t.Errorf("IsInteger(%#v, %v) = %v, %v, want %v, %v", tt.val, tt.numericStrings, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package operators
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/singh-anurag-7991/data-guard/internal/domain"
+)
+
+func greaterThan(value interface{}, check domain.Check) (bool, string) {
+	return compareTo(value, check, func(c int) bool { return c > 0 }, "is not greater than")
+}
+
+func lessThan(value interface{}, check domain.Check) (bool, string) {
+	return compareTo(value, check, func(c int) bool { return c < 0 }, "is not less than")
+}
+
+func greaterOrEqual(value interface{}, check domain.Check) (bool, string) {
+	return compareTo(value, check, func(c int) bool { return c >= 0 }, "is less than")
+}
+
+func lessOrEqual(value interface{}, check domain.Check) (bool, string) {
+	return compareTo(value, check, func(c int) bool { return c <= 0 }, "is greater than")
+}
+
+// compareTo compares the number with the threshold Value, passing when pass holds for the
+// order of the two
+func compareTo(value interface{}, check domain.Check, pass func(int) bool, failure string) (bool, string) {
+	v, ok := readNumber(value, check.NumericStrings)
+	if !ok {
+		return false, "value is not a number"
+	}
+	t, ok := readNumber(check.Value, check.NumericStrings)
+	if !ok {
+		return false, "threshold is not a number"
+	}
+	c, ok := v.cmp(t)
+	if ok && pass(c) {
+		return true, ""
+	}
+	return false, fmt.Sprintf("value %v %s %v", v, failure, t)
+}
+
+func between(value interface{}, check domain.Check) (bool, string) {
+	if check.Min == nil && check.Max == nil {
+		return false, "between needs min or max"
+	}
+	v, ok := readNumber(value, check.NumericStrings)
+	if !ok {
+		return false, "value is not a number"
+	}
+	if check.Min != nil {
+		if c, ok := v.cmp(number{f: *check.Min}); !ok || c < 0 {
+			return false, fmt.Sprintf("value %v is below the minimum %v", v, *check.Min)
+		}
+	}
+	if check.Max != nil {
+		if c, ok := v.cmp(number{f: *check.Max}); !ok || c > 0 {
+			return false, fmt.Sprintf("value %v is above the maximum %v", v, *check.Max)
+		}
+	}
+	return true, ""
+}
+
+func isInteger(value interface{}, check domain.Check) (bool, string) {
+	v, ok := readNumber(value, check.NumericStrings)
+	if !ok {
+		return false, "value is not a number"
+	}
+	if v.isInt() {
+		return true, ""
+	}
+	return false, fmt.Sprintf("value %v is not an integer", v)
+}
+
+func multipleOf(value interface{}, check domain.Check) (bool, string) {
+	v, ok := readNumber(value, check.NumericStrings)
+	if !ok {
+		return false, "value is not a number"
+	}
+	m, ok := readNumber(check.Value, check.NumericStrings)
+	if c, positive := m.cmp(number{}); !ok || !positive || c <= 0 {
+		return false, "divisor is not a positive number"
+	}
+	// 0.3 is a multiple of 0.1 in decimal, as in SQL numeric, though not in binary floating point
+	dv, dm := v.exact(), m.exact()
+	if dv != nil && dm != nil && new(big.Rat).Quo(dv, dm).IsInt() {
+		return true, ""
+	}
+	return false, fmt.Sprintf("value %v is not a multiple of %v", v, m)
+}
+
+func precision(value interface{}, check domain.Check) (bool, string) {
+	v, ok := readNumber(value, check.NumericStrings)
+	if !ok {
+		return false, "value is not a number"
+	}
+	places, ok := ToFloat(check.Value)
+	if !ok || places < 0 {
+		return false, "decimal places is not a non-negative number"
+	}
+	if n := v.places(); float64(n) > places {
+		return false, fmt.Sprintf("value %v has %d decimal places, more than %v", v, n, places)
+	}
+	return true, ""
+}
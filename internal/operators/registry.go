@@ -3,6 +3,8 @@ package operators
 import (
 	"fmt"
 	"regexp"
+	"sort"
+	"sync"
 
 	"github.com/singh-anurag-7991/data-guard/internal/domain"
 )
@@ -10,74 +12,186 @@ import (
 // OperatorFunc defines the signature for a validation check
 type OperatorFunc func(value interface{}, check domain.Check) (bool, string)
 
-// Registry holds all available operators
-var Registry = map[string]OperatorFunc{
-	"not_null": notNull,
-	"eq":       equal,
-	"neq":      notEqual,
-	"gt":       greaterThan,
-	"lt":       lessThan,
-	"regex":    regexMatch,
-	"enum":     enumMatch,
+// SQLFunc translates a check into the SQL condition under which a row fails it, given the SQL
+// expression of the checked field. A condition ending in an operator ("amount <=") is completed
+// with a placeholder bound to arg; a nil arg binds nothing. "" means the check has no SQL form.
+type SQLFunc func(column string, check domain.Check) (cond string, arg interface{})
+
+// Arg describes a field of the check an operator reads
+type Arg struct {
+	Name        string `json:"name"` // JSON field of the check: "value", "value_field", "format", ...
+	Type        string `json:"type"` // "number", "string", "array", "duration" or "any"
+	Required    bool   `json:"required,omitempty"`
+	Description string `json:"description,omitempty"`
 }
 
-// Helper to get operator
-func Get(op string) (OperatorFunc, bool) {
-	fn, exists := Registry[op]
-	return fn, exists
+// Operator is a record check the executor can run
+type Operator struct {
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Args        []Arg        `json:"args,omitempty"`
+	NullSafe    bool         `json:"null_safe"` // Func handles null values; otherwise they fail without calling it
+	Func        OperatorFunc `json:"-"`
+	SQL         SQLFunc      `json:"-"` // Translation for pushdown; the optimizer translates the built-in operators itself
 }
 
-// --- Implementations ---
+// reserved are the checks the executor runs itself ("expr" is engine.ExprOp)
+var reserved = map[string]bool{"expr": true, domain.OpExistsIn: true}
 
-func notNull(value interface{}, check domain.Check) (bool, string) {
-	if value == nil {
-		return false, "value is null"
+// Registry holds the operators available to rules. It is safe for concurrent use.
+type Registry struct {
+	mu  sync.RWMutex
+	ops map[string]Operator
+}
+
+// NewRegistry returns a registry of the built-in operators
+func NewRegistry() *Registry {
+	r := &Registry{ops: make(map[string]Operator, len(builtins))}
+	for _, op := range builtins {
+		r.ops[op.Name] = op
 	}
-	return true, ""
+	return r
 }
 
-func equal(value interface{}, check domain.Check) (bool, string) {
-	if value == check.Value {
-		return true, ""
+// Register adds an operator. Its name must be new and not one of the dataset or other checks
+// the executor runs itself.
+func (r *Registry) Register(op Operator) error {
+	if err := validate(op); err != nil {
+		return err
 	}
-	return false, fmt.Sprintf("expected %v, got %v", check.Value, value)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.ops[op.Name]; exists {
+		return fmt.Errorf("operator %s is already registered", op.Name)
+	}
+	r.ops[op.Name] = op
+	return nil
 }
 
-func notEqual(value interface{}, check domain.Check) (bool, string) {
-	if value != check.Value {
-		return true, ""
+// Replace swaps the registered operator of the same name for op
+func (r *Registry) Replace(op Operator) error {
+	if err := validate(op); err != nil {
+		return err
 	}
-	return false, fmt.Sprintf("expected not %v, got %v", check.Value, value)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.ops[op.Name]; !exists {
+		return fmt.Errorf("operator %s is not registered", op.Name)
+	}
+	r.ops[op.Name] = op
+	return nil
 }
 
-func greaterThan(value interface{}, check domain.Check) (bool, string) {
-	v, ok := ToFloat(value)
-	if !ok {
-		return false, "value is not a number"
+// Unregister removes an operator; rules using it fail as with unknown operators
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.ops, name)
+}
+
+func validate(op Operator) error {
+	switch {
+	case op.Name == "":
+		return fmt.Errorf("operator needs a name")
+	case op.Func == nil:
+		return fmt.Errorf("operator %s needs a function", op.Name)
+	case reserved[op.Name] || domain.Check{Op: op.Name}.IsDataset():
+		return fmt.Errorf("operator %s is reserved", op.Name)
 	}
-	t, ok := ToFloat(check.Value)
-	if !ok {
-		return false, "threshold is not a number"
+	return nil
+}
+
+// Get returns the operator of that name. A nil registry has none.
+func (r *Registry) Get(name string) (Operator, bool) {
+	if r == nil {
+		return Operator{}, false
 	}
-	if v > t {
-		return true, ""
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	op, exists := r.ops[name]
+	return op, exists
+}
+
+// List returns every operator, sorted by name
+func (r *Registry) List() []Operator {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := make([]Operator, 0, len(r.ops))
+	for _, op := range r.ops {
+		list = append(list, op)
 	}
-	return false, fmt.Sprintf("value %v is not greater than %v", v, t)
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
 }
 
-func lessThan(value interface{}, check domain.Check) (bool, string) {
-	v, ok := ToFloat(value)
-	if !ok {
-		return false, "value is not a number"
+func valueArg(typ, desc string) Arg {
+	return Arg{Name: "value", Type: typ, Required: true, Description: desc}
+}
+
+var (
+	operandArg = Arg{Name: "value_field", Type: "string", Description: "compare with this field of the record instead of value"}
+	formatArg  = Arg{Name: "format", Type: "string", Description: `"rfc3339" (default), "epoch_seconds", "epoch_millis" or a Go layout`}
+	zoneArg    = Arg{Name: "timezone", Type: "string", Description: "zone of timestamps without an offset, UTC by default"}
+)
+
+var (
+	minArg = Arg{Name: "min", Type: "number", Description: "inclusive lower bound"}
+	maxArg = Arg{Name: "max", Type: "number", Description: "inclusive upper bound"}
+)
+
+// builtins are the operators of every registry. Null values fail those that are not NullSafe.
+var builtins = []Operator{
+	{Name: "not_null", Description: "the value is set", NullSafe: true, Func: notNull},
+	{Name: "eq", Description: "the value equals value", Args: []Arg{valueArg("any", ""), operandArg}, NullSafe: true, Func: equal},
+	{Name: "neq", Description: "the value differs from value", Args: []Arg{valueArg("any", ""), operandArg}, NullSafe: true, Func: notEqual},
+	{Name: "gt", Description: "the number is greater than value", Args: []Arg{valueArg("number", ""), operandArg}, Func: greaterThan},
+	{Name: "lt", Description: "the number is less than value", Args: []Arg{valueArg("number", ""), operandArg}, Func: lessThan},
+	{Name: "gte", Description: "the number is at least value", Args: []Arg{valueArg("number", ""), operandArg}, Func: greaterOrEqual},
+	{Name: "lte", Description: "the number is at most value", Args: []Arg{valueArg("number", ""), operandArg}, Func: lessOrEqual},
+	{Name: "regex", Description: "the string matches the regular expression value", Args: []Arg{valueArg("string", "RE2 syntax")}, Func: regexMatch},
+	{Name: "enum", Description: "the value is one of value", Args: []Arg{valueArg("array", "")}, NullSafe: true, Func: enumMatch},
+	{Name: domain.OpNotIn, Description: "the value is none of value; null passes", Args: []Arg{valueArg("array", "numbers match whatever their type")}, NullSafe: true, Func: notIn},
+	{Name: domain.OpIsNull, Description: "the value is null", NullSafe: true, Func: isNull},
+	{Name: domain.OpIsEmpty, Description: `the value is null or ""`, NullSafe: true, Func: isEmpty},
+
+	{Name: domain.OpBetween, Description: "the number is between min and max", Args: []Arg{minArg, maxArg}, Func: between},
+	{Name: domain.OpIsInteger, Description: "the number has no fractional part", Func: isInteger},
+	{Name: domain.OpMultipleOf, Description: "the number is a multiple of value, in decimal (0.3 of 0.1)", Args: []Arg{valueArg("number", "positive")}, Func: multipleOf},
+	{Name: domain.OpPrecision, Description: "the number has at most value decimal places", Args: []Arg{valueArg("number", "")}, Func: precision},
+
+	{Name: domain.OpMinLength, Description: "the string has at least value characters", Args: []Arg{valueArg("number", "")}, Func: minLength},
+	{Name: domain.OpMaxLength, Description: "the string has at most value characters", Args: []Arg{valueArg("number", "")}, Func: maxLength},
+	{Name: domain.OpContains, Description: "the string contains value", Args: []Arg{valueArg("string", "")}, Func: contains},
+	{Name: domain.OpStartsWith, Description: "the string starts with value", Args: []Arg{valueArg("string", "")}, Func: startsWith},
+	{Name: domain.OpEndsWith, Description: "the string ends with value", Args: []Arg{valueArg("string", "")}, Func: endsWith},
+
+	{Name: domain.OpBefore, Description: "the timestamp is before value", Args: []Arg{valueArg("any", `a timestamp or "now"`), operandArg, formatArg, zoneArg}, Func: before},
+	{Name: domain.OpAfter, Description: "the timestamp is after value", Args: []Arg{valueArg("any", `a timestamp or "now"`), operandArg, formatArg, zoneArg}, Func: after},
+	{Name: domain.OpWithinDays, Description: "the timestamp is at most value days old and not in the future", Args: []Arg{valueArg("number", ""), formatArg, zoneArg}, Func: withinDays},
+	{Name: domain.OpNotFuture, Description: "the timestamp is not in the future", Args: []Arg{formatArg, zoneArg}, Func: notFuture},
+}
+
+// --- Implementations ---
+
+func notNull(value interface{}, check domain.Check) (bool, string) {
+	if value == nil {
+		return false, "value is null"
 	}
-	t, ok := ToFloat(check.Value)
-	if !ok {
-		return false, "threshold is not a number"
+	return true, ""
+}
+
+func equal(value interface{}, check domain.Check) (bool, string) {
+	if Equal(value, check.Value, check.NumericStrings) {
+		return true, ""
 	}
-	if v < t {
+	return false, fmt.Sprintf("expected %v, got %v", check.Value, value)
+}
+
+func notEqual(value interface{}, check domain.Check) (bool, string) {
+	if !Equal(value, check.Value, check.NumericStrings) {
 		return true, ""
 	}
-	return false, fmt.Sprintf("value %v is not less than %v", v, t)
+	return false, fmt.Sprintf("expected not %v, got %v", check.Value, value)
 }
 
 func regexMatch(value interface{}, check domain.Check) (bool, string) {
@@ -100,38 +214,52 @@ func regexMatch(value interface{}, check domain.Check) (bool, string) {
 }
 
 func enumMatch(value interface{}, check domain.Check) (bool, string) {
-	allowedList, ok := check.Value.([]interface{})
+	allowed, ok := valueList(check.Value)
 	if !ok {
-		// Try string slice
-		if strList, ok := check.Value.([]string); ok {
-			for _, item := range strList {
-				if item == value {
-					return true, ""
-				}
-			}
-			return false, fmt.Sprintf("value %v not in enum list", check.Value)
-		}
 		return false, "enum list must be an array"
 	}
-
-	for _, item := range allowedList {
-		if item == value {
+	for _, item := range allowed {
+		if Equal(value, item, check.NumericStrings) {
 			return true, ""
 		}
 	}
 	return false, fmt.Sprintf("value %v not in enum list", check.Value)
 }
 
-// Utility to convert numbers to float64 safely
-func ToFloat(i interface{}) (float64, bool) {
-	switch v := i.(type) {
-	case int:
-		return float64(v), true
-	case int64:
-		return float64(v), true
-	case float64:
-		return v, true
-	default:
-		return 0, false
+func isNull(value interface{}, check domain.Check) (bool, string) {
+	if value == nil {
+		return true, ""
+	}
+	return false, fmt.Sprintf("expected null, got %v", value)
+}
+
+func notIn(value interface{}, check domain.Check) (bool, string) {
+	excluded, ok := valueList(check.Value)
+	if !ok {
+		return false, "excluded values must be an array"
+	}
+	if value == nil {
+		return true, ""
+	}
+	for _, item := range excluded {
+		if Equal(value, item, check.NumericStrings) {
+			return false, fmt.Sprintf("value %v is excluded", value)
+		}
+	}
+	return true, ""
+}
+
+// valueList reads the array Value of enum and not_in checks, decoded from JSON or built in Go
+func valueList(v interface{}) ([]interface{}, bool) {
+	switch list := v.(type) {
+	case []interface{}:
+		return list, true
+	case []string:
+		out := make([]interface{}, len(list))
+		for i, s := range list {
+			out[i] = s
+		}
+		return out, true
 	}
+	return nil, false
 }
@@ -1,6 +1,7 @@
 package operators
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/singh-anurag-7991/data-guard/internal/domain"
@@ -22,11 +23,16 @@ func TestOperators(t *testing.T) {
 		{"eq_string_match", "eq", "hello", "hello", true},
 		{"eq_string_mismatch", "eq", "hello", "world", false},
 		{"eq_int_match", "eq", 10, 10, true},
+		{"eq_int_float", "eq", 100, 100.0, true},
+		{"eq_number_string", "eq", "100", 100.0, false},
 
 		// gt
 		{"gt_int_valid", "gt", 15, 10, true},
 		{"gt_int_invalid", "gt", 5, 10, false},
 		{"gt_float_valid", "gt", 10.5, 10.0, true},
+		{"gt_int32", "gt", int32(15), 10.0, true},
+		{"gt_json_number", "gt", json.Number("10.000000000000000001"), 10, true},
+		{"gt_string", "gt", "15", 10, false},
 
 		// lt
 		{"lt_int_valid", "lt", 5, 10, true},
@@ -39,19 +45,112 @@ func TestOperators(t *testing.T) {
 		// enum
 		{"enum_valid", "enum", "active", []string{"active", "inactive"}, true},
 		{"enum_invalid", "enum", "deleted", []string{"active", "inactive"}, false},
+		{"enum_number_types", "enum", int64(2), []interface{}{1.0, 2.0}, true},
+
+		// gte / lte
+		{"gte_equal", "gte", 10, 10.0, true},
+		{"gte_invalid", "gte", 9.5, 10, false},
+		{"lte_equal", "lte", int64(10), 10, true},
+		{"lte_string", "lte", "5", 10, false},
+
+		// not_in
+		{"not_in_valid", "not_in", "pending", []interface{}{"deleted", "banned"}, true},
+		{"not_in_invalid", "not_in", "deleted", []string{"deleted", "banned"}, false},
+		{"not_in_number_types", "not_in", int64(3), []interface{}{3.0}, false},
+		{"not_in_string_number", "not_in", "3", []interface{}{3.0}, true},
+		{"not_in_null", "not_in", nil, []interface{}{"deleted"}, true},
+		{"not_in_arrays", "not_in", []interface{}{"a"}, []interface{}{[]interface{}{"a"}}, true},
+
+		// strings
+		{"min_length_runes", "min_length", "äöü", 3.0, true},
+		{"max_length_invalid", "max_length", "abcd", 3, false},
+		{"contains_valid", "contains", "a@b", "@", true},
+		{"starts_with_invalid", "starts_with", "cab", "ab", false},
+		{"ends_with_number", "ends_with", 10, "0", false},
+		{"is_empty_blank", "is_empty", "", nil, true},
+		{"is_empty_space", "is_empty", " ", nil, false},
+		{"is_null_zero", "is_null", 0, nil, false},
+
+		// numbers
+		{"multiple_of_decimal", "multiple_of", 0.3, 0.1, true},
+		{"multiple_of_invalid", "multiple_of", 7, 2, false},
+		{"multiple_of_zero_divisor", "multiple_of", 0, 0, false},
+		{"precision_valid", "precision", 19.99, 2, true},
+		{"precision_invalid", "precision", 0.125, 2, false},
+		{"is_integer_valid", "is_integer", 4.0, nil, true},
+		{"is_integer_invalid", "is_integer", 4.5, nil, false},
 	}
 
+	registry := NewRegistry()
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			opFunc, exists := Get(tt.op)
+			op, exists := registry.Get(tt.op)
 			if !exists {
 				t.Fatalf("operator %s not found", tt.op)
 			}
 			check := domain.Check{Value: tt.checkVal}
-			got, _ := opFunc(tt.val, check)
+			got, _ := op.Func(tt.val, check)
 			if got != tt.want {
 				t.Errorf("op %s(%v, %v) = %v, want %v", tt.op, tt.val, tt.checkVal, got, tt.want)
 			}
 		})
 	}
 }
+
+func TestRegistry_Register(t *testing.T) {
+	even := Operator{
+		Name: "even",
+		Func: func(value interface{}, check domain.Check) (bool, string) {
+			f, ok := ToFloat(value)
+			return ok && int(f)%2 == 0, "value is odd"
+		},
+	}
+
+	tests := []struct {
+		name    string
+		op      Operator
+		wantErr string
+	}{
+		{"custom", even, ""},
+		{"duplicate", Operator{Name: "gt", Func: even.Func}, "operator gt is already registered"},
+		{"executor check", Operator{Name: "exists_in", Func: even.Func}, "operator exists_in is reserved"},
+		{"dataset check", Operator{Name: domain.OpUnique, Func: even.Func}, "operator unique is reserved"},
+		{"no function", Operator{Name: "odd"}, "operator odd needs a function"},
+		{"no name", Operator{Func: even.Func}, "operator needs a name"},
+	}
+
+	r := NewRegistry()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := r.Register(tt.op)
+			if (err == nil) != (tt.wantErr == "") || (err != nil && err.Error() != tt.wantErr) {
+				t.Errorf("expected error %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	if _, ok := r.Get("even"); !ok {
+		t.Error("expected the custom operator to be registered")
+	}
+	if _, ok := NewRegistry().Get("even"); ok {
+		t.Error("expected registries not to share operators")
+	}
+	if err := r.Replace(Operator{Name: "odd", Func: even.Func}); err == nil {
+		t.Error("expected only registered operators to be replaced")
+	}
+	if err := r.Replace(Operator{Name: "even", Description: "replaced", Func: even.Func}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if op, _ := r.Get("even"); op.Description != "replaced" {
+		t.Errorf("expected the replaced operator, got %+v", op)
+	}
+	list := r.List()
+	if len(list) != len(builtins)+1 || list[0].Name != domain.OpAfter {
+		t.Errorf("expected the operators sorted by name, got %d starting with %s", len(list), list[0].Name)
+	}
+
+	r.Unregister("even")
+	if _, ok := r.Get("even"); ok {
+		t.Error("expected the operator to be unregistered")
+	}
+}
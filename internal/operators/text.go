@@ -0,0 +1,74 @@
+package operators
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/singh-anurag-7991/data-guard/internal/domain"
+)
+
+func minLength(value interface{}, check domain.Check) (bool, string) {
+	s, ok := value.(string)
+	if !ok {
+		return false, "value is not a string"
+	}
+	limit, ok := ToFloat(check.Value)
+	if !ok {
+		return false, "length is not a number"
+	}
+	if n := utf8.RuneCountInString(s); float64(n) < limit {
+		return false, fmt.Sprintf("value %q has %d characters, fewer than %v", s, n, limit)
+	}
+	return true, ""
+}
+
+func maxLength(value interface{}, check domain.Check) (bool, string) {
+	s, ok := value.(string)
+	if !ok {
+		return false, "value is not a string"
+	}
+	limit, ok := ToFloat(check.Value)
+	if !ok {
+		return false, "length is not a number"
+	}
+	if n := utf8.RuneCountInString(s); float64(n) > limit {
+		return false, fmt.Sprintf("value %q has %d characters, more than %v", s, n, limit)
+	}
+	return true, ""
+}
+
+func contains(value interface{}, check domain.Check) (bool, string) {
+	return matchString(value, check, strings.Contains, "does not contain")
+}
+
+func startsWith(value interface{}, check domain.Check) (bool, string) {
+	return matchString(value, check, strings.HasPrefix, "does not start with")
+}
+
+func endsWith(value interface{}, check domain.Check) (bool, string) {
+	return matchString(value, check, strings.HasSuffix, "does not end with")
+}
+
+// matchString checks a string against the string Value of the check with match
+func matchString(value interface{}, check domain.Check, match func(s, sub string) bool, failure string) (bool, string) {
+	s, ok := value.(string)
+	if !ok {
+		return false, "value is not a string"
+	}
+	sub, ok := check.Value.(string)
+	if !ok {
+		return false, "operand is not a string"
+	}
+	if match(s, sub) {
+		return true, ""
+	}
+	return false, fmt.Sprintf("value %q %s %q", s, failure, sub)
+}
+
+func isEmpty(value interface{}, check domain.Check) (bool, string) {
+	if value == nil || value == "" {
+		return true, ""
+	}
+	return false, fmt.Sprintf("expected an empty value, got %v", value)
+}
@@ -0,0 +1,220 @@
+package operators
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+	_ "time/tzdata" // Timezones must not depend on the host having zoneinfo
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/singh-anurag-7991/data-guard/internal/domain"
+)
+
+// Timestamp formats besides Go layouts ("02.01.2006 15:04")
+const (
+	FormatRFC3339      = "rfc3339" // RFC 3339, also without offset or time ("2024-05-01")
+	FormatEpochSeconds = "epoch_seconds"
+	FormatEpochMillis  = "epoch_millis"
+)
+
+// timeLayouts are the string forms of FormatRFC3339, tried in order
+var timeLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02"}
+
+// clockLayouts are the default string forms of a time of day
+var clockLayouts = []string{"15:04:05.999999999", "15:04"}
+
+// ToTime converts timestamps (time.Time or RFC 3339 / date strings) to time.Time
+func ToTime(i interface{}) (time.Time, bool) {
+	return ParseTime(i, FormatRFC3339, time.UTC)
+}
+
+// ParseTime reads a timestamp in the given format. Strings without an offset are in loc.
+// time.Time values, as returned by pgx for date and timestamp columns, are taken as-is.
+func ParseTime(i interface{}, format string, loc *time.Location) (time.Time, bool) {
+	if t, ok := i.(time.Time); ok {
+		return t, true
+	}
+
+	switch format {
+	case FormatEpochSeconds, FormatEpochMillis:
+		f, ok := ToFloat(i)
+		if s, isString := i.(string); isString {
+			var err error
+			f, err = strconv.ParseFloat(s, 64)
+			ok = err == nil
+		}
+		if !ok {
+			return time.Time{}, false
+		}
+		// The negated ranges reject NaN and infinities too, which int64 would turn into garbage
+		if format == FormatEpochMillis {
+			if !(f >= math.MinInt64 && f < math.MaxInt64) {
+				return time.Time{}, false
+			}
+			return time.UnixMilli(int64(f)).UTC(), true
+		}
+		ns := f * 1e9
+		if !(ns >= math.MinInt64 && ns < math.MaxInt64) {
+			return time.Time{}, false
+		}
+		return time.Unix(0, int64(ns)).UTC(), true
+	}
+
+	s, ok := i.(string)
+	if !ok {
+		return time.Time{}, false
+	}
+	layouts := timeLayouts
+	if format != "" && format != FormatRFC3339 {
+		layouts = []string{format}
+	}
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// ParseTimeOfDay reads a time of day ("14:30:00", or in a Go layout) as the time since midnight.
+// pgx returns time columns as pgtype.Time.
+func ParseTimeOfDay(i interface{}, format string) (time.Duration, bool) {
+	switch v := i.(type) {
+	case pgtype.Time:
+		return time.Duration(v.Microseconds) * time.Microsecond, v.Valid
+	case string:
+		layouts := clockLayouts
+		if format != "" {
+			layouts = []string{format}
+		}
+		for _, layout := range layouts {
+			if t, err := time.Parse(layout, v); err == nil {
+				return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute +
+					time.Duration(t.Second())*time.Second + time.Duration(t.Nanosecond()), true
+			}
+		}
+	}
+	return 0, false
+}
+
+// locations caches timezones by name, since loading one reads the tz database. Only zones that
+// exist are stored, so the cache is bounded by the database whatever names checks use.
+var locations sync.Map // name -> *time.Location
+
+// LoadLocation returns the named timezone, UTC when name is empty
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	locations.Store(name, loc)
+	return loc, nil
+}
+
+// checkTime reads the value of a date operator check with the check's format and timezone
+func checkTime(value interface{}, check domain.Check) (time.Time, *time.Location, string) {
+	loc, err := LoadLocation(check.Timezone)
+	if err != nil {
+		return time.Time{}, nil, fmt.Sprintf("unknown timezone %s", check.Timezone)
+	}
+	t, ok := ParseTime(value, check.Format, loc)
+	if !ok {
+		return time.Time{}, nil, "value is not a timestamp"
+	}
+	return t, loc, ""
+}
+
+// Threshold reads the timestamp before/after compare with, in the check's timezone
+func Threshold(check domain.Check) (time.Time, bool) {
+	loc, err := LoadLocation(check.Timezone)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return threshold(check, loc)
+}
+
+// threshold reads the timestamp a value is compared with: "now", or a timestamp in the
+// check's format or RFC 3339
+func threshold(check domain.Check, loc *time.Location) (time.Time, bool) {
+	if check.Value == "now" {
+		return time.Now(), true
+	}
+	if t, ok := ParseTime(check.Value, check.Format, loc); ok {
+		return t, true
+	}
+	return ParseTime(check.Value, FormatRFC3339, loc)
+}
+
+func before(value interface{}, check domain.Check) (bool, string) {
+	return compareTime(value, check, true)
+}
+
+func after(value interface{}, check domain.Check) (bool, string) {
+	return compareTime(value, check, false)
+}
+
+func compareTime(value interface{}, check domain.Check, before bool) (bool, string) {
+	t, loc, reason := checkTime(value, check)
+	if reason != "" {
+		return false, reason
+	}
+	limit, ok := threshold(check, loc)
+	if !ok {
+		return false, "threshold is not a timestamp"
+	}
+
+	if before && t.Before(limit) {
+		return true, ""
+	}
+	if !before && t.After(limit) {
+		return true, ""
+	}
+	word := "after"
+	if before {
+		word = "before"
+	}
+	return false, fmt.Sprintf("%s is not %s %s", t.Format(time.RFC3339), word, limit.Format(time.RFC3339))
+}
+
+func withinDays(value interface{}, check domain.Check) (bool, string) {
+	t, _, reason := checkTime(value, check)
+	if reason != "" {
+		return false, reason
+	}
+	days, ok := ToFloat(check.Value)
+	if !ok || !(days >= 0) {
+		return false, "within_days expects a number of days"
+	}
+	window := time.Duration(math.MaxInt64) // About 292 years, for longer windows
+	if d := days * float64(24*time.Hour); d < math.MaxInt64 {
+		window = time.Duration(d)
+	}
+
+	now := time.Now()
+	if t.After(now) {
+		return false, fmt.Sprintf("%s is in the future", t.Format(time.RFC3339))
+	}
+	if now.Sub(t) > window {
+		return false, fmt.Sprintf("%s is more than %v days old", t.Format(time.RFC3339), days)
+	}
+	return true, ""
+}
+
+func notFuture(value interface{}, check domain.Check) (bool, string) {
+	t, _, reason := checkTime(value, check)
+	if reason != "" {
+		return false, reason
+	}
+	if t.After(time.Now()) {
+		return false, fmt.Sprintf("%s is in the future", t.Format(time.RFC3339))
+	}
+	return true, ""
+}
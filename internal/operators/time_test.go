@@ -0,0 +1,128 @@
+package operators
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/singh-anurag-7991/data-guard/internal/domain"
+)
+
+func TestParseTime(t *testing.T) {
+	berlin, _ := LoadLocation("Europe/Berlin")
+	want := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		val    interface{}
+		format string
+		loc    *time.Location
+		ok     bool
+	}{
+		{"rfc3339", "2024-05-01T14:00:00+02:00", "", time.UTC, true},
+		{"without_offset_in_loc", "2024-05-01 14:00:00", FormatRFC3339, berlin, true},
+		{"time_value", want, FormatEpochMillis, time.UTC, true},
+		{"epoch_seconds", int64(1714564800), FormatEpochSeconds, time.UTC, true},
+		{"epoch_seconds_string", "1714564800", FormatEpochSeconds, time.UTC, true},
+		{"epoch_millis", 1714564800000.0, FormatEpochMillis, time.UTC, true},
+		{"layout", "01.05.2024 12:00", "02.01.2006 15:04", time.UTC, true},
+		{"layout_mismatch", "2024-05-01", "02.01.2006", time.UTC, false},
+		{"not_a_timestamp", "soon", "", time.UTC, false},
+		{"number_without_epoch_format", 1714564800, "", time.UTC, false},
+		{"epoch_seconds_nan", math.NaN(), FormatEpochSeconds, time.UTC, false},
+		{"epoch_seconds_infinite", math.Inf(1), FormatEpochSeconds, time.UTC, false},
+		{"epoch_seconds_out_of_range", 1e12, FormatEpochSeconds, time.UTC, false},
+		{"epoch_millis_out_of_range", "1e19", FormatEpochMillis, time.UTC, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseTime(tt.val, tt.format, tt.loc)
+			if ok != tt.ok {
+				t.Fatalf("ParseTime(%v, %q) ok = %v, want %v", tt.val, tt.format, ok, tt.ok)
+			}
+			if ok && !got.Equal(want) {
+				t.Errorf("ParseTime(%v, %q) = %v, want %v", tt.val, tt.format, got, want)
+			}
+		})
+	}
+}
+
+func TestParseTimeOfDay(t *testing.T) {
+	if d, ok := ParseTimeOfDay("14:30:15", ""); !ok || d != 14*time.Hour+30*time.Minute+15*time.Second {
+		t.Errorf("unexpected time of day %v (%v)", d, ok)
+	}
+	if d, ok := ParseTimeOfDay("2:30 PM", "3:04 PM"); !ok || d != 14*time.Hour+30*time.Minute {
+		t.Errorf("unexpected time of day %v (%v)", d, ok)
+	}
+	if d, ok := ParseTimeOfDay(pgtype.Time{Microseconds: 3600e6, Valid: true}, ""); !ok || d != time.Hour {
+		t.Errorf("unexpected time of day %v (%v)", d, ok)
+	}
+	if _, ok := ParseTimeOfDay("25:00", ""); ok {
+		t.Error("expected 25:00 to be rejected")
+	}
+}
+
+func TestLoadLocation(t *testing.T) {
+	first, err := LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if again, _ := LoadLocation("Europe/Berlin"); again != first {
+		t.Error("expected the timezone to be loaded once")
+	}
+	if loc, _ := LoadLocation(""); loc != time.UTC {
+		t.Errorf("expected UTC by default, got %v", loc)
+	}
+	if _, err := LoadLocation("Mars/Olympus"); err == nil {
+		t.Error("expected an unknown timezone to fail")
+	}
+	if _, cached := locations.Load("Mars/Olympus"); cached {
+		t.Error("expected unknown timezones not to be cached")
+	}
+}
+
+func TestDateOperators(t *testing.T) {
+	now := time.Now()
+	yesterday := now.Add(-24 * time.Hour).UTC().Format(time.RFC3339)
+	tomorrow := now.Add(24 * time.Hour).UTC().Format(time.RFC3339)
+
+	tests := []struct {
+		name  string
+		val   interface{}
+		check domain.Check
+		want  bool
+	}{
+		{"before_valid", "2024-01-31", domain.Check{Op: "before", Value: "2024-02-01"}, true},
+		{"before_invalid", "2024-02-01T00:00:00Z", domain.Check{Op: "before", Value: "2024-02-01"}, false},
+		{"before_timezone", "2024-02-01 00:30:00", domain.Check{Op: "before", Value: "2024-02-01T00:00:00Z", Timezone: "Europe/Berlin"}, true},
+		{"before_now", yesterday, domain.Check{Op: "before", Value: "now"}, true},
+		{"after_epoch", int64(1706745600), domain.Check{Op: "after", Value: "2024-01-01", Format: FormatEpochSeconds}, true},
+		{"after_invalid", now, domain.Check{Op: "after", Value: "now"}, false},
+		{"after_bad_threshold", now, domain.Check{Op: "after", Value: "later"}, false},
+		{"within_days_valid", yesterday, domain.Check{Op: "within_days", Value: 7}, true},
+		{"within_days_too_old", "2020-01-01", domain.Check{Op: "within_days", Value: 7}, false},
+		{"within_days_future", tomorrow, domain.Check{Op: "within_days", Value: 7}, false},
+		{"within_days_centuries", "2020-01-01", domain.Check{Op: "within_days", Value: 1e9}, true},
+		{"within_days_nan", yesterday, domain.Check{Op: "within_days", Value: math.NaN()}, false},
+		{"not_future_valid", now.Add(-time.Minute), domain.Check{Op: "not_future"}, true},
+		{"not_future_invalid", tomorrow, domain.Check{Op: "not_future"}, false},
+		{"not_a_timestamp", "soon", domain.Check{Op: "not_future"}, false},
+		{"unknown_timezone", yesterday, domain.Check{Op: "not_future", Timezone: "Mars/Olympus"}, false},
+	}
+
+	registry := NewRegistry()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			op, exists := registry.Get(tt.check.Op)
+			if !exists {
+				t.Fatalf("operator %s not found", tt.check.Op)
+			}
+			got, reason := op.Func(tt.val, tt.check)
+			if got != tt.want {
+				t.Errorf("op %s(%v, %v) = %v (%s), want %v", tt.check.Op, tt.val, tt.check.Value, got, reason, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/singh-anurag-7991/data-guard/internal/operators"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+)
+
+// Loader keeps the operators of the *.wasm modules of a directory registered: new and changed
+// files are loaded, removed ones unregistered
+type Loader struct {
+	dir      string
+	registry *operators.Registry
+	limits   Limits
+	runtime  wazero.Runtime
+	loaded   map[string]loadedPlugin // by operator name
+}
+
+// loadedPlugin is a plugin with the file it was loaded from
+type loadedPlugin struct {
+	plugin  *Plugin
+	modTime time.Time
+	size    int64
+}
+
+func NewLoader(ctx context.Context, dir string, registry *operators.Registry, limits Limits) (*Loader, error) {
+	config := wazero.NewRuntimeConfig().WithCloseOnContextDone(true)
+	if limits.MemoryPages > 0 {
+		config = config.WithMemoryLimitPages(limits.MemoryPages)
+	}
+	runtime := wazero.NewRuntimeWithConfig(ctx, config)
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, runtime); err != nil {
+		runtime.Close(ctx)
+		return nil, fmt.Errorf("failed to instantiate WASI: %w", err)
+	}
+	return &Loader{dir: dir, registry: registry, limits: limits, runtime: runtime, loaded: map[string]loadedPlugin{}}, nil
+}
+
+// Reload brings the registry in line with the directory. A module that fails to load keeps
+// its previous version registered, if any; the errors are joined.
+func (l *Loader) Reload(ctx context.Context) error {
+	entries, err := os.ReadDir(l.dir)
+	if err != nil {
+		return fmt.Errorf("failed to read plugins directory: %w", err)
+	}
+
+	var errs []error
+	seen := map[string]bool{}
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".wasm")
+		if !ok || entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		seen[name] = true
+
+		prev, exists := l.loaded[name]
+		if exists && info.ModTime().Equal(prev.modTime) && info.Size() == prev.size {
+			continue
+		}
+		if err := l.load(ctx, name, info); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	for name, prev := range l.loaded {
+		if !seen[name] {
+			l.registry.Unregister(name)
+			prev.plugin.Close(ctx)
+			delete(l.loaded, name)
+			slog.Info("Unloaded plugin", "operator", name)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// load compiles the module of an operator and registers it in place of its previous version
+func (l *Loader) load(ctx context.Context, name string, info os.FileInfo) error {
+	wasm, err := os.ReadFile(filepath.Join(l.dir, info.Name()))
+	if err != nil {
+		return err
+	}
+	p, err := Compile(ctx, l.runtime, name, wasm, l.limits)
+	if err != nil {
+		return err
+	}
+
+	prev, exists := l.loaded[name]
+	if exists {
+		err = l.registry.Replace(p.Operator())
+	} else {
+		err = l.registry.Register(p.Operator())
+	}
+	if err != nil {
+		p.Close(ctx)
+		return fmt.Errorf("plugin %s: %w", name, err)
+	}
+	if exists {
+		prev.plugin.Close(ctx)
+	}
+	l.loaded[name] = loadedPlugin{plugin: p, modTime: info.ModTime(), size: info.Size()}
+	slog.Info("Loaded plugin", "operator", name, "reloaded", exists)
+	return nil
+}
+
+// Run reloads the directory every interval until ctx is done, then unloads every plugin
+func (l *Loader) Run(ctx context.Context, every time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			l.Close(context.Background())
+			return
+		case <-ticker.C:
+			if err := l.Reload(ctx); err != nil {
+				slog.Error("Plugin reload failed", "error", err)
+			}
+		}
+	}
+}
+
+// Close unregisters and releases every plugin
+func (l *Loader) Close(ctx context.Context) {
+	for name, prev := range l.loaded {
+		l.registry.Unregister(name)
+		prev.plugin.Close(ctx)
+	}
+	l.loaded = map[string]loadedPlugin{}
+	l.runtime.Close(ctx)
+}
@@ -0,0 +1,220 @@
+// Package plugin runs check operators shipped as WebAssembly modules, so custom checks
+// (checksums, internal ID formats) need no fork. Modules run in wazero, a pure-Go runtime,
+// without filesystem or network access. The module luhn.wasm provides the operator "luhn" by
+// exporting:
+//
+//	memory                   its linear memory
+//	alloc(len i32) i32       a buffer of len bytes, which the host writes the input of a call to
+//	check(ptr, len i32) i64  checks the input, {"value": <value>, "check": <check>} as JSON, and
+//	                         returns 0 when the value passes, otherwise ptr<<32 | len of the reason
+//	describe() i64           optional: ptr<<32 | len of {"description", "args", "null_safe"} as JSON
+//
+// Every call runs under Limits. Modules importing WASI get it without any host access, and
+// reactors (wasip1 c-shared builds) are initialized through _initialize.
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/singh-anurag-7991/data-guard/internal/domain"
+	"github.com/singh-anurag-7991/data-guard/internal/operators"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+)
+
+// Limits bound the resources of a plugin
+type Limits struct {
+	Timeout     time.Duration // Per call, instantiation included
+	MemoryPages uint32        // Cap on a module's memory in 64 KiB pages
+}
+
+// DefaultLimits allow 100ms per call and 16 MiB of memory
+var DefaultLimits = Limits{Timeout: 100 * time.Millisecond, MemoryPages: 256}
+
+// callInput is what check receives
+type callInput struct {
+	Value interface{}  `json:"value"`
+	Check domain.Check `json:"check"`
+}
+
+// metadata is what describe returns
+type metadata struct {
+	Description string          `json:"description"`
+	Args        []operators.Arg `json:"args"`
+	NullSafe    bool            `json:"null_safe"`
+}
+
+// Plugin is one compiled module. Calls are serialized on a single instance, which is replaced
+// after a failed call since a trap or deadline may leave it broken.
+type Plugin struct {
+	name     string
+	runtime  wazero.Runtime
+	compiled wazero.CompiledModule
+	timeout  time.Duration
+	meta     metadata
+
+	mu     sync.Mutex
+	mod    api.Module // nil until the next call after a failure
+	closed bool
+}
+
+// Compile loads the module of the named operator into runtime, checking it implements the ABI
+func Compile(ctx context.Context, runtime wazero.Runtime, name string, wasm []byte, limits Limits) (*Plugin, error) {
+	compiled, err := runtime.CompileModule(ctx, wasm)
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: %w", name, err)
+	}
+	p := &Plugin{name: name, runtime: runtime, compiled: compiled, timeout: limits.Timeout}
+
+	funcs := compiled.ExportedFunctions()
+	for _, export := range []string{"alloc", "check"} {
+		if funcs[export] == nil {
+			compiled.Close(ctx)
+			return nil, fmt.Errorf("plugin %s does not export %s", name, export)
+		}
+	}
+	if compiled.ExportedMemories()["memory"] == nil {
+		compiled.Close(ctx)
+		return nil, fmt.Errorf("plugin %s does not export memory", name)
+	}
+
+	if funcs["describe"] != nil {
+		if err := p.describe(); err != nil {
+			p.Close(ctx)
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// Operator returns the plugin as an operator of the registry. Its checks are not pushed down.
+func (p *Plugin) Operator() operators.Operator {
+	return operators.Operator{
+		Name:        p.name,
+		Description: p.meta.Description,
+		Args:        p.meta.Args,
+		NullSafe:    p.meta.NullSafe,
+		Func:        p.Check,
+	}
+}
+
+// Check runs the module's check on a value. Failing calls fail the value with the error.
+func (p *Plugin) Check(value interface{}, check domain.Check) (bool, string) {
+	input, err := json.Marshal(callInput{Value: value, Check: check})
+	if err != nil {
+		return false, fmt.Sprintf("plugin %s: %v", p.name, err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out, err := p.call("check", input)
+	if err != nil {
+		return false, err.Error()
+	}
+	if out == nil {
+		return true, ""
+	}
+	if len(out) == 0 {
+		return false, fmt.Sprintf("rejected by %s", p.name)
+	}
+	return false, string(out)
+}
+
+// describe reads the metadata of the module
+func (p *Plugin) describe() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out, err := p.call("describe", nil)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(out, &p.meta); err != nil {
+		return fmt.Errorf("plugin %s: invalid describe output: %w", p.name, err)
+	}
+	return nil
+}
+
+// call runs an exported function within the time limit, passing input through alloc unless nil.
+// It returns the memory the result points to, nil for 0. p.mu must be held.
+func (p *Plugin) call(export string, input []byte) ([]byte, error) {
+	if p.closed {
+		return nil, fmt.Errorf("plugin %s was unloaded", p.name)
+	}
+	ctx := context.Background()
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+
+	out, err := p.invoke(ctx, export, input)
+	if err != nil {
+		// The instance may be left half-way through a call
+		if p.mod != nil {
+			p.mod.Close(context.Background())
+			p.mod = nil
+		}
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("plugin %s exceeded its time limit of %v", p.name, p.timeout)
+		}
+		return nil, fmt.Errorf("plugin %s failed: %w", p.name, err)
+	}
+	return out, nil
+}
+
+func (p *Plugin) invoke(ctx context.Context, export string, input []byte) ([]byte, error) {
+	if p.mod == nil {
+		mod, err := p.runtime.InstantiateModule(ctx, p.compiled, wazero.NewModuleConfig().WithName("").WithStartFunctions("_initialize"))
+		if err != nil {
+			return nil, err
+		}
+		p.mod = mod
+	}
+
+	var params []uint64
+	if input != nil {
+		res, err := p.mod.ExportedFunction("alloc").Call(ctx, uint64(len(input)))
+		if err != nil {
+			return nil, fmt.Errorf("alloc: %w", err)
+		}
+		ptr := uint32(res[0])
+		if !p.mod.Memory().Write(ptr, input) {
+			return nil, fmt.Errorf("alloc returned %d bytes out of memory at %d", len(input), ptr)
+		}
+		params = []uint64{uint64(ptr), uint64(len(input))}
+	}
+
+	res, err := p.mod.ExportedFunction(export).Call(ctx, params...)
+	if err != nil {
+		return nil, err
+	}
+	if len(res) != 1 {
+		return nil, fmt.Errorf("%s returned %d results", export, len(res))
+	}
+	if res[0] == 0 {
+		return nil, nil
+	}
+	ptr, size := uint32(res[0]>>32), uint32(res[0])
+	out, ok := p.mod.Memory().Read(ptr, size)
+	if !ok {
+		return nil, fmt.Errorf("%s returned %d bytes out of memory at %d", export, size, ptr)
+	}
+	return append([]byte{}, out...), nil // The view changes with the next call
+}
+
+// Close releases the module; later calls fail
+func (p *Plugin) Close(ctx context.Context) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.mod != nil {
+		p.mod.Close(ctx)
+		p.mod = nil
+	}
+	p.closed = true
+	p.compiled.Close(ctx)
+}
@@ -0,0 +1,131 @@
+package plugin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/singh-anurag-7991/data-guard/internal/domain"
+	"github.com/singh-anurag-7991/data-guard/internal/operators"
+	"github.com/tetratelabs/wazero"
+)
+
+func compileTestdata(t *testing.T, name string, limits Limits) *Plugin {
+	t.Helper()
+	wasm, err := os.ReadFile(filepath.Join("testdata", name+".wasm"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	runtime := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().WithCloseOnContextDone(true))
+	t.Cleanup(func() { runtime.Close(ctx) })
+
+	p, err := Compile(ctx, runtime, name, wasm, limits)
+	if err != nil {
+		t.Fatalf("failed to compile %s: %v", name, err)
+	}
+	return p
+}
+
+func TestPlugin_Check(t *testing.T) {
+	p := compileTestdata(t, "is_string", DefaultLimits)
+
+	op := p.Operator()
+	if op.Name != "is_string" || op.Description != "the value is a string" || !op.NullSafe {
+		t.Errorf("expected the described operator, got %+v", op)
+	}
+
+	tests := []struct {
+		value      interface{}
+		wantPass   bool
+		wantReason string
+	}{
+		{"abc", true, ""},
+		{42, false, "value is not a string"},
+		{nil, false, "value is not a string"},
+		{strings.Repeat("x", 10000), true, ""},
+	}
+	for _, tt := range tests {
+		pass, reason := op.Func(tt.value, domain.Check{Op: "is_string"})
+		if pass != tt.wantPass || reason != tt.wantReason {
+			t.Errorf("%.10v: expected (%v, %q), got (%v, %q)", tt.value, tt.wantPass, tt.wantReason, pass, reason)
+		}
+	}
+
+	p.Close(context.Background())
+	if pass, reason := p.Check("abc", domain.Check{}); pass || reason != "plugin is_string was unloaded" {
+		t.Errorf("expected closed plugins to fail, got (%v, %q)", pass, reason)
+	}
+}
+
+func TestPlugin_Timeout(t *testing.T) {
+	p := compileTestdata(t, "spin", Limits{Timeout: 20 * time.Millisecond})
+
+	for i := 0; i < 2; i++ { // A fresh instance is used after the deadline
+		pass, reason := p.Check("abc", domain.Check{})
+		if pass || reason != "plugin spin exceeded its time limit of 20ms" {
+			t.Errorf("expected the call to time out, got (%v, %q)", pass, reason)
+		}
+	}
+}
+
+func TestCompile_Invalid(t *testing.T) {
+	ctx := context.Background()
+	runtime := wazero.NewRuntime(ctx)
+	defer runtime.Close(ctx)
+
+	if _, err := Compile(ctx, runtime, "junk", []byte("not wasm"), DefaultLimits); err == nil {
+		t.Error("expected invalid modules to fail")
+	}
+	// The smallest valid module exports nothing
+	if _, err := Compile(ctx, runtime, "empty", []byte("\x00asm\x01\x00\x00\x00"), DefaultLimits); err == nil || err.Error() != "plugin empty does not export alloc" {
+		t.Errorf("expected a missing export, got %v", err)
+	}
+}
+
+func TestLoader_Reload(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	isString, _ := os.ReadFile(filepath.Join("testdata", "is_string.wasm"))
+	spin, _ := os.ReadFile(filepath.Join("testdata", "spin.wasm"))
+
+	registry := operators.NewRegistry()
+	l, err := NewLoader(ctx, dir, registry, Limits{Timeout: 20 * time.Millisecond, MemoryPages: 16})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close(ctx)
+
+	path := filepath.Join(dir, "custom.wasm")
+	_ = os.WriteFile(path, isString, 0o644)
+	_ = os.WriteFile(filepath.Join(dir, "gt.wasm"), isString, 0o644)
+	_ = os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("ignored"), 0o644)
+	if err := l.Reload(ctx); err == nil || !strings.Contains(err.Error(), "plugin gt: operator gt is already registered") {
+		t.Errorf("expected plugins not to replace built-in operators, got %v", err)
+	}
+	op, ok := registry.Get("custom")
+	if !ok {
+		t.Fatal("expected the plugin to be registered")
+	}
+	if pass, _ := op.Func("abc", domain.Check{}); !pass {
+		t.Error("expected the plugin to pass strings")
+	}
+
+	// A changed file is loaded again
+	_ = os.WriteFile(path, spin, 0o644)
+	_ = os.Chtimes(path, time.Now(), time.Now().Add(time.Minute))
+	_ = l.Reload(ctx)
+	op, _ = registry.Get("custom")
+	if pass, reason := op.Func("abc", domain.Check{}); pass || !strings.Contains(reason, "time limit") {
+		t.Errorf("expected the reloaded plugin to time out, got (%v, %q)", pass, reason)
+	}
+
+	_ = os.Remove(path)
+	_ = l.Reload(ctx)
+	if _, ok := registry.Get("custom"); ok {
+		t.Error("expected the removed plugin to be unregistered")
+	}
+}
@@ -0,0 +1,35 @@
+;; Test plugin passing string values. Build with: wat2wasm is_string.wat
+(module
+  (memory (export "memory") 1)
+  (data (i32.const 0) "{\"description\":\"the value is a string\",\"null_safe\":true}")
+  (data (i32.const 64) "value is not a string")
+
+  ;; Bump allocator above the data, reset by every check
+  (global $next (mut i32) (i32.const 1024))
+
+  (func (export "alloc") (param $len i32) (result i32)
+    (local $ptr i32)
+    global.get $next
+    local.set $ptr
+    global.get $next
+    local.get $len
+    i32.add
+    global.set $next
+    local.get $ptr)
+
+  ;; The input starts with {"value": so a string value has its quote at offset 9
+  (func (export "check") (param $ptr i32) (param $len i32) (result i64)
+    i32.const 1024
+    global.set $next
+    local.get $ptr
+    i32.load8_u offset=9
+    i32.const 34
+    i32.eq
+    if (result i64)
+      i64.const 0
+    else
+      i64.const 274877906965 ;; 64 << 32 | 21
+    end)
+
+  (func (export "describe") (result i64)
+    i64.const 56))
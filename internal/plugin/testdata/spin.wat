@@ -0,0 +1,11 @@
+;; Test plugin whose check never returns. Build with: wat2wasm spin.wat
+(module
+  (memory (export "memory") 1)
+
+  (func (export "alloc") (param $len i32) (result i32)
+    i32.const 1024)
+
+  (func (export "check") (param $ptr i32) (param $len i32) (result i64)
+    (loop $forever
+      br $forever)
+    unreachable))
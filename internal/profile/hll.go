@@ -0,0 +1,42 @@
+package profile
+
+import (
+	"math"
+	"math/bits"
+)
+
+// hllPrecision sets 2^12 registers, a standard error of about 1.6%
+const hllPrecision = 12
+
+// hyperLogLog estimates the number of distinct 64-bit hashes it was given
+type hyperLogLog struct {
+	registers [1 << hllPrecision]uint8
+}
+
+func (h *hyperLogLog) add(hash uint64) {
+	idx := hash >> (64 - hllPrecision)
+	// The remaining bits, with a sentinel so the rank is bounded
+	rest := hash<<hllPrecision | 1<<(hllPrecision-1)
+	if rank := uint8(bits.LeadingZeros64(rest) + 1); rank > h.registers[idx] {
+		h.registers[idx] = rank
+	}
+}
+
+// estimate returns the distinct count, using linear counting while registers are still empty
+func (h *hyperLogLog) estimate() int {
+	m := float64(len(h.registers))
+	var sum float64
+	zeros := 0
+	for _, r := range h.registers {
+		sum += math.Ldexp(1, -int(r))
+		if r == 0 {
+			zeros++
+		}
+	}
+
+	e := 0.7213 / (1 + 1.079/m) * m * m / sum
+	if e <= 2.5*m && zeros > 0 {
+		e = m * math.Log(m/float64(zeros))
+	}
+	return int(math.Round(e))
+}
@@ -0,0 +1,207 @@
+// Package profile summarizes the distribution of fields over a run: null and distinct counts,
+// min/max, mean, quantiles and the most frequent values. Distinct counts, quantiles and top
+// values come from sketches (HyperLogLog, t-digest, Space-Saving), so a profile needs the
+// same memory however many records there are.
+package profile
+
+import (
+	"fmt"
+	"hash/maphash"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/singh-anurag-7991/data-guard/internal/domain"
+	"github.com/singh-anurag-7991/data-guard/internal/fieldpath"
+	"github.com/singh-anurag-7991/data-guard/internal/operators"
+)
+
+// quantiles reported for numeric fields
+var quantiles = []struct {
+	name string
+	q    float64
+}{
+	{"p01", 0.01}, {"p05", 0.05}, {"p25", 0.25}, {"p50", 0.5}, {"p75", 0.75}, {"p95", 0.95}, {"p99", 0.99},
+}
+
+// topValues is how many of the most frequent values a profile lists
+const topValues = 10
+
+// Builder profiles one field as its values stream by
+type Builder struct {
+	field  string
+	count  int
+	nulls  int
+	seed   maphash.Seed
+	hll    hyperLogLog
+	top    *topK
+	digest tDigest
+	sum    float64
+
+	minTime, maxTime time.Time
+	minStr, maxStr   string
+	times, strs      int
+}
+
+func NewBuilder(field string) *Builder {
+	return &Builder{field: field, seed: maphash.MakeSeed(), top: newTopK()}
+}
+
+// Add observes one value of the field, nil when the record has none
+func (b *Builder) Add(val interface{}) {
+	if val == nil {
+		b.nulls++
+		return
+	}
+	b.count++
+
+	key := valueKey(val)
+	b.hll.add(maphash.String(b.seed, key))
+	b.top.add(key, val)
+
+	if f, ok := operators.ToFloat(val); ok {
+		b.digest.add(f)
+		b.sum += f
+		return
+	}
+	switch v := val.(type) {
+	case time.Time:
+		if b.times == 0 || v.Before(b.minTime) {
+			b.minTime = v
+		}
+		if b.times == 0 || v.After(b.maxTime) {
+			b.maxTime = v
+		}
+		b.times++
+	case string:
+		if b.strs == 0 || v < b.minStr {
+			b.minStr = v
+		}
+		if b.strs == 0 || v > b.maxStr {
+			b.maxStr = v
+		}
+		b.strs++
+	}
+}
+
+// valueKey identifies a value for distinct counts and top values, so 1 and 1.0 are the same
+func valueKey(val interface{}) string {
+	if d, ok := operators.FormatDecimal(val); ok {
+		return "n" + d
+	}
+	if f, ok := operators.ToFloat(val); ok {
+		return "n" + strconv.FormatFloat(f, 'g', -1, 64) // Infinities and NaN
+	}
+	if s, ok := val.(string); ok {
+		return "s" + s
+	}
+	return fmt.Sprintf("%T:%v", val, val)
+}
+
+// Profile returns what was observed so far
+func (b *Builder) Profile() domain.FieldProfile {
+	p := domain.FieldProfile{Field: b.field, Count: b.count, Nulls: b.nulls}
+	if b.count == 0 {
+		return p
+	}
+	p.Distinct = min(b.hll.estimate(), b.count)
+	for _, vc := range b.top.top(topValues) {
+		p.TopK = append(p.TopK, domain.ValueCount{Value: vc.value, Count: vc.count})
+	}
+
+	switch {
+	case b.digest.total > 0:
+		mean := b.sum / b.digest.total
+		p.Min, p.Max, p.Mean = b.digest.min, b.digest.max, &mean
+		p.Quantiles = make(map[string]float64, len(quantiles))
+		for _, q := range quantiles {
+			p.Quantiles[q.name] = b.digest.quantile(q.q)
+		}
+	case b.times > 0:
+		p.Min, p.Max = b.minTime, b.maxTime
+	case b.strs > 0:
+		p.Min, p.Max = b.minStr, b.maxStr
+	}
+	return p
+}
+
+// Build profiles every top-level field of records plus the given field paths, sorted by field.
+// Records without a field count as nulls of it.
+func Build(records []domain.Record, paths []string) []domain.FieldProfile {
+	fields := map[string]bool{}
+	for _, record := range records {
+		for field := range record {
+			fields[field] = true
+		}
+	}
+	for _, path := range paths {
+		fields[path] = true
+	}
+
+	builders := make([]*Builder, 0, len(fields))
+	for field := range fields {
+		builders = append(builders, NewBuilder(field))
+	}
+	sort.Slice(builders, func(i, j int) bool { return builders[i].field < builders[j].field })
+
+	for _, record := range records {
+		for _, b := range builders {
+			val, _ := fieldpath.Get(record, b.field)
+			b.Add(val)
+		}
+	}
+
+	profiles := make([]domain.FieldProfile, len(builders))
+	for i, b := range builders {
+		profiles[i] = b.Profile()
+	}
+	return profiles
+}
+
+// Stats lists the statistics a profile_change check can compare, quantiles aside ("p50")
+var Stats = []string{"count", "nulls", "null_ratio", "distinct", "min", "max", "mean"}
+
+// Stat returns a statistic of a profile: one of Stats or a quantile name. ok is false when the
+// name is unknown or the statistic is undefined, like the mean of strings.
+func Stat(p domain.FieldProfile, name string) (float64, bool) {
+	switch name {
+	case "count":
+		return float64(p.Count), true
+	case "nulls":
+		return float64(p.Nulls), true
+	case "null_ratio":
+		if p.Count+p.Nulls == 0 {
+			return 0, false
+		}
+		return float64(p.Nulls) / float64(p.Count+p.Nulls), true
+	case "distinct":
+		return float64(p.Distinct), true
+	case "min":
+		return operators.ToFloat(p.Min)
+	case "max":
+		return operators.ToFloat(p.Max)
+	case "mean":
+		if p.Mean == nil {
+			return 0, false
+		}
+		return *p.Mean, true
+	}
+	q, ok := p.Quantiles[name]
+	return q, ok && !math.IsNaN(q)
+}
+
+// IsStat reports whether Stat knows the statistic
+func IsStat(name string) bool {
+	for _, s := range Stats {
+		if s == name {
+			return true
+		}
+	}
+	for _, q := range quantiles {
+		if q.name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,140 @@
+package profile
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/singh-anurag-7991/data-guard/internal/domain"
+)
+
+func TestBuild(t *testing.T) {
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	records := []domain.Record{
+		{"amount": 10, "country": "DE", "at": day, "customer": map[string]interface{}{"tier": "gold"}},
+		{"amount": 20.0, "country": "DE", "at": day.Add(time.Hour)},
+		{"amount": nil, "country": "FR"},
+		{"amount": 30, "country": "DE", "at": day.Add(-time.Hour)},
+	}
+
+	profiles := Build(records, []string{"customer.tier"})
+	byField := map[string]domain.FieldProfile{}
+	var fields []string
+	for _, p := range profiles {
+		byField[p.Field] = p
+		fields = append(fields, p.Field)
+	}
+	if want := []string{"amount", "at", "country", "customer", "customer.tier"}; !reflect.DeepEqual(fields, want) {
+		t.Fatalf("expected fields %v, got %v", want, fields)
+	}
+
+	amount := byField["amount"]
+	if amount.Count != 3 || amount.Nulls != 1 || amount.Distinct != 3 {
+		t.Errorf("amount counts = %d/%d/%d, want 3/1/3", amount.Count, amount.Nulls, amount.Distinct)
+	}
+	if amount.Min != 10.0 || amount.Max != 30.0 || amount.Mean == nil || *amount.Mean != 20 {
+		t.Errorf("amount min/max/mean = %v/%v/%v, want 10/30/20", amount.Min, amount.Max, amount.Mean)
+	}
+	if amount.Quantiles["p50"] != 20 {
+		t.Errorf("amount p50 = %v, want 20", amount.Quantiles["p50"])
+	}
+
+	country := byField["country"]
+	if country.Min != "DE" || country.Max != "FR" || country.Mean != nil {
+		t.Errorf("country min/max/mean = %v/%v/%v, want DE/FR/nil", country.Min, country.Max, country.Mean)
+	}
+	if want := []domain.ValueCount{{Value: "DE", Count: 3}, {Value: "FR", Count: 1}}; !reflect.DeepEqual(country.TopK, want) {
+		t.Errorf("country top_k = %v, want %v", country.TopK, want)
+	}
+
+	if at := byField["at"]; at.Min != day.Add(-time.Hour) || at.Max != day.Add(time.Hour) || at.Nulls != 1 {
+		t.Errorf("at min/max/nulls = %v/%v/%d", at.Min, at.Max, at.Nulls)
+	}
+	if tier := byField["customer.tier"]; tier.Count != 1 || tier.Nulls != 3 {
+		t.Errorf("customer.tier count/nulls = %d/%d, want 1/3", tier.Count, tier.Nulls)
+	}
+}
+
+func TestHyperLogLog(t *testing.T) {
+	for _, n := range []int{10, 1000, 100000} {
+		b := NewBuilder("id")
+		for i := 0; i < n; i++ {
+			b.Add(fmt.Sprintf("id-%d", i))
+			b.Add(fmt.Sprintf("id-%d", i)) // repeats do not count
+		}
+		got := b.Profile().Distinct
+		if math.Abs(float64(got-n)) > 0.05*float64(n) {
+			t.Errorf("distinct of %d values estimated as %d", n, got)
+		}
+	}
+}
+
+func TestTDigest(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	var d tDigest
+	for _, i := range rng.Perm(100000) {
+		d.add(float64(i))
+	}
+
+	for _, q := range []float64{0.01, 0.25, 0.5, 0.75, 0.99} {
+		want := q * 100000
+		if got := d.quantile(q); math.Abs(got-want) > 0.005*100000 {
+			t.Errorf("quantile(%v) = %v, want about %v", q, got, want)
+		}
+	}
+	if d.quantile(0) != 0 || d.quantile(1) != 99999 {
+		t.Errorf("expected the extremes to be exact, got %v and %v", d.quantile(0), d.quantile(1))
+	}
+}
+
+func TestTopK(t *testing.T) {
+	tk := newTopK()
+	// Two heavy hitters among many values seen once
+	for i := 0; i < 1000; i++ {
+		tk.add(fmt.Sprintf("rare-%d", i), i)
+		if i%4 == 0 {
+			tk.add("a", "a")
+		}
+		if i%10 == 0 {
+			tk.add("b", "b")
+		}
+	}
+
+	top := tk.top(2)
+	if len(top) != 2 || top[0].value != "a" || top[1].value != "b" {
+		t.Fatalf("expected a and b on top, got %+v %+v", top[0], top[1])
+	}
+	if top[0].count < 250 {
+		t.Errorf("count of a is an upper bound, got %d", top[0].count)
+	}
+}
+
+func TestStat(t *testing.T) {
+	mean := 2.5
+	p := domain.FieldProfile{Count: 3, Nulls: 1, Distinct: 2, Min: 1.0, Max: "z", Mean: &mean, Quantiles: map[string]float64{"p50": 2}}
+
+	tests := []struct {
+		name string
+		want float64
+		ok   bool
+	}{
+		{"count", 3, true},
+		{"null_ratio", 0.25, true},
+		{"distinct", 2, true},
+		{"min", 1, true},
+		{"max", 0, false}, // not a number
+		{"mean", 2.5, true},
+		{"p50", 2, true},
+		{"p99", 0, false},
+		{"median", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := Stat(p, tt.name)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Stat(%s) = %v, %v; want %v, %v", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package profile
+
+import (
+	"math"
+	"sort"
+)
+
+// digestCompression bounds the number of centroids to a few times this
+const digestCompression = 100
+
+// centroid is the mean of weight values close to each other
+type centroid struct {
+	mean   float64
+	weight float64
+}
+
+// tDigest estimates quantiles of a stream of numbers with bounded memory. Centroids are small
+// near the tails, where quantiles need precision, and large around the median.
+type tDigest struct {
+	centroids []centroid // merged, sorted by mean
+	buffer    []centroid // added since the last merge
+	total     float64
+	min, max  float64
+}
+
+func (d *tDigest) add(x float64) {
+	if d.total == 0 || x < d.min {
+		d.min = x
+	}
+	if d.total == 0 || x > d.max {
+		d.max = x
+	}
+	d.total++
+	d.buffer = append(d.buffer, centroid{mean: x, weight: 1})
+	if len(d.buffer) >= 5*digestCompression {
+		d.merge()
+	}
+}
+
+// merge folds the buffer into the centroids, joining neighbours while the joined centroid
+// stays under the size limit 4·n·q·(1-q)/compression at its quantile q
+func (d *tDigest) merge() {
+	if len(d.buffer) == 0 {
+		return
+	}
+	all := append(d.centroids, d.buffer...)
+	sort.Slice(all, func(i, j int) bool { return all[i].mean < all[j].mean })
+
+	merged := []centroid{all[0]}
+	var before float64 // weight of the centroids before the last merged one
+	for _, c := range all[1:] {
+		last := &merged[len(merged)-1]
+		q := (before + (last.weight+c.weight)/2) / d.total
+		if last.weight+c.weight <= 4*d.total*q*(1-q)/digestCompression {
+			last.mean += (c.mean - last.mean) * c.weight / (last.weight + c.weight)
+			last.weight += c.weight
+			continue
+		}
+		before += last.weight
+		merged = append(merged, c)
+	}
+	d.centroids, d.buffer = merged, nil
+}
+
+// quantile interpolates between the centroids around q·n, within the exact min and max
+func (d *tDigest) quantile(q float64) float64 {
+	d.merge()
+	if len(d.centroids) == 0 {
+		return math.NaN()
+	}
+	target := q * d.total
+
+	// The mean of a centroid stands at the middle of its weight
+	prevMean, prevPos := d.min, 0.0
+	var cum float64
+	for _, c := range d.centroids {
+		pos := cum + c.weight/2
+		if target < pos {
+			if pos == prevPos {
+				return c.mean
+			}
+			return prevMean + (c.mean-prevMean)*(target-prevPos)/(pos-prevPos)
+		}
+		prevMean, prevPos = c.mean, pos
+		cum += c.weight
+	}
+	if d.total == prevPos {
+		return d.max
+	}
+	return prevMean + (d.max-prevMean)*(target-prevPos)/(d.total-prevPos)
+}
@@ -0,0 +1,67 @@
+package profile
+
+import "sort"
+
+// topKCapacity is how many values the top-k sketch counts at once
+const topKCapacity = 64
+
+// topK finds the most frequent values with the Space-Saving algorithm: once the sketch is
+// full, a new value replaces the least frequent one and inherits its count, so counts are
+// upper bounds exact for values that were never evicted.
+type topK struct {
+	counts map[string]*valueCount
+}
+
+type valueCount struct {
+	value interface{} // first value seen with the key
+	count int
+}
+
+func newTopK() *topK {
+	return &topK{counts: map[string]*valueCount{}}
+}
+
+func (t *topK) add(key string, value interface{}) {
+	if vc, ok := t.counts[key]; ok {
+		vc.count++
+		return
+	}
+	if len(t.counts) < topKCapacity {
+		t.counts[key] = &valueCount{value: value, count: 1}
+		return
+	}
+
+	var minKey string
+	var least *valueCount
+	for k, vc := range t.counts {
+		if least == nil || vc.count < least.count || (vc.count == least.count && k < minKey) {
+			minKey, least = k, vc
+		}
+	}
+	delete(t.counts, minKey)
+	t.counts[key] = &valueCount{value: value, count: least.count + 1}
+}
+
+// top returns the n most frequent values, most frequent first
+func (t *topK) top(n int) []*valueCount {
+	keys := make([]string, 0, len(t.counts))
+	for k := range t.counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := t.counts[keys[i]], t.counts[keys[j]]
+		if a.count != b.count {
+			return a.count > b.count
+		}
+		return keys[i] < keys[j]
+	})
+
+	if len(keys) > n {
+		keys = keys[:n]
+	}
+	top := make([]*valueCount, len(keys))
+	for i, k := range keys {
+		top[i] = t.counts[k]
+	}
+	return top
+}
@@ -0,0 +1,132 @@
+// Package reference resolves the reference sets of exists_in checks: tables of the connected
+// database, stored lookup lists and the latest dataset of another source.
+package reference
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/singh-anurag-7991/data-guard/internal/domain"
+	"github.com/singh-anurag-7991/data-guard/internal/engine"
+	"github.com/singh-anurag-7991/data-guard/internal/fieldpath"
+	"github.com/singh-anurag-7991/data-guard/internal/storage"
+)
+
+// Resolver implements engine.ReferenceResolver and engine.BaselineResolver
+type Resolver struct {
+	store  storage.Provider
+	tables engine.ReferenceResolver // nil without a database
+}
+
+// NewResolver creates a resolver; db may be nil, in which case table references fail
+func NewResolver(store storage.Provider, db engine.TableQuerier) *Resolver {
+	r := &Resolver{store: store}
+	if db != nil {
+		r.tables = engine.TableReferences(db)
+	}
+	return r
+}
+
+// ExistingKeys returns the keys found in the reference
+func (r *Resolver) ExistingKeys(ctx context.Context, ref domain.Reference, keys []string) ([]string, error) {
+	switch {
+	case ref.Lookup != "":
+		values, err := r.store.GetLookup(ctx, ref.Lookup)
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, fmt.Errorf("lookup %q does not exist", ref.Lookup)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return intersect(keys, values), nil
+
+	case ref.Source != "":
+		if ref.Column == "" {
+			return nil, fmt.Errorf("source references need a column")
+		}
+		records, err := r.store.GetSnapshot(ctx, ref.Source)
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, fmt.Errorf("no dataset ingested for source %q", ref.Source)
+		}
+		if err != nil {
+			return nil, err
+		}
+		var values []interface{}
+		for _, record := range records {
+			for _, m := range fieldpath.Resolve(record, ref.Column) {
+				if m.Value != nil {
+					values = append(values, m.Value)
+				}
+			}
+		}
+		return intersect(keys, values), nil
+
+	case ref.Table != "":
+		if r.tables == nil {
+			return nil, fmt.Errorf("table references need a database connection")
+		}
+		return r.tables.ExistingKeys(ctx, ref, keys)
+
+	default:
+		return nil, fmt.Errorf("ref needs a table, lookup or source")
+	}
+}
+
+// ReferenceValues returns the values of the reference, the baseline of shift checks
+func (r *Resolver) ReferenceValues(ctx context.Context, ref domain.Reference) ([]interface{}, error) {
+	switch {
+	case ref.Lookup != "":
+		values, err := r.store.GetLookup(ctx, ref.Lookup)
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, fmt.Errorf("lookup %q does not exist", ref.Lookup)
+		}
+		return values, err
+
+	case ref.Source != "":
+		if ref.Column == "" {
+			return nil, fmt.Errorf("source references need a column")
+		}
+		records, err := r.store.GetSnapshot(ctx, ref.Source)
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, fmt.Errorf("source %q: %w", ref.Source, engine.ErrNoReferenceData)
+		}
+		if err != nil {
+			return nil, err
+		}
+		var values []interface{}
+		for _, record := range records {
+			for _, m := range fieldpath.Resolve(record, ref.Column) {
+				if m.Value != nil {
+					values = append(values, m.Value)
+				}
+			}
+		}
+		return values, nil
+
+	case ref.Table != "":
+		tables, ok := r.tables.(engine.BaselineResolver)
+		if !ok {
+			return nil, fmt.Errorf("table references need a database connection")
+		}
+		return tables.ReferenceValues(ctx, ref)
+
+	default:
+		return nil, fmt.Errorf("ref needs a table, lookup or source")
+	}
+}
+
+// intersect returns the keys matching one of values
+func intersect(keys []string, values []interface{}) []string {
+	set := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		set[engine.ReferenceKey(v)] = struct{}{}
+	}
+	var found []string
+	for _, key := range keys {
+		if _, ok := set[key]; ok {
+			found = append(found, key)
+		}
+	}
+	return found
+}
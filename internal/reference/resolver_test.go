@@ -0,0 +1,71 @@
+package reference
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/singh-anurag-7991/data-guard/internal/domain"
+	"github.com/singh-anurag-7991/data-guard/internal/engine"
+	"github.com/singh-anurag-7991/data-guard/internal/storage"
+)
+
+func TestResolver_ExistingKeys(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	_ = store.SaveLookup(ctx, "countries", []interface{}{"DE", "FR", 7.0})
+	_ = store.SaveSnapshot(ctx, "customers", []domain.Record{
+		{"id": 1, "tags": []interface{}{"a"}},
+		{"id": 2.0},
+	})
+	r := NewResolver(store, nil)
+
+	tests := []struct {
+		name    string
+		ref     domain.Reference
+		keys    []string
+		want    []string
+		wantErr bool
+	}{
+		{"lookup", domain.Reference{Lookup: "countries"}, []string{"DE", "US", "7"}, []string{"DE", "7"}, false},
+		{"source", domain.Reference{Source: "customers", Column: "id"}, []string{"1", "2", "3"}, []string{"1", "2"}, false},
+		{"source_wildcard", domain.Reference{Source: "customers", Column: "tags[*]"}, []string{"a", "b"}, []string{"a"}, false},
+		{"missing_lookup", domain.Reference{Lookup: "nope"}, []string{"x"}, nil, true},
+		{"never_ingested", domain.Reference{Source: "nope", Column: "id"}, []string{"x"}, nil, true},
+		{"table_without_db", domain.Reference{Table: "customers", Column: "id"}, []string{"1"}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.ExistingKeys(ctx, tt.ref, tt.keys)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestResolver_ReferenceValues(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	_ = store.SaveLookup(ctx, "countries", []interface{}{"DE", "FR"})
+	_ = store.SaveSnapshot(ctx, "orders", []domain.Record{{"price": 1.0}, {"price": nil}, {}, {"price": 3.0}})
+	r := NewResolver(store, nil)
+
+	if got, err := r.ReferenceValues(ctx, domain.Reference{Source: "orders", Column: "price"}); err != nil || !reflect.DeepEqual(got, []interface{}{1.0, 3.0}) {
+		t.Errorf("expected the non-null prices, got %v (%v)", got, err)
+	}
+	if got, err := r.ReferenceValues(ctx, domain.Reference{Lookup: "countries"}); err != nil || len(got) != 2 {
+		t.Errorf("expected the lookup values, got %v (%v)", got, err)
+	}
+	if _, err := r.ReferenceValues(ctx, domain.Reference{Source: "nope", Column: "price"}); !errors.Is(err, engine.ErrNoReferenceData) {
+		t.Errorf("expected ErrNoReferenceData, got %v", err)
+	}
+	if _, err := r.ReferenceValues(ctx, domain.Reference{Table: "orders", Column: "price"}); err == nil {
+		t.Error("expected table references to need a database")
+	}
+}
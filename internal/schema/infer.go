@@ -0,0 +1,366 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/netip"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/singh-anurag-7991/data-guard/internal/domain"
+	"github.com/singh-anurag-7991/data-guard/internal/operators"
+)
+
+// Thresholds of the suggested rules
+const (
+	maxEnumValues  = 10   // at most this many distinct strings make an enum
+	minEnumRepeats = 3    // ...each seen this many times on average
+	minSamples     = 10   // values needed before suggesting a range or pattern
+	rangeTolerance = 0.02 // the range spans the 1st to 99th percentile, so allow 2% outside
+)
+
+// Suggestion is a schema and starter rules inferred from sample records
+type Suggestion struct {
+	Records int           `json:"records"` // size of the sample
+	Schema  domain.Schema `json:"schema"`
+	Rules   []domain.Rule `json:"rules"`
+}
+
+// DefaultSampleSize is how many rows SampleTable fetches when no limit is given
+const DefaultSampleSize = 1000
+
+// RowFetcher runs queries against a database (satisfied by *postgres.Client)
+type RowFetcher interface {
+	FetchRows(ctx context.Context, query string, args ...interface{}) ([]domain.Record, error)
+}
+
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// IsIdentifier reports whether name is safe to put in SQL unquoted as a table or column name:
+// letters, digits and underscores, optionally qualified by a schema ("sales.orders")
+func IsIdentifier(name string) bool {
+	return identifier.MatchString(name)
+}
+
+// SampleTable fetches up to limit rows of a table to infer from
+func SampleTable(ctx context.Context, db RowFetcher, table string, limit int) ([]domain.Record, error) {
+	if !IsIdentifier(table) {
+		return nil, fmt.Errorf("invalid table name %q", table)
+	}
+	if limit <= 0 {
+		limit = DefaultSampleSize
+	}
+	return db.FetchRows(ctx, fmt.Sprintf("SELECT * FROM %s LIMIT $1", table), limit)
+}
+
+// fieldStats is what was observed of one field path
+type fieldStats struct {
+	present int // objects holding the field (elements, for array items)
+	nulls   int
+	kinds   map[string]int
+	numbers []float64
+	strings map[string]int // distinct strings, up to maxEnumValues+1
+	texts   []string       // every string, for formats
+}
+
+// inferrer collects field statistics over the sample
+type inferrer struct {
+	stats   map[string]*fieldStats
+	objects map[string]int // objects seen per path, "" counting the records
+}
+
+// Infer proposes a schema and rules describing records: a type per field (nullable when null
+// was seen, optional when missing), not_null for fields always present and set, enums for
+// low-cardinality strings, ranges from the 1st to 99th percentile of numbers and regex patterns
+// for codes of digits or letters. Nested objects become field paths, arrays of them wildcard paths.
+func Infer(records []domain.Record) Suggestion {
+	in := newInferrer(records)
+
+	s := Suggestion{Records: len(records), Schema: domain.Schema{}, Rules: []domain.Rule{}}
+	paths := make([]string, 0, len(in.stats))
+	for path := range in.stats {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		st := in.stats[path]
+		spec := st.inferType()
+		if spec == "" {
+			continue // Mixed types, objects and arrays get no type
+		}
+		optional := in.optional(path)
+
+		var keywords []string
+		if optional {
+			keywords = append(keywords, "optional")
+		}
+		if st.nulls > 0 {
+			keywords = append(keywords, "nullable")
+		}
+		s.Schema[path] = strings.Join(append(keywords, spec), " ")
+		s.Rules = append(s.Rules, st.suggestRules(path, spec, optional)...)
+	}
+	return s
+}
+
+// optional reports whether path is missing from some records, itself or by an enclosing
+// object being absent or null
+func (in *inferrer) optional(path string) bool {
+	optional := in.missing(path)
+	for parent := parentOf(path); parent != "" && !optional; parent = parentOf(parent) {
+		optional = in.missing(parent) || in.stats[parent].nulls > 0
+	}
+	return optional
+}
+
+// missing reports whether some object that could hold path did not
+func (in *inferrer) missing(path string) bool {
+	if strings.HasSuffix(path, "[*]") {
+		return false // Array elements are never missing
+	}
+	return in.stats[path].present < in.objects[parentOf(path)]
+}
+
+func newInferrer(records []domain.Record) *inferrer {
+	in := &inferrer{stats: map[string]*fieldStats{}, objects: map[string]int{}}
+	for _, record := range records {
+		in.observeObject(record, "")
+	}
+	return in
+}
+
+func (in *inferrer) field(path string) *fieldStats {
+	st, ok := in.stats[path]
+	if !ok {
+		st = &fieldStats{kinds: map[string]int{}, strings: map[string]int{}}
+		in.stats[path] = st
+	}
+	return st
+}
+
+// observeObject records the fields of one object found at path
+func (in *inferrer) observeObject(obj map[string]interface{}, path string) {
+	in.objects[path]++
+	for key, val := range obj {
+		child := key
+		if path != "" {
+			child = path + "." + key
+		}
+		st := in.field(child)
+		st.present++
+		in.observeValue(st, val, child)
+	}
+}
+
+func (in *inferrer) observeValue(st *fieldStats, val interface{}, path string) {
+	st.kinds[kindOf(val)]++
+
+	switch v := val.(type) {
+	case nil:
+		st.nulls++
+	case map[string]interface{}:
+		in.observeObject(v, path)
+	case []interface{}:
+		for _, item := range v {
+			items := in.field(path + "[*]")
+			items.present++
+			in.observeValue(items, item, path+"[*]")
+		}
+	case string:
+		st.texts = append(st.texts, v)
+		if _, seen := st.strings[v]; seen || len(st.strings) <= maxEnumValues {
+			st.strings[v]++
+		}
+	default:
+		if f, ok := operators.ToFloat(val); ok {
+			st.numbers = append(st.numbers, f)
+		}
+	}
+}
+
+// kindOf classifies a value, including the types pgx returns for Postgres columns
+func kindOf(val interface{}) string {
+	switch val.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case time.Time:
+		return "timestamp"
+	case pgtype.Time:
+		return "time"
+	case [16]byte:
+		return "uuid"
+	case netip.Addr, netip.Prefix:
+		return "ip"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	}
+	if _, ok := operators.ToFloat(val); ok {
+		return "number"
+	}
+	return "other"
+}
+
+// inferType returns the schema type all non-null values share, "" if there is none
+func (st *fieldStats) inferType() string {
+	var kind string
+	for k := range st.kinds {
+		if k == "null" {
+			continue
+		}
+		if kind != "" {
+			return ""
+		}
+		kind = k
+	}
+
+	switch kind {
+	case "boolean", "timestamp", "time", "uuid", "ip":
+		return kind
+	case "number":
+		for _, f := range st.numbers {
+			if f != math.Trunc(f) {
+				return Number
+			}
+		}
+		return Integer
+	case "string":
+		return inferStringType(st.texts)
+	}
+	return ""
+}
+
+// stringFormats are tried in order; every value must have the type
+var stringFormats = []string{UUID, Email, URL, IP, Date, Timestamp, Time, Decimal}
+
+func inferStringType(texts []string) string {
+	for _, name := range stringFormats {
+		t, _ := Parse(name)
+		if !all(texts, func(s string) bool { return t.Valid(s) }) {
+			continue
+		}
+		if name != Decimal {
+			return name
+		}
+		// Digit codes such as zip codes are strings, amounts have a decimal point
+		if all(texts, func(s string) bool { return strings.Contains(s, ".") }) {
+			return decimalSpec(texts)
+		}
+	}
+	return String
+}
+
+// decimalSpec sizes a decimal type to the digits observed
+func decimalSpec(texts []string) string {
+	whole, scale := 1, 0
+	for _, s := range texts {
+		w, f, _ := strings.Cut(strings.TrimLeft(s, "+-"), ".")
+		whole = max(whole, len(strings.TrimLeft(w, "0")))
+		scale = max(scale, len(strings.TrimRight(f, "0")))
+	}
+	return fmt.Sprintf("decimal:%d,%d", whole+scale, scale)
+}
+
+// Character classes of suggested regex patterns
+var codeClasses = []struct {
+	class string
+	re    *regexp.Regexp
+}{
+	{"[0-9]", regexp.MustCompile(`^[0-9]+$`)},
+	{"[A-Z]", regexp.MustCompile(`^[A-Z]+$`)},
+	{"[a-z]", regexp.MustCompile(`^[a-z]+$`)},
+}
+
+// suggestRules proposes rules for a field of the given type
+func (st *fieldStats) suggestRules(path, spec string, optional bool) []domain.Rule {
+	var rules []domain.Rule
+	if !optional && st.nulls == 0 {
+		rules = append(rules, domain.Rule{ID: path + "_not_null", Field: path, Checks: []domain.Check{{Op: "not_null"}}})
+	}
+	notNull := &domain.Condition{Field: path, Op: "not_null"}
+
+	switch {
+	case spec == String && len(st.strings) <= maxEnumValues && len(st.texts) >= minEnumRepeats*len(st.strings):
+		values := make([]string, 0, len(st.strings))
+		for v := range st.strings {
+			values = append(values, v)
+		}
+		sort.Strings(values)
+		rules = append(rules, domain.Rule{ID: path + "_enum", Field: path, When: notNull, Checks: []domain.Check{{Op: "enum", Value: values}}})
+	case spec == String:
+		if pattern := codePattern(st.texts); pattern != "" {
+			rules = append(rules, domain.Rule{ID: path + "_pattern", Field: path, When: notNull, Checks: []domain.Check{{Op: "regex", Value: pattern}}})
+		}
+	case (spec == Integer || spec == Number) && len(st.numbers) >= minSamples:
+		sorted := append([]float64{}, st.numbers...)
+		sort.Float64s(sorted)
+		lo, hi := percentile(sorted, 0.01), percentile(sorted, 0.99)
+		rules = append(rules, domain.Rule{
+			ID:        path + "_range",
+			Field:     path,
+			When:      notNull,
+			Severity:  domain.SeverityWarning,
+			Tolerance: rangeTolerance,
+			Checks:    []domain.Check{{Op: "expr", Value: fmt.Sprintf("$ >= %s && $ <= %s", formatNumber(lo), formatNumber(hi))}},
+		})
+	}
+	return rules
+}
+
+// codePattern describes strings made of one character class, e.g. ^[0-9]{5}$ for zip codes
+func codePattern(texts []string) string {
+	if len(texts) < minSamples {
+		return ""
+	}
+	for _, cc := range codeClasses {
+		if !all(texts, cc.re.MatchString) {
+			continue
+		}
+		lo, hi := len(texts[0]), len(texts[0])
+		for _, s := range texts {
+			lo, hi = min(lo, len(s)), max(hi, len(s))
+		}
+		if lo == hi {
+			return fmt.Sprintf("^%s{%d}$", cc.class, lo)
+		}
+		return fmt.Sprintf("^%s{%d,%d}$", cc.class, lo, hi)
+	}
+	return ""
+}
+
+// percentile returns the nearest-rank percentile of sorted values
+func percentile(sorted []float64, p float64) float64 {
+	i := int(math.Ceil(p*float64(len(sorted)))) - 1
+	return sorted[max(0, min(i, len(sorted)-1))]
+}
+
+// parentOf returns the object path holding path: "customer" for "customer.zip", "items[*]"
+// for "items[*].sku"; array element paths belong to their array ("items" for "items[*]")
+func parentOf(path string) string {
+	if strings.HasSuffix(path, "[*]") {
+		return strings.TrimSuffix(path, "[*]")
+	}
+	if i := strings.LastIndex(path, "."); i >= 0 {
+		return path[:i]
+	}
+	return ""
+}
+
+func all(texts []string, ok func(string) bool) bool {
+	for _, s := range texts {
+		if !ok(s) {
+			return false
+		}
+	}
+	return len(texts) > 0
+}
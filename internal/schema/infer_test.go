@@ -0,0 +1,83 @@
+package schema
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/singh-anurag-7991/data-guard/internal/domain"
+)
+
+func TestInfer(t *testing.T) {
+	var records []domain.Record
+	for i := 0; i < 100; i++ {
+		r := domain.Record{
+			"id":     float64(i + 1),
+			"status": []string{"open", "paid", "shipped"}[i%3],
+			"email":  fmt.Sprintf("user%d@example.com", i),
+			"price":  fmt.Sprintf("%d.%02d", i, i%100),
+			"zip":    fmt.Sprintf("%05d", 10000+i),
+			"score":  float64(i) / 2,
+			"customer": map[string]interface{}{
+				"name": fmt.Sprintf("Customer %d", i),
+			},
+			"items": []interface{}{map[string]interface{}{"sku": "A1", "qty": float64(i % 4)}},
+		}
+		if i%10 == 0 {
+			r["note"] = nil
+		} else if i%2 == 0 {
+			r["note"] = "call first"
+		}
+		if i == 5 {
+			delete(r, "customer")
+		}
+		records = append(records, r)
+	}
+
+	got := Infer(records)
+	wantSchema := domain.Schema{
+		"id":            "integer",
+		"status":        "string",
+		"email":         "email",
+		"price":         "decimal:4,2",
+		"zip":           "string",
+		"score":         "number",
+		"customer.name": "optional string",
+		"items[*].sku":  "string",
+		"items[*].qty":  "integer",
+		"note":          "optional nullable string",
+	}
+	if got.Records != 100 || !reflect.DeepEqual(got.Schema, wantSchema) {
+		t.Errorf("unexpected schema:\n got %v\nwant %v", got.Schema, wantSchema)
+	}
+
+	rules := map[string]domain.Rule{}
+	for _, r := range got.Rules {
+		rules[r.ID] = r
+	}
+	if _, ok := rules["id_not_null"]; !ok {
+		t.Error("expected not_null on a field always set")
+	}
+	if _, ok := rules["note_not_null"]; ok {
+		t.Error("expected no not_null on a nullable field")
+	}
+	if r := rules["status_enum"]; !reflect.DeepEqual(r.Checks[0].Value, []string{"open", "paid", "shipped"}) {
+		t.Errorf("unexpected enum: %+v", r)
+	}
+	if r := rules["zip_pattern"]; r.Checks[0].Value != "^[0-9]{5}$" {
+		t.Errorf("unexpected pattern: %+v", r)
+	}
+	if r := rules["id_range"]; r.Checks[0].Value != "$ >= 1 && $ <= 99" || r.Severity != domain.SeverityWarning {
+		t.Errorf("unexpected range: %+v", r)
+	}
+	if _, ok := rules["email_enum"]; ok {
+		t.Error("expected no enum on a high-cardinality field")
+	}
+}
+
+func TestInfer_MixedTypes(t *testing.T) {
+	got := Infer([]domain.Record{{"v": "a"}, {"v": 1.0}})
+	if _, ok := got.Schema["v"]; ok {
+		t.Errorf("expected no type for mixed values, got %v", got.Schema)
+	}
+}
@@ -0,0 +1,304 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/singh-anurag-7991/data-guard/internal/domain"
+)
+
+// Imported is a JSON Schema converted to a DataGuard schema and rules
+type Imported struct {
+	Schema      domain.Schema `json:"schema"`
+	Rules       []domain.Rule `json:"rules"`
+	Unsupported []string      `json:"unsupported"` // JSON pointers of keywords not converted, e.g. "#/properties/tags/uniqueItems"
+}
+
+// annotations carry no constraint and are skipped silently
+var annotations = map[string]bool{
+	"$schema": true, "$id": true, "$comment": true, "$anchor": true, "$defs": true, "definitions": true,
+	"title": true, "description": true, "examples": true, "default": true, "deprecated": true,
+	"readOnly": true, "writeOnly": true, "contentMediaType": true, "contentEncoding": true,
+}
+
+// formats maps JSON Schema string formats to schema types
+var formats = map[string]string{
+	"email":     Email,
+	"uuid":      UUID,
+	"uri":       URL, // http(s) only
+	"ipv4":      IP,
+	"ipv6":      IP,
+	"date-time": Timestamp,
+	"date":      Date,
+}
+
+// FromJSONSchema converts a JSON Schema (draft 2020-12) document. Object properties become
+// field paths ("customer.zip"), array items wildcard paths ("items[*].sku"). Fields outside
+// "required", or inside an optional object, are optional; "null" in "type" makes them nullable.
+// Constraints without a schema type (enum, pattern, bounds, lengths) become rules that only
+// apply to non-null values. Local "$ref"s are followed.
+func FromJSONSchema(doc []byte) (Imported, error) {
+	var root map[string]interface{}
+	if err := json.Unmarshal(doc, &root); err != nil {
+		return Imported{}, fmt.Errorf("invalid JSON Schema: %w", err)
+	}
+	c := &converter{
+		root:   root,
+		refs:   map[string]bool{},
+		result: Imported{Schema: domain.Schema{}, Rules: []domain.Rule{}, Unsupported: []string{}},
+	}
+	c.convert(root, "", "#", false)
+	return c.result, nil
+}
+
+type converter struct {
+	root   map[string]interface{}
+	refs   map[string]bool // $refs being followed, to stop on recursion
+	result Imported
+}
+
+// convert adds the constraints of node, describing the field at path ("" for the record)
+func (c *converter) convert(node map[string]interface{}, path, pointer string, optional bool) {
+	if ref, ok := node["$ref"].(string); ok {
+		c.follow(ref, path, pointer, optional)
+	}
+
+	types, nullable, ok := nodeTypes(node["type"])
+	if !ok || len(types) > 1 {
+		c.unsupported(pointer, "type")
+		types = nil
+	}
+	typ := ""
+	if len(types) == 1 {
+		typ = types[0]
+	}
+	if typ == "" && (path == "" || node["properties"] != nil) {
+		typ = "object" // "type" is often left out of objects
+	}
+	if path == "" && typ != "object" {
+		c.unsupported(pointer, "type") // Records are objects
+		typ = ""
+	}
+
+	var constraints []constraint
+	spec := ""
+	handled := map[string]bool{"$ref": true, "type": true}
+
+	switch typ {
+	case "object":
+		handled["properties"], handled["required"] = true, true
+		required := map[string]bool{}
+		if list, ok := node["required"].([]interface{}); ok {
+			for _, name := range list {
+				if s, ok := name.(string); ok {
+					required[s] = true
+				}
+			}
+		}
+		props, _ := node["properties"].(map[string]interface{})
+		for _, name := range sortedKeys(props) {
+			child, ok := props[name].(map[string]interface{})
+			if !ok {
+				c.unsupported(pointer+"/properties", escapePointer(name))
+				continue
+			}
+			c.convert(child, joinPath(path, name), pointer+"/properties/"+escapePointer(name), optional || !required[name])
+		}
+		if len(props) == 0 && path != "" {
+			spec = "object" // Declared, so strict mode leaves its content alone
+		}
+	case "array":
+		handled["items"] = true
+		if items, ok := node["items"].(map[string]interface{}); ok {
+			c.convert(items, path+"[*]", pointer+"/items", optional)
+		} else if path != "" {
+			spec = "array"
+		}
+	case "integer":
+		spec = Integer
+		var lo, hi string
+		if f, ok := number(node["minimum"]); ok {
+			lo, handled["minimum"] = formatNumber(math.Ceil(f)), true
+		}
+		if f, ok := number(node["maximum"]); ok {
+			hi, handled["maximum"] = formatNumber(math.Floor(f)), true
+		}
+		if lo != "" || hi != "" {
+			spec = fmt.Sprintf("integer:%s..%s", lo, hi)
+		}
+	case "number":
+		spec = Number
+	case "boolean":
+		spec = Boolean
+	case "string":
+		spec = String
+		if format, ok := node["format"].(string); ok {
+			handled["format"] = true
+			if mapped, ok := formats[format]; ok {
+				spec = mapped
+			} else {
+				c.unsupported(pointer, "format")
+			}
+		}
+	case "":
+	default:
+		c.unsupported(pointer, "type")
+	}
+
+	// Constraints checked by rules
+	if values, ok := node["enum"].([]interface{}); ok {
+		handled["enum"] = true
+		constraints = append(constraints, constraint{"enum", domain.Check{Op: "enum", Value: values}})
+	}
+	if val, ok := node["const"]; ok {
+		handled["const"] = true
+		constraints = append(constraints, constraint{"const", domain.Check{Op: "eq", Value: val}})
+	}
+	if pattern, ok := node["pattern"].(string); ok {
+		handled["pattern"] = true
+		constraints = append(constraints, constraint{"pattern", domain.Check{Op: "regex", Value: pattern}})
+	}
+	for _, bound := range []struct{ keyword, expr string }{
+		{"minimum", "$ >= %s"},
+		{"maximum", "$ <= %s"},
+		{"exclusiveMinimum", "$ > %s"},
+		{"exclusiveMaximum", "$ < %s"},
+		{"minLength", "len($) >= %s"},
+		{"maxLength", "len($) <= %s"},
+	} {
+		if f, ok := number(node[bound.keyword]); ok && !handled[bound.keyword] {
+			handled[bound.keyword] = true
+			constraints = append(constraints, constraint{bound.keyword, domain.Check{Op: "expr", Value: fmt.Sprintf(bound.expr, formatNumber(f))}})
+		}
+	}
+
+	for _, keyword := range sortedKeys(node) {
+		if !handled[keyword] && !annotations[keyword] {
+			c.unsupported(pointer, keyword)
+		}
+	}
+
+	if path == "" {
+		for _, con := range constraints {
+			c.unsupported(pointer, con.keyword) // Constraints on the whole record
+		}
+		return
+	}
+	if spec != "" {
+		var keywords []string
+		if optional {
+			keywords = append(keywords, "optional")
+		}
+		if nullable {
+			keywords = append(keywords, "nullable")
+		}
+		c.result.Schema[path] = strings.Join(append(keywords, spec), " ")
+	}
+	for _, con := range constraints {
+		c.result.Rules = append(c.result.Rules, domain.Rule{
+			ID:     fmt.Sprintf("%s_%s", path, con.keyword),
+			Field:  path,
+			When:   &domain.Condition{Field: path, Op: "not_null"},
+			Checks: []domain.Check{con.check},
+		})
+	}
+}
+
+// constraint is a check converted from a keyword
+type constraint struct {
+	keyword string
+	check   domain.Check
+}
+
+// follow converts the target of a local $ref in place of the referencing node
+func (c *converter) follow(ref, path, pointer string, optional bool) {
+	if !strings.HasPrefix(ref, "#") || c.refs[ref] {
+		c.unsupported(pointer, "$ref") // Remote or recursive
+		return
+	}
+	var node interface{} = c.root
+	for _, seg := range strings.Split(strings.TrimPrefix(ref, "#"), "/")[1:] {
+		m, ok := node.(map[string]interface{})
+		if !ok {
+			node = nil
+			break
+		}
+		node = m[unescapePointer(seg)]
+	}
+	target, ok := node.(map[string]interface{})
+	if !ok {
+		c.unsupported(pointer, "$ref")
+		return
+	}
+	c.refs[ref] = true
+	c.convert(target, path, ref, optional)
+	delete(c.refs, ref)
+}
+
+func (c *converter) unsupported(pointer, keyword string) {
+	c.result.Unsupported = append(c.result.Unsupported, pointer+"/"+keyword)
+}
+
+// nodeTypes reads "type", a name or a list of names; "null" is reported as nullable
+func nodeTypes(v interface{}) (types []string, nullable bool, ok bool) {
+	var names []interface{}
+	switch t := v.(type) {
+	case nil:
+		return nil, false, true
+	case string:
+		names = []interface{}{t}
+	case []interface{}:
+		names = t
+	default:
+		return nil, false, false
+	}
+	for _, name := range names {
+		s, isString := name.(string)
+		if !isString {
+			return nil, false, false
+		}
+		if s == "null" {
+			nullable = true
+		} else {
+			types = append(types, s)
+		}
+	}
+	return types, nullable, true
+}
+
+func number(v interface{}) (float64, bool) {
+	f, ok := v.(float64)
+	return f, ok
+}
+
+func formatNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func escapePointer(s string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(s)
+}
+
+func unescapePointer(s string) string {
+	return strings.NewReplacer("~1", "/", "~0", "~").Replace(s)
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,91 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/singh-anurag-7991/data-guard/internal/domain"
+)
+
+func TestFromJSONSchema(t *testing.T) {
+	doc := `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"title": "Order",
+		"type": "object",
+		"required": ["id", "email", "items"],
+		"properties": {
+			"id": { "type": "integer", "minimum": 1 },
+			"email": { "type": ["string", "null"], "format": "email" },
+			"status": { "enum": ["open", "paid"] },
+			"code": { "type": "string", "pattern": "^[A-Z]{3}$", "minLength": 3 },
+			"total": { "type": "number", "exclusiveMinimum": 0 },
+			"customer": { "$ref": "#/$defs/customer" },
+			"items": {
+				"type": "array",
+				"uniqueItems": true,
+				"items": {
+					"type": "object",
+					"required": ["sku"],
+					"properties": { "sku": { "type": "string" }, "qty": { "type": "integer", "maximum": 99 } }
+				}
+			},
+			"tags": { "type": "array" },
+			"ts": { "type": "string", "format": "hostname" }
+		},
+		"additionalProperties": false,
+		"$defs": {
+			"customer": { "type": "object", "required": ["zip"], "properties": { "zip": { "type": "string" } } }
+		}
+	}`
+
+	got, err := FromJSONSchema([]byte(doc))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	wantSchema := domain.Schema{
+		"id":           "integer:1..",
+		"email":        "nullable email",
+		"code":         "optional string",
+		"total":        "optional number",
+		"customer.zip": "optional string",
+		"items[*].sku": "string",
+		"items[*].qty": "optional integer:..99",
+		"tags":         "optional array",
+		"ts":           "optional string",
+	}
+	if !reflect.DeepEqual(got.Schema, wantSchema) {
+		t.Errorf("unexpected schema:\n got %v\nwant %v", got.Schema, wantSchema)
+	}
+
+	var rules []string
+	for _, r := range got.Rules {
+		rules = append(rules, r.ID)
+		if r.When == nil || r.When.Field != r.Field || r.When.Op != "not_null" {
+			t.Errorf("rule %s should only apply to present values, got %+v", r.ID, r.When)
+		}
+	}
+	wantRules := []string{"code_pattern", "code_minLength", "status_enum", "total_exclusiveMinimum"}
+	if !reflect.DeepEqual(rules, wantRules) {
+		t.Errorf("expected rules %v, got %v", wantRules, rules)
+	}
+	if c := got.Rules[1].Checks[0]; c.Op != "expr" || c.Value != "len($) >= 3" {
+		t.Errorf("unexpected minLength check: %+v", c)
+	}
+
+	wantUnsupported := []string{"#/properties/items/uniqueItems", "#/properties/ts/format", "#/additionalProperties"}
+	if !reflect.DeepEqual(got.Unsupported, wantUnsupported) {
+		t.Errorf("expected unsupported %v, got %v", wantUnsupported, got.Unsupported)
+	}
+}
+
+func TestFromJSONSchema_Invalid(t *testing.T) {
+	if _, err := FromJSONSchema([]byte(`[`)); err == nil {
+		t.Error("expected invalid JSON to be rejected")
+	}
+
+	got, _ := FromJSONSchema([]byte(`{"$defs": {"node": {"type": "object", "properties": {"next": {"$ref": "#/$defs/node"}}}}, "properties": {"head": {"$ref": "#/$defs/node"}}}`))
+	if !reflect.DeepEqual(got.Unsupported, []string{"#/$defs/node/properties/next/$ref"}) {
+		t.Errorf("expected the recursive $ref to be reported, got %v", got.Unsupported)
+	}
+}
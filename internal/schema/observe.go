@@ -0,0 +1,49 @@
+package schema
+
+import (
+	"strings"
+
+	"github.com/singh-anurag-7991/data-guard/internal/domain"
+)
+
+// Kinds of observed values besides those of kindOf
+const (
+	KindNull  = "null"  // only nulls were seen
+	KindMixed = "mixed" // values of several kinds
+)
+
+// Observe describes the shape of records for drift detection: every field path, objects and
+// arrays included, with the kind of its values ("string", "number", "boolean", "timestamp",
+// "time", "uuid", "ip", "object", "array", KindNull or KindMixed) prefixed by "optional" when
+// some records lack it and "nullable" when it was null. Kinds are coarser than Infer's types
+// so that a batch of whole numbers or of digit-only strings does not look like a retype.
+func Observe(records []domain.Record) domain.Schema {
+	in := newInferrer(records)
+	observed := domain.Schema{}
+	for path, st := range in.stats {
+		var keywords []string
+		if in.optional(path) {
+			keywords = append(keywords, "optional")
+		}
+		if st.nulls > 0 {
+			keywords = append(keywords, "nullable")
+		}
+		observed[path] = strings.Join(append(keywords, st.kind()), " ")
+	}
+	return observed
+}
+
+// kind returns the kind all non-null values share
+func (st *fieldStats) kind() string {
+	kind := KindNull
+	for k := range st.kinds {
+		switch {
+		case k == "null":
+		case kind != KindNull:
+			return KindMixed
+		default:
+			kind = k
+		}
+	}
+	return kind
+}
@@ -0,0 +1,30 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/singh-anurag-7991/data-guard/internal/domain"
+)
+
+func TestObserve(t *testing.T) {
+	records := []domain.Record{
+		{"id": 1, "amount": 9.5, "tags": []interface{}{"a"}, "address": map[string]interface{}{"city": "Berlin"}, "code": "01"},
+		{"id": 2, "amount": nil, "tags": []interface{}{}, "address": nil, "code": 2},
+		{"id": 3, "amount": 3, "tags": []interface{}{"b"}, "address": map[string]interface{}{"city": "Paris"}, "deleted": nil},
+	}
+
+	want := domain.Schema{
+		"id":           "number",
+		"amount":       "nullable number",
+		"tags":         "array",
+		"tags[*]":      "string",
+		"address":      "nullable object",
+		"address.city": "optional string", // the address of the second record is null
+		"code":         "optional mixed",
+		"deleted":      "optional nullable null",
+	}
+	if got := Observe(records); !reflect.DeepEqual(got, want) {
+		t.Errorf("Observe() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,241 @@
+// Package schema parses and checks the field types of a domain.Schema.
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/singh-anurag-7991/data-guard/internal/operators"
+)
+
+// Type names. Parameters follow a colon: "integer:0..100", "decimal:10,2", "enum:a|b",
+// "timestamp:epoch_millis". The keywords "optional" (the field may be missing) and "nullable"
+// (the value may be null) come first: "optional nullable email".
+const (
+	String    = "string"
+	Number    = "number"
+	Boolean   = "boolean"
+	Integer   = "integer"
+	Decimal   = "decimal"
+	UUID      = "uuid"
+	Email     = "email"
+	URL       = "url"
+	IP        = "ip"
+	Country   = "country"  // ISO 3166-1 alpha-2, e.g. "DE"
+	Currency  = "currency" // ISO 4217, e.g. "EUR"
+	Enum      = "enum"
+	Timestamp = "timestamp"
+	Date      = "date"
+	Time      = "time"
+)
+
+// Patterns shared with the SQL translation, valid in both Go and Postgres regular expressions
+const (
+	UUIDPattern  = `^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`
+	EmailPattern = `^[^@[:space:]]+@[^@[:space:]]+\.[^@[:space:]]+$`
+	URLPattern   = `^[hH][tT][tT][pP][sS]?://[^/?#[:space:]]+([/?#][^[:space:]]*)?$`
+	IPv4Pattern  = `^((25[0-5]|2[0-4][0-9]|1[0-9][0-9]|[1-9]?[0-9])\.){3}(25[0-5]|2[0-4][0-9]|1[0-9][0-9]|[1-9]?[0-9])$`
+)
+
+var (
+	uuidRe  = regexp.MustCompile(UUIDPattern)
+	emailRe = regexp.MustCompile(EmailPattern)
+	urlRe   = regexp.MustCompile(URLPattern)
+)
+
+// Type is a parsed schema type
+type Type struct {
+	Name      string
+	Spec      string       // as written in the schema without keywords, e.g. "integer:0..100"
+	Optional  bool         // the field may be missing
+	Nullable  bool         // the value may be null
+	Min, Max  *json.Number // integer range (inclusive)
+	Precision int          // decimal digits in total, 0 when unbounded
+	Scale     int          // decimal digits after the point
+	Values    []string     // enum values
+	Format    string       // timestamp, date and time format
+}
+
+// Parse reads a schema type. Unknown names are accepted and never fail a value.
+func Parse(spec string) (Type, error) {
+	var t Type
+	for {
+		if rest, ok := strings.CutPrefix(spec, "optional "); ok {
+			t.Optional, spec = true, strings.TrimSpace(rest)
+		} else if rest, ok := strings.CutPrefix(spec, "nullable "); ok {
+			t.Nullable, spec = true, strings.TrimSpace(rest)
+		} else {
+			break
+		}
+	}
+	name, param, _ := strings.Cut(spec, ":")
+	t.Name, t.Spec = name, spec
+
+	switch name {
+	case Integer:
+		if param == "" {
+			return t, nil
+		}
+		lo, hi, ok := strings.Cut(param, "..")
+		if !ok {
+			return t, fmt.Errorf("integer range must look like 0..100")
+		}
+		var err error
+		if t.Min, err = parseBound(lo); err != nil {
+			return t, err
+		}
+		if t.Max, err = parseBound(hi); err != nil {
+			return t, err
+		}
+	case Decimal:
+		if param == "" {
+			return t, nil
+		}
+		p, s, _ := strings.Cut(param, ",")
+		var err error
+		if t.Precision, err = strconv.Atoi(p); err != nil || t.Precision < 1 {
+			return t, fmt.Errorf("decimal precision must be a positive integer")
+		}
+		if s != "" {
+			if t.Scale, err = strconv.Atoi(s); err != nil || t.Scale < 0 || t.Scale > t.Precision {
+				return t, fmt.Errorf("decimal scale must be between 0 and the precision")
+			}
+		}
+	case Enum:
+		if param == "" {
+			return t, fmt.Errorf("enum needs values, e.g. enum:active|inactive")
+		}
+		t.Values = strings.Split(param, "|")
+	case Timestamp, Date, Time:
+		t.Format = param
+		if name == Date && t.Format == "" {
+			t.Format = "2006-01-02"
+		}
+	case String, Number, Boolean, UUID, Email, URL, IP, Country, Currency:
+		if param != "" {
+			return t, fmt.Errorf("%s takes no parameters", name)
+		}
+	}
+	return t, nil
+}
+
+func parseBound(s string) (*json.Number, error) {
+	if s == "" {
+		return nil, nil
+	}
+	n, ok := operators.NumericString(s)
+	if !ok {
+		return nil, fmt.Errorf("invalid integer bound %q", s)
+	}
+	return &n, nil
+}
+
+// Known reports whether the type is checked at all
+func (t Type) Known() bool {
+	switch t.Name {
+	case String, Number, Boolean, Integer, Decimal, UUID, Email, URL, IP, Country, Currency, Enum, Timestamp, Date, Time:
+		return true
+	}
+	return false
+}
+
+// Valid reports whether v has the type. Null values only pass nullable and unknown types.
+func (t Type) Valid(v interface{}) bool {
+	if !t.Known() || (v == nil && t.Nullable) {
+		return true
+	}
+	s, isString := v.(string)
+
+	switch t.Name {
+	case String:
+		return isString
+	case Number:
+		_, ok := operators.ToFloat(v)
+		return ok
+	case Boolean:
+		_, ok := v.(bool)
+		return ok
+	case Integer:
+		if isInt, _ := operators.IsInteger(v, false); !isInt {
+			return false
+		}
+		if t.Min != nil {
+			if c, ok := operators.Compare(v, *t.Min, false); !ok || c < 0 {
+				return false
+			}
+		}
+		if t.Max != nil {
+			if c, ok := operators.Compare(v, *t.Max, false); !ok || c > 0 {
+				return false
+			}
+		}
+		return true
+	case Decimal:
+		if d, ok := operators.FormatDecimal(v); ok {
+			s, isString = d, true
+		}
+		return isString && t.validDecimal(s)
+	case UUID:
+		if _, ok := v.([16]byte); ok { // pgx uuid
+			return true
+		}
+		return isString && uuidRe.MatchString(s)
+	case Email:
+		return isString && emailRe.MatchString(s)
+	case URL:
+		return isString && urlRe.MatchString(s)
+	case IP:
+		switch v.(type) {
+		case netip.Addr, netip.Prefix: // pgx inet
+			return true
+		}
+		_, err := netip.ParseAddr(s)
+		return isString && err == nil
+	case Country:
+		return isString && countrySet[s]
+	case Currency:
+		return isString && currencySet[s]
+	case Enum:
+		for _, allowed := range t.Values {
+			if isString && s == allowed {
+				return true
+			}
+		}
+		return false
+	case Timestamp, Date:
+		_, ok := operators.ParseTime(v, t.Format, time.UTC)
+		return ok
+	default: // Time
+		_, ok := operators.ParseTimeOfDay(v, t.Format)
+		return ok
+	}
+}
+
+// validDecimal checks the digits of a decimal in text form ("-12.50"): trailing zeros of the
+// fraction and leading zeros of the integer part do not count
+func (t Type) validDecimal(s string) bool {
+	s = strings.TrimLeft(s, "+-")
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole+frac == "" || strings.Trim(whole+frac, "0123456789") != "" {
+		return false
+	}
+	if t.Precision == 0 {
+		return true
+	}
+	whole = strings.TrimLeft(whole, "0")
+	frac = strings.TrimRight(frac, "0")
+	return len(frac) <= t.Scale && len(whole) <= t.Precision-t.Scale
+}
+
+// DecimalPattern is the Postgres regular expression for the text form of the decimal type
+func (t Type) DecimalPattern() string {
+	if t.Precision == 0 {
+		return `^[-+]?[0-9]*(\.[0-9]*)?$`
+	}
+	return fmt.Sprintf(`^[-+]?0*[0-9]{0,%d}(\.[0-9]{0,%d}0*)?$`, t.Precision-t.Scale, t.Scale)
+}
@@ -0,0 +1,98 @@
+package schema
+
+import (
+	"encoding/json"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestType_Valid(t *testing.T) {
+	tests := []struct {
+		spec string
+		val  interface{}
+		want bool
+	}{
+		{"string", "x", true},
+		{"string", 1.0, false},
+		{"number", 2.5, true},
+		{"boolean", true, true},
+
+		{"integer", 2.0, true},
+		{"integer", 2.5, false},
+		{"integer", "2", false},
+		{"integer:1..100", int64(100), true},
+		{"integer:1..100", 0.0, false},
+		{"integer:..0", -3.0, true},
+		{"integer:..0", 1.0, false},
+		{"integer", json.Number("1.0000000000000000001"), false},
+		{"integer:..9007199254740992", int64(9007199254740993), false},
+		{"integer:9007199254740993..", int64(9007199254740993), true},
+
+		{"decimal", "12.345", true},
+		{"decimal", "12a", false},
+		{"decimal:5,2", 123.45, true},
+		{"decimal:5,2", "123.4500", true},
+		{"decimal:5,2", "0.5", true},
+		{"decimal:5,2", 123.456, false},
+		{"decimal:5,2", "1234.5", false},
+		{"decimal:5,2", ".", false},
+
+		{"uuid", "3f1c2a9e-5b7d-4c1e-9a2b-0d4e6f8a1b2c", true},
+		{"uuid", [16]byte{1}, true},
+		{"uuid", "3f1c2a9e5b7d4c1e9a2b0d4e6f8a1b2c", false},
+		{"email", "ada@example.com", true},
+		{"email", "ada@example", false},
+		{"url", "https://example.com/a?b=c", true},
+		{"url", "ftp://example.com", false},
+		{"ip", "10.0.0.1", true},
+		{"ip", "2001:db8::1", true},
+		{"ip", "10.0.0.256", false},
+		{"ip", netip.MustParsePrefix("10.0.0.0/24"), true},
+		{"country", "DE", true},
+		{"country", "de", false},
+		{"country", "XX", false},
+		{"currency", "EUR", true},
+		{"currency", "EURO", false},
+		{"enum:active|inactive", "active", true},
+		{"enum:active|inactive", "deleted", false},
+
+		{"timestamp", "2024-05-01T12:00:00Z", true},
+		{"timestamp", time.Now(), true},
+		{"date", "2024-05-01", true},
+		{"time", "12:00", true},
+
+		{"object", map[string]interface{}{}, true}, // Unknown types are not checked
+		{"email", nil, false},
+		{"nullable email", nil, true},
+		{"optional nullable email", "ada", false},
+	}
+
+	for _, tt := range tests {
+		typ, err := Parse(tt.spec)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.spec, err)
+		}
+		if got := typ.Valid(tt.val); got != tt.want {
+			t.Errorf("%s.Valid(%v) = %v, want %v", tt.spec, tt.val, got, tt.want)
+		}
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, spec := range []string{"integer:1-100", "integer:a..b", "decimal:0", "decimal:2,3", "enum", "uuid:v4"} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("expected %q to be rejected", spec)
+		}
+	}
+}
+
+func TestParse_Keywords(t *testing.T) {
+	typ, err := Parse("optional nullable decimal:10,2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !typ.Optional || !typ.Nullable || typ.Spec != "decimal:10,2" || typ.Precision != 10 || typ.Scale != 2 {
+		t.Errorf("unexpected type: %+v", typ)
+	}
+}
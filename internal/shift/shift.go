@@ -0,0 +1,246 @@
+// Package shift measures how far the distribution of a field moved from a baseline: the
+// population stability index, the Kolmogorov-Smirnov statistic of numbers, a chi-square test
+// of category frequencies and the largest change in a category's share.
+package shift
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/singh-anurag-7991/data-guard/internal/operators"
+)
+
+// Distribution holds the non-null values of a field
+type Distribution struct {
+	numbers    []float64
+	categories map[string]int // values by their key, numbers included
+	total      int
+	numeric    bool // every value is a number
+}
+
+func NewDistribution() *Distribution {
+	return &Distribution{categories: map[string]int{}, numeric: true}
+}
+
+// Add records a value; nil values are ignored
+func (d *Distribution) Add(val interface{}) {
+	if val == nil {
+		return
+	}
+	d.total++
+	if f, ok := operators.ToFloat(val); ok {
+		d.numbers = append(d.numbers, f)
+		key, exact := operators.FormatDecimal(val)
+		if !exact {
+			key = strconv.FormatFloat(f, 'g', -1, 64) // Infinities and NaN
+		}
+		d.categories["n"+key]++
+		return
+	}
+	d.numeric = false
+	if s, ok := val.(string); ok {
+		d.categories["s"+s]++
+		return
+	}
+	d.categories[fmt.Sprintf("%T:%v", val, val)]++
+}
+
+// Len returns the number of values
+func (d *Distribution) Len() int {
+	return d.total
+}
+
+// Numeric reports whether there are values and all of them are numbers
+func (d *Distribution) Numeric() bool {
+	return d.total > 0 && d.numeric
+}
+
+// psiBins is how many quantile bins of the baseline numbers are compared
+const psiBins = 10
+
+// psiFloor stands in for empty bins, whose log ratio would be infinite
+const psiFloor = 1e-4
+
+// PSI returns the population stability index of current against baseline,
+// sum((c - b) * ln(c / b)) over the shares of each bin. Numbers are binned by the deciles of
+// the baseline, other values are their own bin. Below 0.1 is usually read as stable, above
+// 0.25 as a significant shift.
+func PSI(baseline, current *Distribution) float64 {
+	var b, c []float64
+	if baseline.Numeric() && current.Numeric() {
+		edges := binEdges(baseline.numbers)
+		b, c = binShares(baseline.numbers, edges), binShares(current.numbers, edges)
+	} else {
+		keys := categoryKeys(baseline, current)
+		b, c = shares(baseline, keys), shares(current, keys)
+	}
+
+	var psi float64
+	for i := range b {
+		bs, cs := math.Max(b[i], psiFloor), math.Max(c[i], psiFloor)
+		psi += (cs - bs) * math.Log(cs/bs)
+	}
+	return psi
+}
+
+// binEdges returns the distinct inner deciles of numbers
+func binEdges(numbers []float64) []float64 {
+	sorted := sortedCopy(numbers)
+	var edges []float64
+	for i := 1; i < psiBins; i++ {
+		edge := sorted[i*len(sorted)/psiBins]
+		if len(edges) == 0 || edge > edges[len(edges)-1] {
+			edges = append(edges, edge)
+		}
+	}
+	return edges
+}
+
+// binShares returns the share of numbers in each bin, bin i holding edges[i-1] <= x < edges[i]
+func binShares(numbers []float64, edges []float64) []float64 {
+	out := make([]float64, len(edges)+1)
+	for _, x := range numbers {
+		out[sort.Search(len(edges), func(i int) bool { return edges[i] > x })]++
+	}
+	for i := range out {
+		out[i] /= float64(len(numbers))
+	}
+	return out
+}
+
+// KS returns the two-sample Kolmogorov-Smirnov statistic, the largest distance between the
+// empirical distribution functions of the numbers; ok is false unless both are numeric
+func KS(baseline, current *Distribution) (float64, bool) {
+	if !baseline.Numeric() || !current.Numeric() {
+		return 0, false
+	}
+	a, b := sortedCopy(baseline.numbers), sortedCopy(current.numbers)
+
+	var d float64
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		x := math.Min(a[i], b[j])
+		for i < len(a) && a[i] == x {
+			i++
+		}
+		for j < len(b) && b[j] == x {
+			j++
+		}
+		d = math.Max(d, math.Abs(float64(i)/float64(len(a))-float64(j)/float64(len(b))))
+	}
+	return d, true
+}
+
+// ChiSquare tests whether baseline and current share category frequencies with Pearson's
+// chi-square test of homogeneity. It returns the statistic, its degrees of freedom (categories
+// minus one) and the p-value, the probability of a difference at least this large by chance.
+func ChiSquare(baseline, current *Distribution) (stat float64, df int, p float64) {
+	keys := categoryKeys(baseline, current)
+	nb, nc := float64(baseline.total), float64(current.total)
+	n := nb + nc
+	for _, k := range keys {
+		ob, oc := float64(baseline.categories[k]), float64(current.categories[k])
+		col := ob + oc
+		eb, ec := col*nb/n, col*nc/n
+		stat += (ob-eb)*(ob-eb)/eb + (oc-ec)*(oc-ec)/ec
+	}
+	df = len(keys) - 1
+	if df < 1 {
+		return 0, 0, 1 // A single category cannot differ
+	}
+	return stat, df, upperGamma(float64(df)/2, stat/2)
+}
+
+// ShareDelta returns the largest absolute change in the share of one category and that
+// category's key with its type prefix stripped
+func ShareDelta(baseline, current *Distribution) (delta float64, category string) {
+	keys := categoryKeys(baseline, current)
+	b, c := shares(baseline, keys), shares(current, keys)
+	for i, k := range keys {
+		if d := math.Abs(c[i] - b[i]); d > delta {
+			delta, category = d, k
+		}
+	}
+	if len(category) > 0 && (category[0] == 'n' || category[0] == 's') {
+		category = category[1:]
+	}
+	return delta, category
+}
+
+// categoryKeys returns the categories seen in either distribution, sorted
+func categoryKeys(a, b *Distribution) []string {
+	seen := map[string]bool{}
+	var keys []string
+	for _, d := range []*Distribution{a, b} {
+		for k := range d.categories {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func shares(d *Distribution, keys []string) []float64 {
+	out := make([]float64, len(keys))
+	for i, k := range keys {
+		out[i] = float64(d.categories[k]) / float64(d.total)
+	}
+	return out
+}
+
+func sortedCopy(numbers []float64) []float64 {
+	sorted := append([]float64(nil), numbers...)
+	sort.Float64s(sorted)
+	return sorted
+}
+
+// upperGamma returns the regularized upper incomplete gamma function Q(a, x), by its series
+// below a+1 and its continued fraction above (Numerical Recipes, gammq)
+func upperGamma(a, x float64) float64 {
+	if x <= 0 {
+		return 1
+	}
+	lg, _ := math.Lgamma(a)
+	prefix := math.Exp(-x + a*math.Log(x) - lg)
+
+	if x < a+1 {
+		sum, term := 1/a, 1/a
+		for n := 1.0; n < 500; n++ {
+			term *= x / (a + n)
+			sum += term
+			if math.Abs(term) < math.Abs(sum)*1e-15 {
+				break
+			}
+		}
+		return math.Max(0, 1-sum*prefix)
+	}
+
+	const tiny = 1e-300
+	b := x + 1 - a
+	c, d := 1/tiny, 1/b
+	h := d
+	for i := 1.0; i < 500; i++ {
+		an := -i * (i - a)
+		b += 2
+		d = an*d + b
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = b + an/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		delta := d * c
+		h *= delta
+		if math.Abs(delta-1) < 1e-15 {
+			break
+		}
+	}
+	return prefix * h
+}
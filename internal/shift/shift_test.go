@@ -0,0 +1,110 @@
+package shift
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func distribution(values ...interface{}) *Distribution {
+	d := NewDistribution()
+	for _, v := range values {
+		d.Add(v)
+	}
+	return d
+}
+
+func normal(rng *rand.Rand, n int, mean, sd float64) *Distribution {
+	d := NewDistribution()
+	for i := 0; i < n; i++ {
+		d.Add(rng.NormFloat64()*sd + mean)
+	}
+	return d
+}
+
+func TestPSI(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	baseline := normal(rng, 5000, 100, 10)
+
+	if psi := PSI(baseline, normal(rng, 5000, 100, 10)); psi > 0.05 {
+		t.Errorf("expected a stable PSI for the same distribution, got %v", psi)
+	}
+	if psi := PSI(baseline, normal(rng, 5000, 110, 10)); psi < 0.25 {
+		t.Errorf("expected a significant PSI for a shifted mean, got %v", psi)
+	}
+
+	// Categories are their own bins
+	before := distribution("a", "a", "b", "b")
+	after := distribution("a", "a", "a", "b")
+	want := (0.75-0.5)*math.Log(0.75/0.5) + (0.25-0.5)*math.Log(0.25/0.5)
+	if psi := PSI(before, after); math.Abs(psi-want) > 1e-12 {
+		t.Errorf("PSI() = %v, want %v", psi, want)
+	}
+}
+
+func TestKS(t *testing.T) {
+	if d, ok := KS(distribution(1, 2, 3, 4), distribution(1, 2, 3, 4)); !ok || d != 0 {
+		t.Errorf("expected identical samples to have D = 0, got %v, %v", d, ok)
+	}
+	if d, _ := KS(distribution(1, 2, 3, 4), distribution(3, 4, 5, 6)); d != 0.5 {
+		t.Errorf("expected D = 0.5, got %v", d)
+	}
+	if d, _ := KS(distribution(1, 2), distribution(5, 6)); d != 1 {
+		t.Errorf("expected disjoint samples to have D = 1, got %v", d)
+	}
+	if _, ok := KS(distribution("a"), distribution(1)); ok {
+		t.Errorf("expected KS to need numbers")
+	}
+
+	// Prices suddenly rounded to whole numbers
+	rng := rand.New(rand.NewSource(2))
+	exact, rounded := NewDistribution(), NewDistribution()
+	for i := 0; i < 1000; i++ {
+		p := 5 + rng.Float64()*10
+		exact.Add(p)
+		rounded.Add(math.Round(p))
+	}
+	if d, _ := KS(exact, rounded); d < 0.04 {
+		t.Errorf("expected rounding to move the distribution, got D = %v", d)
+	}
+}
+
+func TestChiSquare(t *testing.T) {
+	same := []interface{}{"DE", "DE", "FR", "IT"}
+	if stat, df, p := ChiSquare(distribution(same...), distribution(same...)); stat != 0 || df != 2 || p != 1 {
+		t.Errorf("expected no difference, got %v %v %v", stat, df, p)
+	}
+
+	// 2x2 table [[50, 50], [70, 30]]: chi-square 8.333 with 1 degree of freedom, p = 0.00389
+	var before, after []interface{}
+	for i := 0; i < 100; i++ {
+		before = append(before, map[bool]string{true: "a", false: "b"}[i < 50])
+		after = append(after, map[bool]string{true: "a", false: "b"}[i < 70])
+	}
+	stat, df, p := ChiSquare(distribution(before...), distribution(after...))
+	if math.Abs(stat-8.3333) > 1e-3 || df != 1 || math.Abs(p-0.003892) > 1e-5 {
+		t.Errorf("ChiSquare() = %v, %v, %v; want 8.333, 1, 0.00389", stat, df, p)
+	}
+}
+
+func TestUpperGamma(t *testing.T) {
+	tests := []struct{ a, x, want float64 }{
+		{0.5, 1.9207, 0.05},  // chi-square, 1 df, 3.841
+		{1, 2, math.Exp(-2)}, // exponential tail
+		{2.5, 5.5, 0.05138},  // chi-square, 5 df, 11
+		{5, 1, 0.99634},      // series branch
+		{10, 40, 3.9259e-9},  // far tail
+	}
+	for _, tt := range tests {
+		if got := upperGamma(tt.a, tt.x); math.Abs(got-tt.want) > 1e-3*tt.want {
+			t.Errorf("upperGamma(%v, %v) = %v, want %v", tt.a, tt.x, got, tt.want)
+		}
+	}
+}
+
+func TestShareDelta(t *testing.T) {
+	delta, category := ShareDelta(distribution("a", "a", "b", "b"), distribution("a", "a", "a", "c"))
+	if math.Abs(delta-0.5) > 1e-12 || category != "b" {
+		t.Errorf("ShareDelta() = %v, %q; want 0.5, b", delta, category)
+	}
+}
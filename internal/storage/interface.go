@@ -2,14 +2,50 @@ package storage
 
 import (
 	"context"
+	"errors"
 
 	"github.com/singh-anurag-7991/data-guard/internal/alerting"
 	"github.com/singh-anurag-7991/data-guard/internal/domain"
 )
 
+// ErrNotFound is returned when a lookup list or snapshot does not exist
+var ErrNotFound = errors.New("not found")
+
+// MaxSnapshotRecords caps how many records of a batch are kept as the source's latest dataset
+const MaxSnapshotRecords = 100000
+
+// AlertStates adapts a Provider to the alerting state store
+func AlertStates(p Provider) alerting.StateManager {
+	return alertStates{p}
+}
+
+type alertStates struct {
+	p Provider
+}
+
+func (a alertStates) GetLastState(sourceID string) (alerting.State, error) {
+	return a.p.GetLastState(context.Background(), sourceID)
+}
+
+func (a alertStates) UpdateState(sourceID string, state alerting.State) error {
+	return a.p.UpdateState(context.Background(), sourceID, state)
+}
+
 type Provider interface {
 	SaveResult(ctx context.Context, res domain.ValidationResult) error
 	GetLastState(ctx context.Context, sourceID string) (alerting.State, error)
 	UpdateState(ctx context.Context, sourceID string, state alerting.State) error
 	GetRecentRuns(ctx context.Context, sourceID string, limit int) ([]domain.ValidationResult, error)
+	GetRuleHistory(ctx context.Context, sourceID, ruleID string, limit int) ([]domain.RuleSummaryPoint, error)
+	GetProfiles(ctx context.Context, sourceID string, limit int) ([]domain.RunProfile, error)
+
+	// Reference data for exists_in checks
+	SaveLookup(ctx context.Context, name string, values []interface{}) error
+	GetLookup(ctx context.Context, name string) ([]interface{}, error)
+	SaveSnapshot(ctx context.Context, sourceID string, records []domain.Record) error
+	GetSnapshot(ctx context.Context, sourceID string) ([]domain.Record, error)
+
+	// Observed shape of each source, the baseline of drift detection
+	SaveObservedSchema(ctx context.Context, sourceID string, observed domain.Schema) error
+	GetObservedSchema(ctx context.Context, sourceID string) (domain.Schema, error)
 }
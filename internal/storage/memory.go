@@ -2,6 +2,7 @@ package storage
 
 import (
 	"context"
+	"math"
 	"sort"
 	"sync"
 
@@ -13,12 +14,18 @@ type MemoryStore struct {
 	mu          sync.RWMutex
 	runs        []domain.ValidationResult
 	alertStates map[string]alerting.State
+	lookups     map[string][]interface{}
+	snapshots   map[string][]domain.Record
+	observed    map[string]domain.Schema
 }
 
 func NewMemoryStore() *MemoryStore {
 	return &MemoryStore{
 		runs:        make([]domain.ValidationResult, 0),
 		alertStates: make(map[string]alerting.State),
+		lookups:     make(map[string][]interface{}),
+		snapshots:   make(map[string][]domain.Record),
+		observed:    make(map[string]domain.Schema),
 	}
 }
 
@@ -71,3 +78,97 @@ func (m *MemoryStore) GetRecentRuns(ctx context.Context, sourceID string, limit
 
 	return filtered, nil
 }
+
+func (m *MemoryStore) GetProfiles(ctx context.Context, sourceID string, limit int) ([]domain.RunProfile, error) {
+	runs, _ := m.GetRecentRuns(ctx, sourceID, math.MaxInt)
+	var profiles []domain.RunProfile
+	for _, r := range runs {
+		if len(r.Profile) == 0 {
+			continue
+		}
+		profiles = append(profiles, domain.RunProfile{SourceID: r.SourceID, Timestamp: r.Timestamp, Fields: r.Profile})
+		if len(profiles) == limit {
+			break
+		}
+	}
+	return profiles, nil
+}
+
+func (m *MemoryStore) GetRuleHistory(ctx context.Context, sourceID, ruleID string, limit int) ([]domain.RuleSummaryPoint, error) {
+	m.mu.RLock()
+	total := len(m.runs)
+	m.mu.RUnlock()
+
+	runs, err := m.GetRecentRuns(ctx, sourceID, total)
+	if err != nil {
+		return nil, err
+	}
+
+	var points []domain.RuleSummaryPoint
+	for _, r := range runs {
+		for _, s := range r.RuleSummaries {
+			if ruleID != "" && s.RuleID != ruleID {
+				continue
+			}
+			points = append(points, domain.RuleSummaryPoint{SourceID: r.SourceID, Timestamp: r.Timestamp, RuleSummary: s})
+			if len(points) == limit {
+				return points, nil
+			}
+		}
+	}
+	return points, nil
+}
+
+func (m *MemoryStore) SaveLookup(ctx context.Context, name string, values []interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lookups[name] = values
+	return nil
+}
+
+func (m *MemoryStore) GetLookup(ctx context.Context, name string) ([]interface{}, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	values, ok := m.lookups[name]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return values, nil
+}
+
+func (m *MemoryStore) SaveSnapshot(ctx context.Context, sourceID string, records []domain.Record) error {
+	if len(records) > MaxSnapshotRecords {
+		records = records[:MaxSnapshotRecords]
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.snapshots[sourceID] = records
+	return nil
+}
+
+func (m *MemoryStore) GetSnapshot(ctx context.Context, sourceID string) ([]domain.Record, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	records, ok := m.snapshots[sourceID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return records, nil
+}
+
+func (m *MemoryStore) SaveObservedSchema(ctx context.Context, sourceID string, observed domain.Schema) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.observed[sourceID] = observed
+	return nil
+}
+
+func (m *MemoryStore) GetObservedSchema(ctx context.Context, sourceID string) (domain.Schema, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	observed, ok := m.observed[sourceID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return observed, nil
+}
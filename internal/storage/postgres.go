@@ -2,8 +2,12 @@ package storage
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 
+	"github.com/jackc/pgx/v5"
+
 	"github.com/singh-anurag-7991/data-guard/internal/alerting"
 	"github.com/singh-anurag-7991/data-guard/internal/domain"
 	"github.com/singh-anurag-7991/data-guard/internal/ingest/postgres"
@@ -25,13 +29,35 @@ func (r *Repository) SaveResult(ctx context.Context, res domain.ValidationResult
 	}
 	defer tx.Rollback(ctx)
 
+	var drift, metrics, anomalies, profile []byte // NULL when empty
+	if len(res.Drift) > 0 {
+		if drift, err = json.Marshal(res.Drift); err != nil {
+			return fmt.Errorf("failed to encode drift: %w", err)
+		}
+	}
+	if len(res.Metrics) > 0 {
+		if metrics, err = json.Marshal(res.Metrics); err != nil {
+			return fmt.Errorf("failed to encode metrics: %w", err)
+		}
+	}
+	if len(res.Anomalies) > 0 {
+		if anomalies, err = json.Marshal(res.Anomalies); err != nil {
+			return fmt.Errorf("failed to encode anomalies: %w", err)
+		}
+	}
+	if len(res.Profile) > 0 {
+		if profile, err = json.Marshal(res.Profile); err != nil {
+			return fmt.Errorf("failed to encode profile: %w", err)
+		}
+	}
+
 	// 1. Insert Run
 	var runID int
 	err = tx.QueryRow(ctx, `
-		INSERT INTO validation_runs (source_id, status, records_checked, rules_failed, created_at)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO validation_runs (source_id, status, records_checked, rules_failed, run_trigger, drift, metrics, anomalies, profile, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 		RETURNING id`,
-		res.SourceID, res.Status, res.RecordsChecked, res.RulesFailed, res.Timestamp,
+		res.SourceID, res.Status, res.RecordsChecked, res.RulesFailed, res.Trigger, drift, metrics, anomalies, profile, res.Timestamp,
 	).Scan(&runID)
 	if err != nil {
 		return fmt.Errorf("failed to insert validation run: %w", err)
@@ -43,9 +69,9 @@ func (r *Repository) SaveResult(ctx context.Context, res domain.ValidationResult
 			// Convert value to string safety
 			valStr := fmt.Sprintf("%v", e.Value)
 			_, err := tx.Exec(ctx, `
-				INSERT INTO validation_errors (run_id, rule_id, field, fail_value, reason)
-				VALUES ($1, $2, $3, $4, $5)`,
-				runID, e.RuleID, e.Field, valStr, e.Reason,
+				INSERT INTO validation_errors (run_id, rule_id, field, fail_value, reason, record_id)
+				VALUES ($1, $2, $3, $4, $5, $6)`,
+				runID, e.RuleID, e.Field, valStr, e.Reason, e.RecordID,
 			)
 			if err != nil {
 				return fmt.Errorf("failed to insert error: %w", err)
@@ -53,6 +79,27 @@ func (r *Repository) SaveResult(ctx context.Context, res domain.ValidationResult
 		}
 	}
 
+	// 3. Insert Rule Summaries
+	for _, s := range res.RuleSummaries {
+		checks, err := json.Marshal(s.Checks)
+		if err != nil {
+			return fmt.Errorf("failed to encode checks for rule %s: %w", s.RuleID, err)
+		}
+		samples, err := json.Marshal(s.SampleValues)
+		if err != nil {
+			return fmt.Errorf("failed to encode samples for rule %s: %w", s.RuleID, err)
+		}
+		_, err = tx.Exec(ctx, `
+			INSERT INTO validation_rule_summaries
+				(run_id, rule_id, field, severity, evaluated, skipped, schema_failed, passed, failed, failure_ratio, checks, sample_values)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+			runID, s.RuleID, s.Field, s.Severity, s.Evaluated, s.Skipped, s.SchemaFailed, s.Passed, s.Failed, s.FailureRatio, checks, samples,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert rule summary: %w", err)
+		}
+	}
+
 	return tx.Commit(ctx)
 }
 
@@ -85,7 +132,7 @@ func (r *Repository) UpdateState(ctx context.Context, sourceID string, state ale
 // GetRecentRuns fetches the latest validation runs, optionally filtered by sourceID
 func (r *Repository) GetRecentRuns(ctx context.Context, sourceID string, limit int) ([]domain.ValidationResult, error) {
 	query := `
-		SELECT id, source_id, status, records_checked, rules_failed, created_at
+		SELECT id, source_id, status, records_checked, rules_failed, COALESCE(run_trigger, ''), drift, metrics, anomalies, created_at
 		FROM validation_runs
 		WHERE ($1 = '' OR source_id = $1)
 		ORDER BY created_at DESC
@@ -101,11 +148,204 @@ func (r *Repository) GetRecentRuns(ctx context.Context, sourceID string, limit i
 	for rows.Next() {
 		var res domain.ValidationResult
 		var id int // Not currently part of domain model, but good to know
-		err := rows.Scan(&id, &res.SourceID, &res.Status, &res.RecordsChecked, &res.RulesFailed, &res.Timestamp)
+		var drift, metrics, anomalies []byte
+		err := rows.Scan(&id, &res.SourceID, &res.Status, &res.RecordsChecked, &res.RulesFailed, &res.Trigger, &drift, &metrics, &anomalies, &res.Timestamp)
 		if err != nil {
 			return nil, fmt.Errorf("scan failed: %w", err)
 		}
+		for _, col := range []struct {
+			data []byte
+			dst  interface{}
+		}{{drift, &res.Drift}, {metrics, &res.Metrics}, {anomalies, &res.Anomalies}} {
+			if col.data == nil {
+				continue
+			}
+			if err := json.Unmarshal(col.data, col.dst); err != nil {
+				return nil, fmt.Errorf("failed to decode run %d: %w", id, err)
+			}
+		}
 		results = append(results, res)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 	return results, nil
 }
+
+// GetProfiles fetches the profiles of the latest profiled runs of a source, newest first
+func (r *Repository) GetProfiles(ctx context.Context, sourceID string, limit int) ([]domain.RunProfile, error) {
+	rows, err := r.client.Pool().Query(ctx, `
+		SELECT source_id, created_at, profile
+		FROM validation_runs
+		WHERE source_id = $1 AND profile IS NOT NULL
+		ORDER BY created_at DESC
+		LIMIT $2`, sourceID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	var profiles []domain.RunProfile
+	for rows.Next() {
+		var p domain.RunProfile
+		var data []byte
+		if err := rows.Scan(&p.SourceID, &p.Timestamp, &data); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		if err := json.Unmarshal(data, &p.Fields); err != nil {
+			return nil, fmt.Errorf("failed to decode profile of %s: %w", sourceID, err)
+		}
+		profiles = append(profiles, p)
+	}
+	return profiles, rows.Err()
+}
+
+// GetRuleHistory fetches per-rule summaries of recent runs for trend charts, newest first
+func (r *Repository) GetRuleHistory(ctx context.Context, sourceID, ruleID string, limit int) ([]domain.RuleSummaryPoint, error) {
+	query := `
+		SELECT vr.source_id, vr.created_at, s.rule_id, s.field, s.severity,
+		       s.evaluated, s.skipped, s.schema_failed, s.passed, s.failed, s.failure_ratio, s.checks, s.sample_values
+		FROM validation_rule_summaries s
+		JOIN validation_runs vr ON vr.id = s.run_id
+		WHERE ($1 = '' OR vr.source_id = $1)
+		  AND ($2 = '' OR s.rule_id = $2)
+		ORDER BY vr.created_at DESC
+		LIMIT $3`
+
+	rows, err := r.client.Pool().Query(ctx, query, sourceID, ruleID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	var points []domain.RuleSummaryPoint
+	for rows.Next() {
+		var p domain.RuleSummaryPoint
+		var checks, samples []byte
+		err := rows.Scan(&p.SourceID, &p.Timestamp, &p.RuleID, &p.Field, &p.Severity,
+			&p.Evaluated, &p.Skipped, &p.SchemaFailed, &p.Passed, &p.Failed, &p.FailureRatio, &checks, &samples)
+		if err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		if len(checks) > 0 {
+			if err := json.Unmarshal(checks, &p.Checks); err != nil {
+				return nil, fmt.Errorf("failed to decode checks: %w", err)
+			}
+		}
+		if len(samples) > 0 {
+			if err := json.Unmarshal(samples, &p.SampleValues); err != nil {
+				return nil, fmt.Errorf("failed to decode samples: %w", err)
+			}
+		}
+		points = append(points, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return points, nil
+}
+
+// SaveLookup stores (or replaces) a named lookup list
+func (r *Repository) SaveLookup(ctx context.Context, name string, values []interface{}) error {
+	data, err := json.Marshal(values)
+	if err != nil {
+		return fmt.Errorf("failed to encode lookup %s: %w", name, err)
+	}
+	_, err = r.client.Pool().Exec(ctx, `
+		INSERT INTO lookup_lists (name, vals, updated_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (name) DO UPDATE
+		SET vals = EXCLUDED.vals, updated_at = NOW()`,
+		name, data,
+	)
+	return err
+}
+
+// GetLookup fetches a named lookup list
+func (r *Repository) GetLookup(ctx context.Context, name string) ([]interface{}, error) {
+	var data []byte
+	err := r.client.Pool().QueryRow(ctx, `SELECT vals FROM lookup_lists WHERE name = $1`, name).Scan(&data)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+
+	var values []interface{}
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("failed to decode lookup %s: %w", name, err)
+	}
+	return values, nil
+}
+
+// SaveSnapshot keeps the latest dataset of a source, capped at MaxSnapshotRecords records
+func (r *Repository) SaveSnapshot(ctx context.Context, sourceID string, records []domain.Record) error {
+	if len(records) > MaxSnapshotRecords {
+		records = records[:MaxSnapshotRecords]
+	}
+	data, err := json.Marshal(records)
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot of %s: %w", sourceID, err)
+	}
+	_, err = r.client.Pool().Exec(ctx, `
+		INSERT INTO source_snapshots (source_id, records, created_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (source_id) DO UPDATE
+		SET records = EXCLUDED.records, created_at = NOW()`,
+		sourceID, data,
+	)
+	return err
+}
+
+// GetSnapshot fetches the latest dataset of a source
+func (r *Repository) GetSnapshot(ctx context.Context, sourceID string) ([]domain.Record, error) {
+	var data []byte
+	err := r.client.Pool().QueryRow(ctx, `SELECT records FROM source_snapshots WHERE source_id = $1`, sourceID).Scan(&data)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+
+	var records []domain.Record
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("failed to decode snapshot of %s: %w", sourceID, err)
+	}
+	return records, nil
+}
+
+// SaveObservedSchema replaces the observed shape of a source
+func (r *Repository) SaveObservedSchema(ctx context.Context, sourceID string, observed domain.Schema) error {
+	data, err := json.Marshal(observed)
+	if err != nil {
+		return fmt.Errorf("failed to encode observed schema of %s: %w", sourceID, err)
+	}
+	_, err = r.client.Pool().Exec(ctx, `
+		INSERT INTO observed_schemas (source_id, fields, updated_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (source_id) DO UPDATE
+		SET fields = EXCLUDED.fields, updated_at = NOW()`,
+		sourceID, data,
+	)
+	return err
+}
+
+// GetObservedSchema fetches the observed shape of a source
+func (r *Repository) GetObservedSchema(ctx context.Context, sourceID string) (domain.Schema, error) {
+	var data []byte
+	err := r.client.Pool().QueryRow(ctx, `SELECT fields FROM observed_schemas WHERE source_id = $1`, sourceID).Scan(&data)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+
+	var observed domain.Schema
+	if err := json.Unmarshal(data, &observed); err != nil {
+		return nil, fmt.Errorf("failed to decode observed schema of %s: %w", sourceID, err)
+	}
+	return observed, nil
+}
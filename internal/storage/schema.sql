@@ -3,23 +3,80 @@
 CREATE TABLE IF NOT EXISTS validation_runs (
     id SERIAL PRIMARY KEY,
     source_id TEXT NOT NULL,
-    status TEXT NOT NULL, -- "PASS", "FAIL"
+    status TEXT NOT NULL, -- "PASS", "WARN", "FAIL"
     records_checked INT NOT NULL,
     rules_failed INT NOT NULL,
+    run_trigger TEXT, -- NULL for validated data, "freshness" for overdue sources
+    drift JSONB, -- changes in the shape of the data since earlier runs
+    metrics JSONB, -- metric name -> value, the time series anomalies are detected on
+    anomalies JSONB, -- metrics far off their baseline
+    profile JSONB, -- per-field distribution, when the run was profiled
     created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
 );
 
+ALTER TABLE validation_runs ADD COLUMN IF NOT EXISTS run_trigger TEXT;
+ALTER TABLE validation_runs ADD COLUMN IF NOT EXISTS drift JSONB;
+ALTER TABLE validation_runs ADD COLUMN IF NOT EXISTS metrics JSONB;
+ALTER TABLE validation_runs ADD COLUMN IF NOT EXISTS anomalies JSONB;
+ALTER TABLE validation_runs ADD COLUMN IF NOT EXISTS profile JSONB;
+
 CREATE TABLE IF NOT EXISTS validation_errors (
     id SERIAL PRIMARY KEY,
     run_id INT REFERENCES validation_runs(id) ON DELETE CASCADE,
     rule_id TEXT NOT NULL,
     field TEXT NOT NULL,
     fail_value TEXT, -- Stores value as string
-    reason TEXT NOT NULL
+    reason TEXT NOT NULL,
+    record_id TEXT -- key of the failing record, or "#<index>" within the batch
 );
 
+CREATE INDEX IF NOT EXISTS idx_validation_runs_source ON validation_runs (source_id, created_at DESC);
+
+ALTER TABLE validation_errors ADD COLUMN IF NOT EXISTS record_id TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_validation_errors_record ON validation_errors (record_id);
+
+CREATE TABLE IF NOT EXISTS validation_rule_summaries (
+    id SERIAL PRIMARY KEY,
+    run_id INT REFERENCES validation_runs(id) ON DELETE CASCADE,
+    rule_id TEXT NOT NULL,
+    field TEXT NOT NULL,
+    severity TEXT NOT NULL,
+    evaluated INT NOT NULL,
+    skipped INT NOT NULL,
+    schema_failed INT NOT NULL DEFAULT 0,
+    passed INT NOT NULL,
+    failed INT NOT NULL,
+    failure_ratio DOUBLE PRECISION NOT NULL,
+    checks JSONB, -- per-check failure counts
+    sample_values JSONB
+);
+
+ALTER TABLE validation_rule_summaries ADD COLUMN IF NOT EXISTS schema_failed INT NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_rule_summaries_run ON validation_rule_summaries (run_id);
+CREATE INDEX IF NOT EXISTS idx_rule_summaries_rule ON validation_rule_summaries (rule_id);
+
 CREATE TABLE IF NOT EXISTS alert_states (
     source_id TEXT PRIMARY KEY,
-    last_status TEXT NOT NULL, -- "PASS", "FAIL"
+    last_status TEXT NOT NULL, -- "PASS", "WARN", "FAIL"
     last_alerted_at TIMESTAMP WITH TIME ZONE
 );
+
+CREATE TABLE IF NOT EXISTS lookup_lists (
+    name TEXT PRIMARY KEY,
+    vals JSONB NOT NULL, -- reference values for exists_in checks
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS source_snapshots (
+    source_id TEXT PRIMARY KEY,
+    records JSONB NOT NULL, -- latest ingested dataset, referenced by exists_in checks
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS observed_schemas (
+    source_id TEXT PRIMARY KEY,
+    fields JSONB NOT NULL, -- field path -> observed kind, the baseline of drift detection
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
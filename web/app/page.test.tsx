@@ -35,6 +35,89 @@ describe('Dashboard', () => {
         })
     })
 
+    it('renders warning status', async () => {
+        const mockData = [
+            {
+                source_id: 'warn_src',
+                status: 'WARN',
+                records_checked: 50,
+                rules_failed: 1,
+                timestamp: new Date().toISOString(),
+            },
+        ]
+            ; (getRecentRuns as jest.Mock).mockResolvedValue(mockData)
+
+        render(<Dashboard />)
+
+        await waitFor(() => {
+            expect(screen.getByText('warn_src')).toBeInTheDocument()
+            expect(screen.getByText('WARN')).toBeInTheDocument()
+        })
+    })
+
+    it('marks overdue runs', async () => {
+        const mockData = [
+            {
+                source_id: 'late_src',
+                status: 'FAIL',
+                trigger: 'freshness',
+                records_checked: 0,
+                rules_failed: 1,
+                timestamp: new Date().toISOString(),
+            },
+        ]
+            ; (getRecentRuns as jest.Mock).mockResolvedValue(mockData)
+
+        render(<Dashboard />)
+
+        await waitFor(() => {
+            expect(screen.getByText('late_src')).toBeInTheDocument()
+            expect(screen.getByText('overdue')).toBeInTheDocument()
+        })
+    })
+
+    it('marks runs with schema drift', async () => {
+        const mockData = [
+            {
+                source_id: 'drift_src',
+                status: 'PASS',
+                records_checked: 10,
+                rules_failed: 0,
+                drift: [{ field: 'amount', kind: 'type_changed', before: 'number', after: 'string' }],
+                timestamp: new Date().toISOString(),
+            },
+        ]
+            ; (getRecentRuns as jest.Mock).mockResolvedValue(mockData)
+
+        render(<Dashboard />)
+
+        await waitFor(() => {
+            expect(screen.getByText('drift_src')).toBeInTheDocument()
+            expect(screen.getByText('schema drift')).toBeInTheDocument()
+        })
+    })
+
+    it('marks runs with anomalies', async () => {
+        const mockData = [
+            {
+                source_id: 'odd_src',
+                status: 'PASS',
+                records_checked: 10,
+                rules_failed: 0,
+                anomalies: [{ metric: 'record_count', value: 10, expected: 1000, score: -42, baseline: 'hour_of_week', samples: 4 }],
+                timestamp: new Date().toISOString(),
+            },
+        ]
+            ; (getRecentRuns as jest.Mock).mockResolvedValue(mockData)
+
+        render(<Dashboard />)
+
+        await waitFor(() => {
+            expect(screen.getByText('odd_src')).toBeInTheDocument()
+            expect(screen.getByText('anomaly')).toBeInTheDocument()
+        })
+    })
+
     it('renders empty state if no data', async () => {
         (getRecentRuns as jest.Mock).mockResolvedValue([])
 
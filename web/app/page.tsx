@@ -2,7 +2,7 @@
 
 import { useEffect, useState } from "react";
 import { getRecentRuns } from "@/lib/api";
-import { ValidationResult } from "@/lib/types";
+import { RunStatus, ValidationResult } from "@/lib/types";
 
 export default function Dashboard() {
   const [runs, setRuns] = useState<ValidationResult[]>([]);
@@ -71,6 +71,25 @@ export default function Dashboard() {
                       </td>
                       <td className="px-6 py-4 whitespace-nowrap text-sm font-medium text-gray-900">
                         {run.source_id}
+                        {run.trigger === "freshness" && (
+                          <span className="ml-2 text-xs font-semibold text-red-600">overdue</span>
+                        )}
+                        {run.drift && run.drift.length > 0 && (
+                          <span
+                            className="ml-2 text-xs font-semibold text-purple-600"
+                            title={run.drift.map((d) => `${d.field}: ${d.kind}`).join("\n")}
+                          >
+                            schema drift
+                          </span>
+                        )}
+                        {run.anomalies && run.anomalies.length > 0 && (
+                          <span
+                            className="ml-2 text-xs font-semibold text-orange-600"
+                            title={run.anomalies.map((a) => `${a.metric}: ${a.value} (expected ${a.expected})`).join("\n")}
+                          >
+                            anomaly
+                          </span>
+                        )}
                       </td>
                       <td className="px-6 py-4 whitespace-nowrap text-sm text-gray-500">
                         {run.records_checked}
@@ -93,7 +112,7 @@ export default function Dashboard() {
   );
 }
 
-function StatusBadge({ status }: { status: "PASS" | "FAIL" }) {
+function StatusBadge({ status }: { status: RunStatus }) {
   if (status === "PASS") {
     return (
       <span className="px-2 inline-flex text-xs leading-5 font-semibold rounded-full bg-green-100 text-green-800">
@@ -101,6 +120,13 @@ function StatusBadge({ status }: { status: "PASS" | "FAIL" }) {
       </span>
     );
   }
+  if (status === "WARN") {
+    return (
+      <span className="px-2 inline-flex text-xs leading-5 font-semibold rounded-full bg-yellow-100 text-yellow-800">
+        WARN
+      </span>
+    );
+  }
   return (
     <span className="px-2 inline-flex text-xs leading-5 font-semibold rounded-full bg-red-100 text-red-800">
       FAIL
@@ -1,4 +1,4 @@
-import { ValidationResult } from "./types";
+import { OperatorInfo, RuleSummaryPoint, ValidationResult } from "./types";
 
 const API_BASE_URL = "http://localhost:8080";
 
@@ -20,3 +20,37 @@ export async function getRecentRuns(sourceID?: string): Promise<ValidationResult
         return [];
     }
 }
+
+export async function getRuleStats(sourceID: string, ruleID?: string): Promise<RuleSummaryPoint[]> {
+    const url = new URL(`${API_BASE_URL}/api/rules/stats`);
+    url.searchParams.set("source_id", sourceID);
+    if (ruleID) {
+        url.searchParams.set("rule_id", ruleID);
+    }
+
+    try {
+        const res = await fetch(url.toString(), { cache: "no-store" });
+        if (!res.ok) {
+            throw new Error(`Failed to fetch rule stats: ${res.statusText}`);
+        }
+        const data = await res.json();
+        return data || [];
+    } catch (error) {
+        console.error("API Fetch Error:", error);
+        return [];
+    }
+}
+
+export async function getOperators(): Promise<OperatorInfo[]> {
+    try {
+        const res = await fetch(`${API_BASE_URL}/api/operators`, { cache: "no-store" });
+        if (!res.ok) {
+            throw new Error(`Failed to fetch operators: ${res.statusText}`);
+        }
+        const data = await res.json();
+        return data || [];
+    } catch (error) {
+        console.error("API Fetch Error:", error);
+        return [];
+    }
+}
@@ -1,15 +1,96 @@
+export type RunStatus = "PASS" | "WARN" | "FAIL";
+
 export interface ErrorDetail {
   rule_id: string;
   field: string;
   value: any;
   reason: string;
+  severity?: "error" | "warning" | "info";
+  record_id?: string;
+}
+
+export interface CheckSummary {
+  op: string;
+  failed: number;
+  statistic?: number;
+}
+
+export interface RuleSummary {
+  rule_id: string;
+  field: string;
+  severity: "error" | "warning" | "info";
+  evaluated: number;
+  skipped: number;
+  schema_failed: number; // skipped because a field the rule uses failed the schema
+  passed: number;
+  failed: number;
+  failure_ratio: number;
+  checks?: CheckSummary[];
+  sample_values?: any[];
+}
+
+export interface RuleSummaryPoint extends RuleSummary {
+  source_id: string;
+  timestamp: string; // ISO string
+}
+
+export interface Drift {
+  field: string;
+  kind: "added" | "removed" | "type_changed" | "nullability_changed";
+  before?: string;
+  after?: string;
+}
+
+export interface Anomaly {
+  metric: string; // e.g. "record_count", "null_ratio:email"
+  value: number;
+  expected: number;
+  score: number;
+  baseline: "hour_of_week" | "all";
+  samples: number;
+}
+
+export interface FieldProfile {
+  field: string;
+  count: number;
+  nulls: number;
+  distinct: number; // estimate
+  min?: number | string;
+  max?: number | string;
+  mean?: number;
+  quantiles?: Record<string, number>; // "p01" to "p99"
+  top_k?: { value: any; count: number }[];
 }
 
 export interface ValidationResult {
   source_id: string;
-  status: "PASS" | "FAIL";
+  status: RunStatus;
+  trigger?: "freshness"; // set on runs recorded because the source is overdue
   records_checked: number;
   rules_failed: number;
+  rule_summaries?: RuleSummary[];
   errors?: ErrorDetail[];
+  truncated?: boolean;
+  truncated_reason?: string;
+  drift?: Drift[]; // changes in the shape of the data since earlier runs
+  metrics?: Record<string, number>;
+  anomalies?: Anomaly[]; // metrics far off their baseline
+  profile?: FieldProfile[];
   timestamp: string; // ISO string
 }
+
+export interface OperatorArg {
+  name: string;
+  type: string;
+  required?: boolean;
+  description?: string;
+}
+
+export interface OperatorInfo {
+  name: string;
+  description: string;
+  args?: OperatorArg[];
+  null_safe: boolean;
+  scope: "record" | "dataset";
+  pushdown: boolean;
+}